@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/auth"
+	"github.com/seelentov/aifmt/internal/config"
+	"github.com/seelentov/aifmt/internal/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// AuthCmd - команда для управления API ключом
+var AuthCmd = &cobra.Command{
+	Use:   "auth",
+	Short: i18n.Sprintf("Manage the API key"),
+	Long: i18n.Sprintf(`Manage the OpenRouter API key.
+
+The key is looked up in the following order:
+  1. the AIFMT_API_KEY and OPENROUTER_API_KEY environment variables;
+  2. the command from the api_key_command configuration key (e.g. "pass show openrouter");
+  3. the file from the api_key_file configuration key;
+  4. the OS credential store, or the ~/.aifmt/credentials file if it is not available;
+  5. the api_key configuration key (deprecated, plain text).`),
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: i18n.Sprintf("Save the API key to the credential store"),
+	Long: i18n.Sprintf(`Save the API key to the OS credential store (macOS keychain, Secret Service
+on Linux). If the store is not available, the key is saved to the
+~/.aifmt/credentials file accessible only by the owner.
+The key is read from standard input. The api_key stored in plain text in the
+configuration is removed afterwards.`),
+	Example: i18n.Sprintf(`  # Type the key on the keyboard
+  aifmt auth login
+
+  # Pass the key from another program
+  pass show openrouter | aifmt auth login`),
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := readSecret(i18n.Sprintf("Enter the API key: "))
+		if err != nil {
+			exitWithError(err)
+		}
+
+		storeAPIKey(key)
+	},
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: i18n.Sprintf("Remove the API key from the credential store and the configuration"),
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		removed := false
+
+		if store := auth.DefaultStore(); store != nil {
+			err := store.Delete()
+			if err != nil && !errors.Is(err, auth.ErrNotFound) {
+				exitWithError(err)
+			}
+			removed = err == nil
+		}
+
+		if ok, err := config.Unset(viper.ConfigFileUsed(), "api_key"); err != nil {
+			exitWithError(err)
+		} else if ok {
+			removed = true
+		}
+
+		if !removed {
+			i18n.Printf("The API key was not stored\n")
+			return
+		}
+
+		i18n.Printf("API key removed\n")
+		for _, name := range auth.EnvVars {
+			if os.Getenv(name) != "" {
+				i18n.Printf("Warning: the key is still set in the environment variable %s\n", name)
+			}
+		}
+	},
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: i18n.Sprintf("Print the source of the API key"),
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		p := activeProfile()
+		key, source, err := resolveAPIKey(p)
+		if errors.Is(err, auth.ErrNotFound) {
+			i18n.Printf("The API key is not configured. Run 'aifmt auth login' or set the AIFMT_API_KEY environment variable.\n")
+			os.Exit(1)
+		}
+		if err != nil {
+			exitWithError(err)
+		}
+
+		if p.Name != "" {
+			i18n.Printf("Profile: %s\n", p.Name)
+		}
+		i18n.Printf("API key: %s\nSource: %s\n", config.Mask(key), source)
+		if viper.GetString("api_key") != "" {
+			i18n.Printf("Warning: the configuration stores api_key in plain text. Run 'aifmt auth login' to move it to the credential store.\n")
+		}
+	},
+}
+
+// resolveAPIKey возвращает API ключ и его источник для профиля. Если в профиле задан
+// собственный источник ключа, используется только он
+func resolveAPIKey(p *config.Profile) (string, string, error) {
+	if p.HasKeySource() {
+		return auth.ResolveExplicit(auth.Source{
+			Env:     p.APIKeyEnv,
+			Command: p.APIKeyCommand,
+			File:    p.APIKeyFile,
+		})
+	}
+
+	return auth.Resolve(auth.Source{
+		Command: viper.GetString("api_key_command"),
+		File:    viper.GetString("api_key_file"),
+		Plain:   viper.GetString("api_key"),
+	})
+}
+
+// apiKey возвращает API ключ для профиля или завершает программу, если он не настроен.
+// Для провайдеров, которым ключ не нужен, возвращается пустая строка
+func apiKey(p *config.Profile) string {
+	key, _, err := resolveAPIKey(p)
+	if errors.Is(err, auth.ErrNotFound) {
+		if !p.KeyRequired() {
+			return ""
+		}
+		i18n.Fprintf(os.Stderr, "The API token is not configured. Please run 'aifmt auth login' or set the AIFMT_API_KEY environment variable first.\n")
+		os.Exit(1)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+	return key
+}
+
+// storeAPIKey сохраняет ключ в хранилище учетных данных и удаляет открытый ключ из конфигурации
+func storeAPIKey(key string) {
+	store := auth.DefaultStore()
+	if store == nil {
+		exitWithError(i18n.Error("the credential store is not available"))
+	}
+
+	if err := store.Set(key); err != nil {
+		exitWithError(i18n.Errorf("error saving the API key: %w", err))
+	}
+
+	if _, err := config.Unset(viper.ConfigFileUsed(), "api_key"); err != nil {
+		exitWithError(err)
+	}
+
+	i18n.Printf("API key %s saved: %s\n", config.Mask(key), store.Name())
+}
+
+// readSecret читает секрет из стандартного ввода. Если ввод - терминал, эхо отключается
+func readSecret(prompt string) (string, error) {
+	terminal := false
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		terminal = true
+		fmt.Fprint(os.Stderr, prompt)
+		if stty("-echo") == nil {
+			defer func() {
+				stty("echo")
+				fmt.Fprintln(os.Stderr)
+			}()
+		}
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", i18n.Errorf("error reading the API key: %w", err)
+	}
+
+	key := strings.TrimSpace(line)
+	if key == "" {
+		if terminal {
+			return "", i18n.Error("no API key entered")
+		}
+		return "", i18n.Error("no API key passed to standard input")
+	}
+
+	return key, nil
+}
+
+// stty изменяет режим терминала стандартного ввода
+func stty(mode string) error {
+	c := exec.Command("stty", mode)
+	c.Stdin = os.Stdin
+	return c.Run()
+}
+
+func init() {
+	AuthCmd.AddCommand(authLoginCmd, authLogoutCmd, authStatusCmd)
+}
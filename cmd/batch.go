@@ -0,0 +1,408 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/seelentov/aifmt/internal/batch"
+	"github.com/seelentov/aifmt/internal/config"
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/project"
+	"github.com/seelentov/aifmt/internal/runner"
+	"github.com/seelentov/aifmt/internal/syntax"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Провайдеры пакетных заданий
+const (
+	batchBackendOpenAI = "openai" // Batch API OpenAI
+	batchBackendLocal  = "local"  // Локальное выполнение запросов по одному
+)
+
+// BatchCmd - команда для пакетного форматирования
+var BatchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: i18n.Sprintf("Batch formatting of a large number of files"),
+	Long: i18n.Sprintf(`Batch formatting: requests for all files are sent to the provider as one job
+in the OpenAI Batch API format (JSONL), and the results are applied later,
+when the job is done. This is cheaper and does not require waiting for a
+response for every file.
+
+Jobs are stored in the ~/.aifmt/batches directory. A result is applied only to
+files that have not changed since the job was submitted, and it goes through
+the usual checks: syntax, the verify_cmd command and the report.
+
+The openai backend uses the Batch API. The local backend runs the requests one
+by one with the regular API when the job is submitted and is suitable for
+providers without a batch API (OpenRouter, Ollama) and for testing.`),
+	Example: i18n.Sprintf(`  # Submit a job for all Go files
+  aifmt batch submit -l go $(git ls-files '*.go')
+
+  # State of all jobs or of one job
+  aifmt batch status
+  aifmt batch status 20250101-120000
+
+  # Download and apply the results
+  aifmt batch fetch 20250101-120000
+  aifmt batch apply 20250101-120000 --verify-cmd "go build ./..."`),
+}
+
+var batchSubmitCmd = &cobra.Command{
+	Use:   i18n.Sprintf("submit [flags] files..."),
+	Short: i18n.Sprintf("Submit a job to format files"),
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := &fmtOptions{explicit: make(map[string]bool)}
+		opts.Language, _ = cmd.Flags().GetString("language")
+		opts.model, _ = cmd.Flags().GetString("model")
+		opts.Mode, _ = cmd.Flags().GetString("mode")
+		opts.Comments, _ = cmd.Flags().GetBool("comments")
+		opts.CommentsLanguage = viper.GetString("comments_language")
+		opts.AllowCommentChanges = !viper.GetBool("check_comments")
+		backend, _ := cmd.Flags().GetString("backend")
+
+		for _, name := range []string{"language", "model", "mode", "comments"} {
+			opts.explicit[name] = cmd.Flags().Changed(name)
+		}
+		if !opts.explicit["model"] {
+			opts.model = defaultModel(opts.model)
+		}
+		if opts.Mode != project.ModeFormat && opts.Mode != project.ModeReview {
+			exitWithError(i18n.Errorf("invalid mode %q, allowed values: %s, %s", opts.Mode, project.ModeFormat, project.ModeReview))
+		}
+		if backend == "" {
+			backend = defaultBatchBackend()
+		}
+
+		store := batchStore()
+		b, err := batchBackend(backend, store)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		ctx, cancel := commandContext(0)
+		defer cancel()
+
+		client := apiClient()
+		log := logger()
+		job := &batch.Job{ID: store.NewID(), Backend: backend, Created: time.Now()}
+
+		var lines []*batch.Line
+		for _, pattern := range args {
+			files, err := filepath.Glob(pattern)
+			if err != nil {
+				log.Error("error parsing pattern", "pattern", pattern, "err", err)
+				continue
+			}
+
+			for _, file := range files {
+				fopts, ignored, err := opts.resolve(file)
+				if ignored {
+					log.Info("file skipped by the project configuration", "file", file)
+					continue
+				}
+				if err != nil {
+					log.Error("configuration error", "file", file, "err", err)
+					continue
+				}
+
+				content, err := os.ReadFile(file)
+				if err != nil {
+					log.Error("error reading file", "file", file, "err", err)
+					continue
+				}
+				abs, err := filepath.Abs(file)
+				if err != nil {
+					exitWithError(err)
+				}
+
+				// Резервные модели в задании не используются
+				fopts.Models = fopts.Models[:1]
+				model := fopts.Models[0]
+
+				dialog := runner.Dialog(ctx, string(content), abs, model, &fopts.Options)
+				body, err := client.RequestBody(model, dialog)
+				if err != nil {
+					exitWithError(err)
+				}
+
+				f := &batch.File{
+					CustomID: fmt.Sprintf("file-%d", len(job.Files)+1),
+					Path:     abs,
+					Hash:     batch.Hash(content),
+					Key:      batch.Key(model, dialog),
+					Options:  fopts.Options,
+				}
+				job.Files = append(job.Files, f)
+				lines = append(lines, &batch.Line{CustomID: f.CustomID, Method: "POST", URL: batch.Endpoint, Body: body})
+			}
+		}
+
+		if len(lines) == 0 {
+			exitWithError(i18n.Error("no files to submit"))
+		}
+
+		input, err := batch.Encode(lines)
+		if err != nil {
+			exitWithError(err)
+		}
+		if err := os.MkdirAll(store.Dir, 0700); err != nil {
+			exitWithError(err)
+		}
+		if err := os.WriteFile(store.InputPath(job.ID), input, 0600); err != nil {
+			exitWithError(err)
+		}
+
+		log.Info("submitting job", "files", len(lines), "backend", backend)
+		if job.RemoteID, err = b.Submit(ctx, input); err != nil {
+			exitWithError(err)
+		}
+		job.State, job.Total = batch.StateValidating, len(lines)
+
+		if err := store.Save(job); err != nil {
+			exitWithError(err)
+		}
+
+		i18n.Printf("Job %s submitted. Check its state with: aifmt batch status %s\n", job.ID, job.ID)
+	},
+}
+
+var batchStatusCmd = &cobra.Command{
+	Use:   i18n.Sprintf("status [job]"),
+	Short: i18n.Sprintf("Job state"),
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store := batchStore()
+
+		if len(args) == 0 {
+			jobs, err := store.List()
+			if err != nil {
+				exitWithError(err)
+			}
+			if len(jobs) == 0 {
+				i18n.Printf("No jobs\n")
+				return
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			i18n.Fprintf(w, "JOB\tBACKEND\tSTATE\tFILES\tSUBMITTED\tAPPLIED\n")
+			for _, job := range jobs {
+				applied := "-"
+				if !job.Applied.IsZero() {
+					applied = job.Applied.Format(time.DateTime)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", job.ID, job.Backend, job.State, len(job.Files), job.Created.Format(time.DateTime), applied)
+			}
+			w.Flush()
+			return
+		}
+
+		job := refreshBatch(store, args[0])
+		i18n.Printf("Job %s: %s, %d of %d completed, %d failed\n", job.ID, job.State, job.Completed, job.Total, job.Failed)
+	},
+}
+
+var batchFetchCmd = &cobra.Command{
+	Use:   i18n.Sprintf("fetch job"),
+	Short: i18n.Sprintf("Download the results of a finished job"),
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store := batchStore()
+		job := fetchBatch(store, refreshBatch(store, args[0]))
+		i18n.Printf("Results of job %s saved to %s. Apply them with: aifmt batch apply %s\n", job.ID, store.OutputPath(job.ID), job.ID)
+	},
+}
+
+var batchApplyCmd = &cobra.Command{
+	Use:   i18n.Sprintf("apply job"),
+	Short: i18n.Sprintf("Apply the job results to files"),
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		mode, _ := cmd.Flags().GetString("mode")
+		report, _ := cmd.Flags().GetBool("report")
+		verifyCmd, _ := cmd.Flags().GetString("verify-cmd")
+
+		if cmd.Flags().Changed("mode") && mode != project.ModeFormat && mode != project.ModeReview {
+			exitWithError(i18n.Errorf("invalid mode %q, allowed values: %s, %s", mode, project.ModeFormat, project.ModeReview))
+		}
+
+		store := batchStore()
+		job, err := store.Load(args[0])
+		if err != nil {
+			exitWithError(err)
+		}
+		if !job.Fetched {
+			job = fetchBatch(store, refreshBatch(store, job.ID))
+		}
+
+		data, err := os.ReadFile(store.OutputPath(job.ID))
+		if err != nil {
+			exitWithError(i18n.Errorf("error reading the job results: %w", err))
+		}
+		answers, err := batch.Decode(data)
+		if err != nil {
+			exitWithError(err)
+		}
+
+		log := logger()
+		r := &runner.Runner{Provider: batch.NewReplay(job, answers), Logger: log, Channels: viper.GetInt("channels")}
+
+		var jobs []*runner.Job
+		for _, f := range job.Files {
+			content, err := os.ReadFile(f.Path)
+			if err != nil {
+				log.Error("error reading file", "file", f.Path, "err", err)
+				continue
+			}
+			if batch.Hash(content) != f.Hash {
+				log.Warn("file changed after the job was submitted, result not applied", "file", f.Path)
+				continue
+			}
+
+			opts := f.Options
+			opts.MaxRetries, opts.Skip = 0, true
+			if cmd.Flags().Changed("mode") {
+				opts.Mode = mode
+			}
+			if cmd.Flags().Changed("verify-cmd") {
+				opts.VerifyCmd, opts.VerifyDir = verifyCmd, ""
+			}
+			opts.Validate = syntaxValidator(opts.Language, string(content))
+
+			jobs = append(jobs, &runner.Job{Path: f.Path, Options: &opts})
+		}
+
+		ctx, cancel := commandContext(0)
+		defer cancel()
+
+		res, err := r.Run(ctx, jobs)
+		if report {
+			writetoReport(res.Results(), time.Now().Format("report_2006-01-02_15:04:05.json"))
+		}
+		res.Print(logOut)
+		if err != nil {
+			os.Exit(1)
+		}
+
+		job.Applied = time.Now()
+		if err := store.Save(job); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+// syntaxValidator возвращает проверку, отклоняющую код с синтаксическими ошибками,
+// если исходный код проходит проверку синтаксиса
+func syntaxValidator(language, original string) func(code string) error {
+	if syntax.Check(language, original) != nil {
+		return nil
+	}
+	return func(code string) error {
+		if err := syntax.Check(language, code); err != nil && !errors.Is(err, syntax.ErrUnsupported) {
+			return i18n.Errorf("syntax error: %w", err)
+		}
+		return nil
+	}
+}
+
+// refreshBatch загружает задание и обновляет его состояние у провайдера, если задание не завершено
+func refreshBatch(store *batch.Store, id string) *batch.Job {
+	job, err := store.Load(id)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	st := &batch.Status{State: job.State}
+	if st.Finished() {
+		return job
+	}
+
+	b, err := batchBackend(job.Backend, store)
+	if err != nil {
+		exitWithError(err)
+	}
+	if st, err = b.Status(context.Background(), job.RemoteID); err != nil {
+		exitWithError(err)
+	}
+
+	job.Update(st)
+	if err := store.Save(job); err != nil {
+		exitWithError(err)
+	}
+	return job
+}
+
+// fetchBatch скачивает результаты завершенного задания
+func fetchBatch(store *batch.Store, job *batch.Job) *batch.Job {
+	if st := (&batch.Status{State: job.State}); !st.Finished() {
+		exitWithError(i18n.Errorf("job %s is still running (state: %s, %d of %d completed)", job.ID, job.State, job.Completed, job.Total))
+	}
+
+	b, err := batchBackend(job.Backend, store)
+	if err != nil {
+		exitWithError(err)
+	}
+	data, err := b.Fetch(context.Background(), job.RemoteID)
+	if err != nil {
+		exitWithError(err)
+	}
+	if err := os.WriteFile(store.OutputPath(job.ID), data, 0600); err != nil {
+		exitWithError(err)
+	}
+
+	job.Fetched = true
+	if err := store.Save(job); err != nil {
+		exitWithError(err)
+	}
+	return job
+}
+
+// batchStore возвращает хранилище заданий в каталоге конфигурации
+func batchStore() *batch.Store {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		exitWithError(err)
+	}
+	return &batch.Store{Dir: filepath.Join(home, ".aifmt", "batches")}
+}
+
+// batchBackend создает провайдера заданий с клиентом API активного профиля
+func batchBackend(name string, store *batch.Store) (batch.Backend, error) {
+	switch name {
+	case batchBackendOpenAI:
+		return &batch.OpenAI{Client: apiClient()}, nil
+	case batchBackendLocal:
+		return &batch.Local{Client: apiClient(), Dir: store.Dir}, nil
+	}
+	return nil, i18n.Errorf("unknown job backend %q, allowed values: %s, %s", name, batchBackendOpenAI, batchBackendLocal)
+}
+
+// defaultBatchBackend возвращает провайдера заданий по умолчанию: Batch API для профиля OpenAI,
+// иначе локальное выполнение
+func defaultBatchBackend() string {
+	if activeProfile().Provider == config.ProviderOpenAI {
+		return batchBackendOpenAI
+	}
+	return batchBackendLocal
+}
+
+func init() {
+	batchSubmitCmd.Flags().StringP("language", "l", "", i18n.Sprintf("Programming language of the files, detected from the extension by default"))
+	batchSubmitCmd.Flags().StringP("model", "m", "deepseek/deepseek-chat:free", i18n.Sprintf("AI model for formatting"))
+	batchSubmitCmd.Flags().String("mode", project.ModeFormat, i18n.Sprintf("Mode for applying the results: format - write the files, review - only print the proposed changes"))
+	batchSubmitCmd.Flags().BoolP("comments", "c", false, i18n.Sprintf("Add comments to the code. The comments language is set in the configuration"))
+	batchSubmitCmd.Flags().String("backend", "", i18n.Sprintf("Job backend: openai or local, openai for an OpenAI profile by default, local otherwise"))
+
+	batchApplyCmd.Flags().String("mode", "", i18n.Sprintf("Override the mode given at submission: format or review"))
+	batchApplyCmd.Flags().BoolP("report", "r", false, i18n.Sprintf("Write the formatting results to a file"))
+	batchApplyCmd.Flags().String("verify-cmd", "", i18n.Sprintf("Command that checks the project after files are written, overrides the command from the project configuration"))
+
+	BatchCmd.AddCommand(batchSubmitCmd, batchStatusCmd, batchFetchCmd, batchApplyCmd)
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/config"
+	"github.com/seelentov/aifmt/internal/i18n"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ConfigCmd - команда для работы с конфигурацией
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: i18n.Sprintf("Manage the configuration"),
+	Long: i18n.Sprintf(`View, change and validate the global aifmt configuration.
+Values are checked against a schema: unknown keys and values of the wrong type
+are rejected. Secret values such as api_key are hidden when printed.`),
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: i18n.Sprintf("Print the value of a key"),
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		key, err := config.Lookup(args[0])
+		if err != nil {
+			exitWithError(err)
+		}
+
+		showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+		fmt.Println(key.Format(viper.Get(args[0]), showSecrets))
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: i18n.Sprintf("Set the value of a key"),
+	Long: i18n.Sprintf(`Set the value of a key in the configuration. The value is converted to the key
+type: integers, true/false for booleans, comma-separated lists.`),
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		setConfigValue(args[0], args[1])
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: i18n.Sprintf("Remove a key from the configuration"),
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if _, err := config.Lookup(args[0]); err != nil {
+			exitWithError(err)
+		}
+
+		removed, err := config.Unset(viper.ConfigFileUsed(), args[0])
+		if err != nil {
+			exitWithError(err)
+		}
+		if !removed {
+			i18n.Printf("Key '%s' is not set in the configuration\n", args[0])
+			return
+		}
+
+		if err := viper.ReadInConfig(); err != nil {
+			exitWithError(i18n.Errorf("error reading the configuration: %w", err))
+		}
+
+		i18n.Printf("Key '%s' removed from the configuration\n", args[0])
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: i18n.Sprintf("Print all configuration keys"),
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+
+		keys := viper.AllKeys()
+		for _, key := range config.Schema {
+			if !strings.Contains(key.Name, "*") && !slices.Contains(keys, key.Name) {
+				keys = append(keys, key.Name)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, name := range keys {
+			key, err := config.Lookup(name)
+			if err != nil {
+				i18n.Printf("%s = %v (unknown key)\n", name, viper.Get(name))
+				continue
+			}
+			fmt.Printf("%s = %s  # %s\n", name, key.Format(viper.Get(name), showSecrets), key.Describe())
+		}
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: i18n.Sprintf("Print the path of the configuration file"),
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(viper.ConfigFileUsed())
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: i18n.Sprintf("Edit the configuration in a text editor"),
+	Long: i18n.Sprintf(`Open the configuration file in the editor from the VISUAL or EDITOR environment
+variable (vi by default) and validate it after the editor exits.`),
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		editor := os.Getenv("VISUAL")
+		if editor == "" {
+			editor = os.Getenv("EDITOR")
+		}
+		if editor == "" {
+			editor = "vi"
+		}
+
+		c := exec.Command("sh", "-c", editor+` "$1"`, "sh", viper.ConfigFileUsed())
+		c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := c.Run(); err != nil {
+			exitWithError(i18n.Errorf("error starting the editor: %w", err))
+		}
+
+		validateConfig()
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: i18n.Sprintf("Validate the configuration file"),
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		validateConfig()
+	},
+}
+
+// setConfigValue проверяет и сохраняет значение ключа в конфигурации
+func setConfigValue(name, raw string) {
+	key, err := config.Lookup(name)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	value, err := key.Parse(raw)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	// API ключ сохраняется в хранилище учетных данных, а не в конфигурацию
+	if key.Name == "api_key" {
+		storeAPIKey(raw)
+		return
+	}
+
+	// Установка значения в конфигурации
+	viper.Set(name, value)
+
+	// Сохранение конфигурации
+	if err := viper.WriteConfig(); err != nil {
+		exitWithError(i18n.Errorf("error saving the configuration: %w", err))
+	}
+
+	i18n.Printf("Value '%s' set for key '%s'\n", key.Format(value, false), name)
+}
+
+// validateConfig проверяет файл конфигурации и завершает программу с ошибкой, если он некорректен
+func validateConfig() {
+	path := viper.ConfigFileUsed()
+
+	settings, err := config.ReadFile(path)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	errs := config.Validate(settings)
+	if len(errs) == 0 {
+		i18n.Printf("Configuration %s is valid\n", path)
+		return
+	}
+
+	i18n.Fprintf(os.Stderr, "Errors in configuration %s:\n", path)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "  - %v\n", err)
+	}
+	os.Exit(1)
+}
+
+// exitWithError выводит ошибку в stderr и завершает программу
+func exitWithError(err error) {
+	i18n.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
+}
+
+func init() {
+	configGetCmd.Flags().Bool("show-secrets", false, i18n.Sprintf("Show secret values"))
+	configListCmd.Flags().Bool("show-secrets", false, i18n.Sprintf("Show secret values"))
+
+	ConfigCmd.AddCommand(configGetCmd, configSetCmd, configUnsetCmd, configListCmd, configPathCmd, configEditCmd, configValidateCmd)
+}
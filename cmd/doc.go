@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/project"
+	"github.com/seelentov/aifmt/internal/service"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// DocCmd - команда для добавления и обновления документирующих комментариев
+var DocCmd = &cobra.Command{
+	Use:   i18n.Sprintf("doc [flags] files..."),
+	Short: i18n.Sprintf("Document code using AI"),
+	Long: i18n.Sprintf(`Add and update documentation comments following the conventions of the
+language: GoDoc for Go (sentences starting with the identifier name), Google or
+NumPy style docstrings for Python, JSDoc and TSDoc for JavaScript and
+TypeScript, Javadoc for Java, and KDoc, rustdoc, C# XML comments, Doxygen,
+PHPDoc and YARD for the other languages.
+
+By default only exported and public symbols are documented, the --all flag
+documents all symbols.
+
+The code itself must not change: for Go the syntax trees of the original and
+the new code are compared ignoring comments, for the other languages the code
+is compared without comments and whitespace. A result that changes the code is
+rejected, and the file is retried or passed to a fallback model.
+
+The comments language is set by the comments_language configuration key, as
+for the fmt command. The project configuration .aifmt.yaml sets the language,
+model, mode, additional instructions and exclusions.`),
+	Example: i18n.Sprintf(`  # Document exported Go symbols
+  aifmt doc *.go
+
+  # Document all Python functions in NumPy style
+  aifmt doc --all --style numpy app.py
+
+  # Only print the proposed comments without writing files
+  aifmt doc --mode review src/*.ts`),
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := &fmtOptions{explicit: make(map[string]bool)}
+		opts.Language, _ = cmd.Flags().GetString("language")
+		opts.model, _ = cmd.Flags().GetString("model")
+		opts.Mode, _ = cmd.Flags().GetString("mode")
+		opts.report, _ = cmd.Flags().GetBool("report")
+		opts.Skip, _ = cmd.Flags().GetBool("skip")
+		opts.MaxRetries = maxRetries()
+		opts.Samples = 1
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		fileTimeout, _ := cmd.Flags().GetDuration("file-timeout")
+
+		doc := &service.DocOptions{}
+		doc.All, _ = cmd.Flags().GetBool("all")
+		doc.Style, _ = cmd.Flags().GetString("style")
+		opts.Doc = doc
+
+		for _, name := range []string{"language", "model", "mode"} {
+			opts.explicit[name] = cmd.Flags().Changed(name)
+		}
+		// Комментарии пишет сама команда, флаг comments не используется
+		opts.explicit["comments"] = true
+
+		if !opts.explicit["model"] {
+			opts.model = defaultModel(opts.model)
+		}
+
+		if opts.Mode != project.ModeFormat && opts.Mode != project.ModeReview {
+			i18n.Fprintf(logOut, "Error: invalid mode %q, allowed values: %s, %s\n", opts.Mode, project.ModeFormat, project.ModeReview)
+			os.Exit(1)
+		}
+
+		if !slices.Contains(service.DocStyles(), doc.Style) {
+			i18n.Fprintf(logOut, "Error: invalid style %q, allowed values: %s\n", doc.Style, strings.Join(service.DocStyles(), ", "))
+			os.Exit(1)
+		}
+
+		opts.CommentsLanguage = viper.GetString("comments_language")
+
+		r := newRunner(fileTimeout)
+
+		ctx, cancel := commandContext(timeout)
+		defer cancel()
+
+		runJobs(ctx, r, opts.jobs(args), opts.report, time.Now().Format("report_2006-01-02_15:04:05.json"))
+	},
+}
+
+func init() {
+	DocCmd.Flags().StringP("language", "l", "", i18n.Sprintf("Programming language of the files, detected from the extension by default"))
+	DocCmd.Flags().StringP("model", "m", "deepseek/deepseek-chat:free", i18n.Sprintf("AI model. Several comma-separated models can be given: the next one is used on failure"))
+	DocCmd.Flags().String("mode", project.ModeFormat, i18n.Sprintf("Mode: format - write the comments to the file, review - only print the proposed changes"))
+	DocCmd.Flags().Bool("all", false, i18n.Sprintf("Document all symbols, not only exported and public ones"))
+	DocCmd.Flags().String("style", service.DocStyleGoogle, i18n.Sprintf("Python docstring style: %s", strings.Join(service.DocStyles(), ", ")))
+	DocCmd.Flags().BoolP("report", "r", false, i18n.Sprintf("Write the results to a file"))
+	DocCmd.Flags().BoolP("skip", "s", false, i18n.Sprintf("Do not retry when processing a file fails"))
+	DocCmd.Flags().Duration("timeout", 0, i18n.Sprintf("Time limit for the whole command, e.g. 10m (0 - no limit)"))
+	DocCmd.Flags().Duration("file-timeout", 0, i18n.Sprintf("Time limit for processing one file, e.g. 2m (0 - no limit)"))
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// modelChain возвращает цепочку моделей из значения вида "a,b,c". Если указана одна модель,
+// к ней добавляются резервные модели из ключа конфигурации fallback_models
+func modelChain(value string) []string {
+	models := splitModels(value)
+	if len(models) > 1 {
+		return models
+	}
+
+	for _, model := range viper.GetStringSlice("fallback_models") {
+		if model = strings.TrimSpace(model); model != "" && !slices.Contains(models, model) {
+			models = append(models, model)
+		}
+	}
+
+	return models
+}
+
+// splitModels разбирает список моделей через запятую без повторов
+func splitModels(value string) []string {
+	var models []string
+	for _, model := range strings.Split(value, ",") {
+		if model = strings.TrimSpace(model); model != "" && !slices.Contains(models, model) {
+			models = append(models, model)
+		}
+	}
+	return models
+}
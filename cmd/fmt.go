@@ -1,258 +1,552 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/seelentov/aifmt/internal/consensus"
 	"github.com/seelentov/aifmt/internal/entity"
-	"github.com/seelentov/aifmt/internal/service"
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/logging"
+	"github.com/seelentov/aifmt/internal/project"
+	"github.com/seelentov/aifmt/internal/runner"
+	"github.com/seelentov/aifmt/internal/style"
+	"github.com/seelentov/aifmt/internal/syntax"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/text/language"
 )
 
-var wg sync.WaitGroup
+// logOut - поток для диагностических сообщений. В режиме stdin сообщения
+// выводятся в stderr, чтобы не смешиваться с отформатированным кодом
+var logOut io.Writer = os.Stdout
+
+// Параметры журнала из глобальных флагов -v, --quiet, --log-format и --debug-dir
+var (
+	Verbosity int    // Подробность сообщений: -v - отладочные, -vv - каждый запрос к API
+	Quiet     bool   // Выводить только предупреждения и ошибки
+	LogFormat string // Формат сообщений: text или json
+	DebugDir  string // Каталог для сохранения запросов к API и ответов на них
+)
+
+// logger создает логгер диагностических сообщений по глобальным флагам
+func logger() *slog.Logger {
+	l, err := logging.New(logOut, logging.Options{Verbosity: Verbosity, Quiet: Quiet, Format: LogFormat})
+	if err != nil {
+		exitWithError(err)
+	}
+	return l
+}
+
+// fmtOptions - параметры форматирования, собранные из флагов и конфигурации
+type fmtOptions struct {
+	runner.Options
+
+	model   string // Модель или цепочка моделей через запятую до применения конфигурации проекта
+	withCtx bool
+	report  bool
+
+	// explicit - флаги, явно указанные в командной строке. Они имеют приоритет над конфигурацией проекта
+	explicit map[string]bool
+}
 
 var FmtCmd = &cobra.Command{
-	Use:   "fmt [флаги] [файлы...]",
-	Short: "Форматирование кода с помощью ИИ",
-	Long: `Форматирование одного или нескольких файлов с кодом с использованием ИИ.
-Вы можете указать язык программирования и модель ИИ для использования.
-Если токен не настроен, вам будет предложено его установить.`,
-	Example: `  # Форматирование Go файла
+	Use:   i18n.Sprintf("fmt [flags] [files...]"),
+	Short: i18n.Sprintf("Format code using AI"),
+	Long: i18n.Sprintf(`Format one or more source files using AI.
+You can choose the programming language and the AI model to use.
+If the token is not configured, you will be asked to set it.
+
+If "-" is given instead of a file or the --stdin flag is set, the code is read
+from standard input and the result is written to standard output. All messages
+in this mode go to stderr, so aifmt can be used as a filter in editors and
+pipelines.
+
+For each file the project configuration .aifmt.yaml is looked up in the file's
+directory and above. It can set the language, model, mode, additional
+instructions, comments and exclusions, including per-path settings for
+matching patterns. Explicit flags take precedence.
+
+Files are processed in parallel, the number of files processed at once is set
+by the channels configuration key. On interrupt (Ctrl-C) or when --timeout
+expires, AI requests are cancelled, no new files are started, and a list of
+processed, skipped and interrupted files is printed at the end. A second
+Ctrl-C exits immediately.`),
+	Example: i18n.Sprintf(`  # Format a Go file
   aifmt fmt -l go main.go
-  
-  # Форматирование нескольких Python файлов с указанной моделью
+
+  # Format several Python files with a specific model
   aifmt fmt -l python --model claude-2 *.py
-  
-  # Форматирование с автоопределением языка
+
+  # Format with language auto-detection
   aifmt fmt script.js
-  
-  # Форматирование с учетом контекста других файлов
-  aifmt fmt -w -l go *.go`,
+
+  # Format taking other files into account
+  aifmt fmt -w -l go *.go
+
+  # Only print the proposed changes without writing files
+  aifmt fmt --mode review *.go
+
+  # Format only lines 40 to 85 or a single function
+  aifmt fmt -l go --lines 40:85 main.go
+  aifmt fmt -l go --func Server.Run server.go
+
+  # Pick the best of three candidates by agreement or with a judge model
+  aifmt fmt --samples 3 main.go
+  aifmt fmt --models deepseek/deepseek-chat:free,qwen/qwen-2.5-coder-32b-instruct:free --pick judge main.go
+
+  # Review the result with another model (up to two rounds of fixes)
+  aifmt fmt --refine 2 --reviewer-model qwen/qwen-2.5-coder-32b-instruct:free main.go
+
+  # Check the build and tests after formatting and revert changes that break them
+  aifmt fmt --verify-cmd "go build ./... && go test ./..." --verify-retries 1 *.go
+
+  # Time limits: at most 2 minutes per file and 10 minutes for the whole command
+  aifmt fmt --file-timeout 2m --timeout 10m *.go
+
+  # Format files staged in the git index (used by the pre-commit hook)
+  aifmt fmt --hook
+  aifmt fmt --hook --check
+
+  # Format standard input (e.g. from Vim: :%%!aifmt fmt -l go -)
+  cat main.go | aifmt fmt -l go -
+  aifmt fmt --stdin --stdin-filename main.go < main.go`),
 	Run: func(cmd *cobra.Command, args []string) {
-		token := viper.GetString("api_key")
-		if token == "" {
-			fmt.Println("API токен не настроен. Пожалуйста, сначала выполните 'aifmt set api_key ваш_токен'.")
+		stdin, _ := cmd.Flags().GetBool("stdin")
+		hook, _ := cmd.Flags().GetBool("hook")
+		check, _ := cmd.Flags().GetBool("check")
+		stdinFilename, _ := cmd.Flags().GetString("stdin-filename")
+		if len(args) > 0 && args[0] == "-" {
+			stdin = true
+			args = args[1:]
+		}
+		if stdin {
+			logOut = os.Stderr
+		}
+
+		opts := &fmtOptions{explicit: make(map[string]bool)}
+		opts.Language, _ = cmd.Flags().GetString("language")
+		opts.model, _ = cmd.Flags().GetString("model")
+		opts.Mode, _ = cmd.Flags().GetString("mode")
+		opts.withCtx, _ = cmd.Flags().GetBool("with-context")
+		opts.Comments, _ = cmd.Flags().GetBool("comments")
+		opts.report, _ = cmd.Flags().GetBool("report")
+		opts.Skip, _ = cmd.Flags().GetBool("skip")
+		opts.MaxRetries = maxRetries()
+		opts.Lines, _ = cmd.Flags().GetString("lines")
+		opts.Func, _ = cmd.Flags().GetString("func")
+		opts.Samples, _ = cmd.Flags().GetInt("samples")
+		opts.Pick, _ = cmd.Flags().GetString("pick")
+		opts.JudgeModel, _ = cmd.Flags().GetString("judge-model")
+		opts.Refine, _ = cmd.Flags().GetInt("refine")
+		opts.ReviewerModel, _ = cmd.Flags().GetString("reviewer-model")
+		if models, _ := cmd.Flags().GetString("models"); models != "" {
+			opts.CandidateModels = splitModels(models)
+		}
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		fileTimeout, _ := cmd.Flags().GetDuration("file-timeout")
+
+		opts.VerifyCmd, _ = cmd.Flags().GetString("verify-cmd")
+		verifyRetries, _ := cmd.Flags().GetInt("verify-retries")
+
+		for _, name := range []string{"language", "model", "mode", "comments", "verify-cmd"} {
+			opts.explicit[name] = cmd.Flags().Changed(name)
+		}
+
+		// Модель из профиля или глобальной конфигурации используется, если она не указана явно
+		if !opts.explicit["model"] {
+			opts.model = defaultModel(opts.model)
+		}
+
+		if opts.Mode != project.ModeFormat && opts.Mode != project.ModeReview {
+			i18n.Fprintf(logOut, "Error: invalid mode %q, allowed values: %s, %s\n", opts.Mode, project.ModeFormat, project.ModeReview)
 			os.Exit(1)
 		}
 
-		language, _ := cmd.Flags().GetString("language")
-		if language == "" {
-			fmt.Println("Ошибка: не указан язык программирования")
+		if opts.Samples < 1 {
+			i18n.Fprintln(logOut, "Error: --samples must be at least 1")
 			os.Exit(1)
 		}
 
-		model, _ := cmd.Flags().GetString("model")
+		if opts.Refine < 0 {
+			i18n.Fprintln(logOut, "Error: --refine must not be negative")
+			os.Exit(1)
+		}
 
-		withCtx, _ := cmd.Flags().GetBool("with-context")
-		comments, _ := cmd.Flags().GetBool("comments")
-		report, _ := cmd.Flags().GetBool("report")
-		skip, _ := cmd.Flags().GetBool("skip")
-		maxRetries := viper.GetInt("max_retry")
+		if !slices.Contains(consensus.Strategies(), opts.Pick) {
+			i18n.Fprintf(logOut, "Error: invalid strategy %q, allowed values: %s\n", opts.Pick, strings.Join(consensus.Strategies(), ", "))
+			os.Exit(1)
+		}
 
-		commentsLanguage := viper.GetString("comments_language")
-		if report && commentsLanguage == "" {
-			fmt.Println("Язык комментариев не настроен. Пожалуйста, сначала выполните 'aifmt set comments_language язык'.")
+		if opts.Lines != "" && opts.Func != "" {
+			i18n.Fprintln(logOut, "Error: --lines and --func cannot be used together")
 			os.Exit(1)
 		}
 
-		if len(args) == 0 {
-			fmt.Println("Ошибка: не указаны файлы для обработки")
+		opts.CommentsLanguage = viper.GetString("comments_language")
+		opts.AllowCommentChanges = !viper.GetBool("check_comments")
+		if opts.report && opts.CommentsLanguage == "" {
+			i18n.Fprintln(logOut, "The comments language is not configured. Please run 'aifmt set comments_language <language>' first.")
+			os.Exit(1)
+		}
+
+		if check && !hook {
+			i18n.Fprintln(logOut, "Error: --check can only be used with --hook")
+			os.Exit(1)
+		}
+
+		if len(args) == 0 && !stdin && !hook {
+			i18n.Fprintln(logOut, "Error: no files to process")
 			cmd.Help()
 			os.Exit(1)
 		}
 
+		r := newRunner(fileTimeout)
+		r.VerifyRetries = verifyRetries
+
+		ctx, cancel := commandContext(timeout)
+		defer cancel()
+
 		// Собираем контекстные файлы, если указан флаг
-		var ctx []*entity.File
-		var allUpds []*entity.Update
-
-		if withCtx {
-			for _, pattern := range args {
-				files, err := filepath.Glob(pattern)
-				if err != nil {
-					fmt.Printf("Ошибка при разборе шаблона %s: %v\n", pattern, err)
-					continue
-				}
-
-				for _, file := range files {
-					content, err := os.ReadFile(file)
-					if err != nil {
-						fmt.Printf("Ошибка чтения контекстного файла %s: %v\n", file, err)
-						continue
-					}
-					ctx = append(ctx, &entity.File{
-						Content: string(content),
-						Path:    file,
-					})
-				}
-			}
-			fmt.Printf("Загружено %d файлов для контекста\n", len(ctx))
+		if opts.withCtx {
+			r.Context = loadContext(args)
+			i18n.Fprintf(logOut, "Loaded %d files for context\n", len(r.Context))
 		}
 
 		repname := time.Now().Format("report_2006-01-02_15:04:05.json")
 
-		// Обрабатываем каждый файл
-		for _, pattern := range args {
-			files, err := filepath.Glob(pattern)
+		if hook {
+			if err := runHook(ctx, r, opts, check, repname); err != nil {
+				fmt.Fprintln(logOut, err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if stdin {
+			if err := formatStdin(ctx, r, opts, stdinFilename, repname); err != nil {
+				fmt.Fprintln(logOut, err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		runJobs(ctx, r, opts.jobs(args), opts.report, repname)
+	},
+}
+
+// newRunner создает конвейер форматирования с клиентом API активного профиля
+func newRunner(fileTimeout time.Duration) *runner.Runner {
+	return &runner.Runner{
+		Provider:    apiClient(),
+		Logger:      logger(),
+		Channels:    viper.GetInt("channels"),
+		FileTimeout: fileTimeout,
+	}
+}
+
+// runJobs обрабатывает файлы, записывает отчет repname, если withReport установлен, выводит
+// итоги и завершает программу с кодом 1, если какие-либо файлы не обработаны. Используется
+// командами fmt и doc
+func runJobs(ctx context.Context, r *runner.Runner, jobs []*runner.Job, withReport bool, repname string) {
+	report, err := processJobs(ctx, r, jobs, withReport, repname)
+	report.Print(logOut)
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// processJobs обрабатывает файлы и записывает отчет repname, если withReport установлен.
+// Отчет о результатах обработки возвращается и при отмене контекста
+func processJobs(ctx context.Context, r *runner.Runner, jobs []*runner.Job, withReport bool, repname string) (*runner.Report, error) {
+	// Отчет перезаписывается после обработки каждого файла, чтобы результаты
+	// сохранились и при прерывании команды
+	var (
+		mu      sync.Mutex
+		results []*entity.FileResult
+	)
+	if withReport {
+		r.OnFile = func(st *runner.FileStatus) {
+			if st.Status != runner.StatusCompleted || st.Result == nil {
+				return
+			}
+			mu.Lock()
+			results = append(results, st.Result)
+			report := append([]*entity.FileResult(nil), results...)
+			mu.Unlock()
+			writetoReport(report, repname)
+		}
+	}
+
+	report, err := r.Run(ctx, jobs)
+
+	// Проверка могла изменить результаты файлов
+	if withReport && verified(report) {
+		writetoReport(report.Results(), repname)
+	}
+
+	return report, err
+}
+
+// verified проверяет, выполнялась ли для файлов команда проверки
+func verified(report *runner.Report) bool {
+	for _, res := range report.Results() {
+		if res.Verification != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve возвращает параметры для конкретного файла с учетом конфигурации проекта .aifmt.yaml.
+// Флаги, явно указанные в командной строке, имеют приоритет над конфигурацией проекта.
+// Второе значение сообщает, что файл исключен из обработки
+func (o *fmtOptions) resolve(file string) (*fmtOptions, bool, error) {
+	res := *o
+	withStyle := viper.GetBool("style")
+	var formatterSpec string
+
+	cfg, err := project.Find(file)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if cfg != nil {
+		if file != "" && cfg.Ignored(file) {
+			return nil, true, nil
+		}
+
+		s := cfg.Resolve(file)
+		if s.Language != "" && !o.explicit["language"] {
+			res.Language = s.Language
+		}
+		if s.Model != "" && !o.explicit["model"] {
+			res.model = s.Model
+		}
+		if s.Mode != "" && !o.explicit["mode"] {
+			res.Mode = s.Mode
+		}
+		if s.Comments != nil && !o.explicit["comments"] {
+			res.Comments = *s.Comments
+		}
+		if s.Style != nil {
+			withStyle = *s.Style
+		}
+		formatterSpec = s.Formatter
+		res.Prompt = s.Prompt
+		if s.VerifyCmd != "" && !o.explicit["verify-cmd"] {
+			res.VerifyCmd = s.VerifyCmd
+			res.VerifyDir = cfg.Root()
+		}
+	}
+
+	res.PromptLanguage = promptLanguage()
+	res.Models = modelChain(res.model)
+	if len(res.Models) == 0 {
+		return nil, false, i18n.Errorf("Error: no AI model specified for %s", file)
+	}
+
+	if res.Language == "" {
+		res.Language = syntax.Language(file)
+	}
+	if res.Language == "" {
+		return nil, false, i18n.Errorf("Error: no programming language specified for %s", file)
+	}
+	if res.Func != "" && res.Language != "go" {
+		return nil, false, i18n.Errorf("Error: --func is only supported for go (%s)", file)
+	}
+
+	// Форматер из конфигурации проекта заменяет форматер языка из глобальной конфигурации
+	res.Formatter = formatterSpec
+	if res.Formatter == "" {
+		res.Formatter = viper.GetString("formatters." + res.Language)
+	}
+
+	// Правила стиля из конфигурации средств форматирования и линтеров проекта
+	if withStyle {
+		pr := i18n.Printer(res.PromptLanguage)
+		rules, err := style.Discover(file, res.Language, pr)
+		if err != nil {
+			i18n.Fprintf(logOut, "Error reading style rules for %s: %v\n", file, err)
+		}
+		if p := style.Prompt(rules, pr); p != "" {
+			res.Prompt = strings.TrimSpace(res.Prompt + "\n" + p)
+		}
+	}
+
+	return &res, false, nil
+}
+
+// promptLanguage возвращает язык запросов к ИИ: ключ конфигурации prompt_language или язык интерфейса
+func promptLanguage() language.Tag {
+	if tag, ok := i18n.Parse(viper.GetString("prompt_language")); ok {
+		return tag
+	}
+	return i18n.Lang()
+}
+
+// jobs возвращает задания для файлов, подходящих под шаблоны, с параметрами из resolve.
+// Ошибки шаблонов и параметров выводятся, а соответствующие файлы пропускаются
+func (o *fmtOptions) jobs(patterns []string) []*runner.Job {
+	var jobs []*runner.Job
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			i18n.Fprintf(logOut, "Error parsing pattern %s: %v\n", pattern, err)
+			continue
+		}
+
+		for _, file := range files {
+			fopts, ignored, err := o.resolve(file)
 			if err != nil {
-				fmt.Printf("Ошибка при разборе шаблона %s: %v\n", pattern, err)
+				fmt.Fprintln(logOut, err)
 				continue
 			}
+			job := &runner.Job{Path: file, Ignored: ignored}
+			if !ignored {
+				job.Options = &fopts.Options
+			}
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
 
-			for _, file := range files {
-				wg.Add(1)
-				go func(file string) {
-					fmt.Printf("Обработка %s (Язык: %s, Модель: %s, Контекст: %v)...\n",
-						file, language, model, withCtx)
-
-					content, err := os.ReadFile(file)
-					if err != nil {
-						fmt.Printf("Ошибка чтения файла %s: %v\n", file, err)
-						if !skip {
-							fmt.Println("Попытка повторного чтения файла...")
-							if err := retryOperation(maxRetries, func() error {
-								content, err = os.ReadFile(file)
-								return err
-							}); err != nil {
-								fmt.Printf("Не удалось прочитать файл %s после %d попыток: %v\n", file, maxRetries, err)
-								return
-							}
-						} else {
-							return
-						}
-					}
-
-					var u string
-					var upds []*entity.Update
-					var formatErr error
-
-					// Функция для форматирования кода
-					formatFunc := func() error {
-						u, upds, formatErr = service.FormatCode(string(content), language, model, token, comments, commentsLanguage, ctx)
-						return formatErr
-					}
-
-					if err := formatFunc(); err != nil {
-						fmt.Printf("Ошибка при форматировании %s: %v\n", file, err)
-						if !skip {
-							fmt.Println("Попытка повторного форматирования...")
-							if err := retryOperation(maxRetries, formatFunc); err != nil {
-								fmt.Printf("Не удалось отформатировать файл %s после %d попыток: %v\n", file, maxRetries, err)
-								return
-							}
-						} else {
-							return
-						}
-					}
-
-					if u == "" {
-						fmt.Printf("Ошибка: ответ ИИ пуст.\n")
-						if !skip {
-							fmt.Println("Попытка повторного форматирования из-за пустого ответа...")
-							retryCount := 0
-							for u == "" && retryCount < maxRetries {
-								retryCount++
-								if err := formatFunc(); err != nil {
-									fmt.Printf("Попытка %d: ошибка форматирования: %v\n", retryCount, err)
-									continue
-								}
-								if u != "" {
-									break
-								}
-								fmt.Printf("Попытка %d: ответ ИИ все еще пуст\n", retryCount)
-								time.Sleep(time.Second * time.Duration(retryCount)) // Увеличиваем задержку между попытками
-							}
-							if u == "" {
-								fmt.Printf("Не удалось получить непустой ответ для файла %s после %d попыток\n", file, maxRetries)
-								return
-							}
-						}
-					}
-
-					for i := range upds {
-						upds[i].Path = file
-					}
-
-					// Выводим предложенные изменения
-					for _, upd := range upds {
-						fmt.Printf("%s:\n```%s\n%s\n```\n%s\n\n", file, language, upd.Code, upd.Description)
-					}
-
-					if report {
-						allUpds = append(allUpds, upds...)
-					}
-
-					// Записываем изменения в файл
-					if err := os.WriteFile(file, []byte(u), 0644); err != nil {
-						fmt.Printf("Ошибка записи в %s: %v\n", file, err)
-						if !skip {
-							fmt.Println("Попытка повторной записи файла...")
-							if err := retryOperation(maxRetries, func() error {
-								return os.WriteFile(file, []byte(u), 0644)
-							}); err != nil {
-								fmt.Printf("Не удалось записать файл %s после %d попыток: %v\n", file, maxRetries, err)
-								return
-							}
-						}
-					}
-
-					fmt.Printf("Файл %s успешно обновлен\n", file)
-
-					if report {
-						writetoReport(allUpds, repname)
-					}
-
-					wg.Done()
-				}(file)
+// formatStdin форматирует код из стандартного ввода и выводит результат в стандартный вывод.
+// При ошибке форматирования исходный код выводится без изменений, чтобы редактор не потерял содержимое буфера
+func formatStdin(ctx context.Context, r *runner.Runner, opts *fmtOptions, filename string, repname string) error {
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return i18n.Errorf("Error reading standard input: %v", err)
+	}
+
+	opts, ignored, err := opts.resolve(filename)
+	if err != nil {
+		os.Stdout.Write(content)
+		return err
+	}
+	if ignored {
+		i18n.Fprintf(logOut, "File %s skipped by the project configuration\n", filename)
+		_, err := os.Stdout.Write(content)
+		return err
+	}
+
+	name := filename
+	if name == "" {
+		name = "<stdin>"
+	}
+
+	i18n.Fprintf(logOut, "Processing %s (Language: %s, Model: %s, Context: %v)...\n",
+		name, opts.Language, strings.Join(opts.Models, ","), opts.withCtx)
+
+	ctx, cancel := r.FileContext(ctx)
+	defer cancel()
+
+	u, result, err := r.Format(ctx, string(content), filename, &opts.Options)
+	if err != nil {
+		os.Stdout.Write(content)
+		if ctx.Err() != nil {
+			return i18n.Errorf("Processing of %s interrupted: %v", name, context.Cause(ctx))
+		}
+		return err
+	}
+
+	// В режиме проверки код выводится без изменений
+	if opts.Mode == project.ModeReview {
+		u = string(content)
+	}
+
+	if _, err := io.WriteString(os.Stdout, u); err != nil {
+		return i18n.Errorf("Error writing to standard output: %v", err)
+	}
+
+	if opts.report {
+		writetoReport([]*entity.FileResult{result}, repname)
+	}
+
+	return nil
+}
+
+// loadContext читает все файлы, подходящие под шаблоны, для использования в качестве контекста
+func loadContext(patterns []string) []*entity.File {
+	var ctx []*entity.File
+
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			i18n.Fprintf(logOut, "Error parsing pattern %s: %v\n", pattern, err)
+			continue
+		}
+
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				i18n.Fprintf(logOut, "Error reading context file %s: %v\n", file, err)
+				continue
 			}
+			ctx = append(ctx, &entity.File{
+				Content: string(content),
+				Path:    file,
+			})
 		}
+	}
 
-		wg.Wait()
-	},
+	return ctx
 }
 
 var wtrMutex sync.Mutex
 
-func writetoReport(upds []*entity.Update, repname string) {
+// writetoReport записывает в отчет результаты обработки файлов: использованную модель и предложенные изменения
+func writetoReport(results []*entity.FileResult, repname string) {
 	wtrMutex.Lock()
-	rep, err := json.Marshal(upds)
+	defer wtrMutex.Unlock()
+
+	rep, err := json.Marshal(results)
 	if err != nil {
-		fmt.Printf("Ошибка при приведении изменений в строку JSON: %s\n", err)
+		i18n.Fprintf(logOut, "Error encoding changes as JSON: %s\n", err)
 		return
 	}
 
 	if err := os.WriteFile(repname, rep, 0644); err != nil {
-		fmt.Printf("Ошибка записи в %s: %v\n", repname, err)
+		i18n.Fprintf(logOut, "Error writing to %s: %v\n", repname, err)
 		return
 	}
 
-	fmt.Printf("Отчет о форматировании записан в %s\n", repname)
-	wtrMutex.Unlock()
-}
-
-// retryOperation выполняет операцию с повторными попытками при ошибках
-func retryOperation(maxRetries int, op func() error) error {
-	var err error
-	for i := 0; i < maxRetries; i++ {
-		if err = op(); err == nil {
-			return nil
-		}
-		fmt.Printf("Попытка %d из %d: %v\n", i+1, maxRetries, err)
-		time.Sleep(time.Second * time.Duration(i+1)) // Увеличиваем задержку между попытками
-	}
-	return fmt.Errorf("достигнуто максимальное количество попыток (%d): %v", maxRetries, err)
+	i18n.Fprintf(logOut, "Formatting report written to %s\n", repname)
 }
 
 func init() {
-	FmtCmd.Flags().StringP("language", "l", "", "Язык программирования файлов")
-	FmtCmd.Flags().StringP("model", "m", "deepseek/deepseek-chat:free", "Модель ИИ для форматирования")
-	FmtCmd.Flags().BoolP("with-context", "w", false, "Использовать контекст других файлов при форматировании")
-	FmtCmd.Flags().BoolP("comments", "c", false, "Добавить в код комментарии. Язык комментариев настраивается в конфигурации")
-	FmtCmd.Flags().BoolP("report", "r", false, "Запись результатов форматирования в файл")
-	FmtCmd.Flags().BoolP("skip", "s", false, "Не повторять попытки при ошибках обработки файлов")
-}
\ No newline at end of file
+	FmtCmd.Flags().StringP("language", "l", "", i18n.Sprintf("Programming language of the files"))
+	FmtCmd.Flags().StringP("model", "m", "deepseek/deepseek-chat:free", i18n.Sprintf("AI model for formatting. Several comma-separated models can be given: the next one is used on failure"))
+	FmtCmd.Flags().String("mode", project.ModeFormat, i18n.Sprintf("Mode: format - format and write the file, review - only print the proposed changes"))
+	FmtCmd.Flags().BoolP("with-context", "w", false, i18n.Sprintf("Use other files as context when formatting"))
+	FmtCmd.Flags().BoolP("comments", "c", false, i18n.Sprintf("Add comments to the code. The comments language is set in the configuration"))
+	FmtCmd.Flags().BoolP("report", "r", false, i18n.Sprintf("Write the formatting results to a file"))
+	FmtCmd.Flags().BoolP("skip", "s", false, i18n.Sprintf("Do not retry when processing a file fails"))
+	FmtCmd.Flags().String("lines", "", i18n.Sprintf("Format only the given line range as start:end, e.g. 40:85"))
+	FmtCmd.Flags().String("func", "", i18n.Sprintf("Format only the given Go function (Type.Method for methods)"))
+	FmtCmd.Flags().Bool("stdin", false, i18n.Sprintf("Read code from standard input and write the result to standard output"))
+	FmtCmd.Flags().Int("samples", 1, i18n.Sprintf("Number of candidates requested from each model to pick the best one"))
+	FmtCmd.Flags().String("models", "", i18n.Sprintf("Comma-separated models to request candidates from"))
+	FmtCmd.Flags().String("pick", consensus.StrategyAgreement, i18n.Sprintf("Candidate selection strategy: %s", strings.Join(consensus.Strategies(), ", ")))
+	FmtCmd.Flags().String("judge-model", "", i18n.Sprintf("Judge model for the judge strategy, the main model by default"))
+	FmtCmd.Flags().Int("refine", 0, i18n.Sprintf("Number of review rounds by a reviewer model before writing"))
+	FmtCmd.Flags().String("reviewer-model", "", i18n.Sprintf("Reviewer model for --refine, the model that produced the result by default"))
+	FmtCmd.Flags().String("verify-cmd", "", i18n.Sprintf("Command that checks the project after files are written, e.g. \"go build ./... && go test ./...\""))
+	FmtCmd.Flags().Int("verify-retries", 0, i18n.Sprintf("Number of retries for files that broke the check, with the check output added to the prompt"))
+	FmtCmd.Flags().Duration("timeout", 0, i18n.Sprintf("Time limit for the whole command, e.g. 10m (0 - no limit)"))
+	FmtCmd.Flags().Duration("file-timeout", 0, i18n.Sprintf("Time limit for processing one file, e.g. 2m (0 - no limit)"))
+	FmtCmd.Flags().Bool("hook", false, i18n.Sprintf("Format files staged in the git index and stage the result (pre-commit hook mode)"))
+	FmtCmd.Flags().Bool("check", false, i18n.Sprintf("With --hook: only check formatting and fail if files need changes"))
+	FmtCmd.Flags().String("stdin-filename", "", i18n.Sprintf("File name for code read from standard input (used to detect the language and in the report)"))
+}
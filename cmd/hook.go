@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/seelentov/aifmt/internal/git"
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/runner"
+	"github.com/seelentov/aifmt/internal/syntax"
+
+	"github.com/spf13/cobra"
+)
+
+// hookMarker - строка, по которой определяется хук, установленный aifmt
+const hookMarker = "# installed by aifmt"
+
+// legacyHookMarker - метка хуков, установленных предыдущими версиями aifmt
+const legacyHookMarker = "# Установлено aifmt"
+
+// hookBackupSuffix - суффикс резервной копии хука, замененного при установке
+const hookBackupSuffix = ".aifmt-backup"
+
+// HookCmd - команда для управления хуком git pre-commit
+var HookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: i18n.Sprintf("Manage the git pre-commit hook"),
+	Long: i18n.Sprintf(`Install and remove the git pre-commit hook that runs 'aifmt fmt --hook'
+for the staged files before every commit.`),
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: i18n.Sprintf("Install the pre-commit hook into the current repository"),
+	Example: i18n.Sprintf(`  # Format the staged files before a commit
+  aifmt hook install
+
+  # Check only: the commit is rejected if files need formatting
+  aifmt hook install --check`),
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		check, _ := cmd.Flags().GetBool("check")
+		force, _ := cmd.Flags().GetBool("force")
+
+		path := hookPath()
+
+		if data, err := os.ReadFile(path); err == nil && !isAifmtHook(data) {
+			if !force {
+				exitWithError(i18n.Errorf("hook %s already exists. Use --force to replace it (a backup will be created)", path))
+			}
+			if err := os.Rename(path, path+hookBackupSuffix); err != nil {
+				exitWithError(i18n.Errorf("error backing up the hook: %w", err))
+			}
+			i18n.Printf("Existing hook saved to %s\n", path+hookBackupSuffix)
+		}
+
+		command := "aifmt fmt --hook"
+		if check {
+			command += " --check"
+		}
+		script := fmt.Sprintf("#!/bin/sh\n%s\nexec %s\n", hookMarker, command)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			exitWithError(i18n.Errorf("error creating the hooks directory: %w", err))
+		}
+		if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+			exitWithError(i18n.Errorf("error writing the hook: %w", err))
+		}
+
+		i18n.Printf("pre-commit hook installed: %s\n", path)
+	},
+}
+
+var hookUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: i18n.Sprintf("Remove the pre-commit hook from the current repository"),
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := hookPath()
+
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			i18n.Printf("The pre-commit hook is not installed\n")
+			return
+		}
+		if err != nil {
+			exitWithError(err)
+		}
+		if !isAifmtHook(data) {
+			exitWithError(i18n.Errorf("hook %s was not installed by aifmt and will not be removed", path))
+		}
+
+		if err := os.Remove(path); err != nil {
+			exitWithError(i18n.Errorf("error removing the hook: %w", err))
+		}
+		i18n.Printf("pre-commit hook removed: %s\n", path)
+
+		if _, err := os.Stat(path + hookBackupSuffix); err == nil {
+			if err := os.Rename(path+hookBackupSuffix, path); err != nil {
+				exitWithError(i18n.Errorf("error restoring the hook: %w", err))
+			}
+			i18n.Printf("Previous hook restored from %s\n", path+hookBackupSuffix)
+		}
+	},
+}
+
+// isAifmtHook сообщает, установлен ли хук с содержимым data командой aifmt
+func isAifmtHook(data []byte) bool {
+	return strings.Contains(string(data), hookMarker) || strings.Contains(string(data), legacyHookMarker)
+}
+
+// hookPath возвращает путь к хуку pre-commit текущего репозитория
+func hookPath() string {
+	repo, err := git.Open(".")
+	if err != nil {
+		exitWithError(i18n.Errorf("the current directory is not a git repository: %w", err))
+	}
+
+	dir, err := repo.HooksDir()
+	if err != nil {
+		exitWithError(err)
+	}
+	return filepath.Join(dir, "pre-commit")
+}
+
+// runHook форматирует файлы, добавленные в индекс. Форматируется содержимое индекса, а не
+// рабочей копии. Если рабочая копия файла не содержит неиндексированных изменений, результат
+// записывается в файл и добавляется в индекс. Иначе обновляется только индекс, чтобы не
+// затронуть неиндексированные изменения. В режиме check файлы не изменяются, а функция
+// возвращает ошибку, если какие-либо файлы требуют форматирования
+func runHook(ctx context.Context, r *runner.Runner, opts *fmtOptions, check bool, repname string) error {
+	repo, err := git.Open(".")
+	if err != nil {
+		return i18n.Errorf("the current directory is not a git repository: %w", err)
+	}
+
+	staged, err := repo.StagedFiles()
+	if err != nil {
+		return err
+	}
+
+	var jobs []*runner.Job
+	for _, rel := range staged {
+		file := filepath.Join(repo.Root, rel)
+
+		fopts, ignored, err := opts.resolve(file)
+		if ignored || err != nil && opts.Language == "" && syntax.Language(file) == "" {
+			continue
+		}
+		if err != nil {
+			r.Logger.Error("configuration error", "file", rel, "err", err)
+			continue
+		}
+
+		jopts := fopts.Options
+		// В режиме check файлы не записываются, проверять проект командой нечего
+		if check {
+			jopts.VerifyCmd = ""
+		}
+		jobs = append(jobs, &runner.Job{Path: file, Options: &jopts})
+	}
+
+	fs := &indexFS{repo: repo, check: check, log: r.Logger}
+	r.FS = fs
+
+	report, err := processJobs(ctx, r, jobs, opts.report, repname)
+	report.Print(logOut)
+	if err != nil {
+		return err
+	}
+	if len(fs.unformatted) > 0 {
+		sort.Strings(fs.unformatted)
+		return i18n.Errorf("Files need formatting: %s. Run 'aifmt fmt --hook' and commit again", strings.Join(fs.unformatted, ", "))
+	}
+	return nil
+}
+
+// indexFS - файловая система, читающая файлы из индекса git. Записанное содержимое добавляется
+// в индекс, а рабочая копия обновляется, только если в ней нет неиндексированных изменений.
+// В режиме check файлы не изменяются, а отличающиеся от индекса запоминаются в unformatted
+type indexFS struct {
+	repo  *git.Repo
+	check bool
+	log   *slog.Logger
+
+	mu          sync.Mutex
+	unformatted []string // Файлы, требующие форматирования, относительно корня репозитория
+}
+
+// ReadFile читает содержимое файла из индекса
+func (f *indexFS) ReadFile(name string) ([]byte, error) {
+	rel, err := filepath.Rel(f.repo.Root, name)
+	if err != nil {
+		return nil, err
+	}
+	return f.repo.StagedContent(rel)
+}
+
+// WriteFile добавляет содержимое файла в индекс, если оно отличается от индексированного
+func (f *indexFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	rel, err := filepath.Rel(f.repo.Root, name)
+	if err != nil {
+		return err
+	}
+
+	staged, err := f.repo.StagedContent(rel)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(staged, data) {
+		return nil
+	}
+
+	if f.check {
+		f.log.Warn("file needs formatting", "file", rel)
+		f.mu.Lock()
+		f.unformatted = append(f.unformatted, rel)
+		f.mu.Unlock()
+		return nil
+	}
+
+	partial, err := f.repo.PartiallyStaged(rel)
+	if err != nil {
+		return err
+	}
+
+	if err := f.repo.Stage(rel, data); err != nil {
+		return err
+	}
+
+	if partial {
+		f.log.Warn("file has unstaged changes: only the staged version was formatted and staged, the working copy is unchanged", "file", rel)
+		return nil
+	}
+
+	if err := os.WriteFile(name, data, perm); err != nil {
+		return i18n.Errorf("Error writing %s: %v", name, err)
+	}
+	f.log.Info("file formatted and staged", "file", rel)
+	return nil
+}
+
+func init() {
+	hookInstallCmd.Flags().Bool("check", false, i18n.Sprintf("Only check formatting and reject the commit without changing files"))
+	hookInstallCmd.Flags().Bool("force", false, i18n.Sprintf("Replace an existing pre-commit hook, keeping a backup of it"))
+
+	HookCmd.AddCommand(hookInstallCmd, hookUninstallCmd)
+}
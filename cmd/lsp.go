@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/seelentov/aifmt/internal/entity"
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/lsp"
+	"github.com/seelentov/aifmt/internal/project"
+	"github.com/seelentov/aifmt/internal/syntax"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// LspCmd - команда запуска сервера Language Server Protocol
+var LspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: i18n.Sprintf("Start a Language Server Protocol server"),
+	Long: i18n.Sprintf(`Start a Language Server Protocol server over standard input and output.
+The server supports formatting a document or a selected range, code actions
+based on the changes proposed by AI, and diagnostics published when a file
+is opened or saved.`),
+	Example: i18n.Sprintf(`  # Start the server (usually done by the editor)
+  aifmt lsp
+
+  # Start without checking code when files are opened and saved
+  aifmt lsp --diagnostics=false`),
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Стандартный вывод занят протоколом LSP
+		logOut = os.Stderr
+
+		opts := &fmtOptions{explicit: make(map[string]bool)}
+		opts.model, _ = cmd.Flags().GetString("model")
+		opts.Comments, _ = cmd.Flags().GetBool("comments")
+		opts.Mode = project.ModeFormat
+		opts.Skip = true
+		opts.MaxRetries = maxRetries()
+		opts.CommentsLanguage = viper.GetString("comments_language")
+		opts.AllowCommentChanges = !viper.GetBool("check_comments")
+		diagnostics, _ := cmd.Flags().GetBool("diagnostics")
+
+		for _, name := range []string{"model", "comments"} {
+			opts.explicit[name] = cmd.Flags().Changed(name)
+		}
+		if !opts.explicit["model"] {
+			opts.model = defaultModel(opts.model)
+		}
+
+		r := newRunner(0)
+
+		// Документ форматируется так же, как командой fmt: с конфигурацией проекта, правилами
+		// стиля, форматером языка и цепочкой моделей. Как и в режиме watch, повторные попытки
+		// не выполняются, чтобы не задерживать редактор
+		format := func(ctx context.Context, path, content, language string, start, end int) (string, []*entity.Update, error) {
+			o := *opts
+			// Язык определяется по расширению файла, идентификатор языка редактора используется
+			// для документов без файла или с неизвестным расширением
+			if syntax.Language(path) == "" {
+				o.Language = language
+			}
+
+			fopts, ignored, err := o.resolve(path)
+			if err != nil {
+				return "", nil, err
+			}
+			if ignored {
+				return "", nil, nil
+			}
+			if start > 0 {
+				fopts.Lines = fmt.Sprintf("%d:%d", start, end)
+			}
+
+			code, res, err := r.Format(ctx, content, path, &fopts.Options)
+			if err != nil {
+				return "", nil, err
+			}
+			// В режиме проверки изменения только публикуются в диагностиках
+			if fopts.Mode == project.ModeReview {
+				code = ""
+			}
+			var upds []*entity.Update
+			if res != nil {
+				upds = res.Updates
+			}
+			return code, upds, nil
+		}
+
+		if err := lsp.NewServer(os.Stdin, os.Stdout, format, diagnostics).Run(); err != nil {
+			i18n.Fprintf(os.Stderr, "LSP server error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	LspCmd.Flags().StringP("model", "m", "deepseek/deepseek-chat:free", i18n.Sprintf("AI model for formatting"))
+	LspCmd.Flags().BoolP("comments", "c", false, i18n.Sprintf("Add comments to the code. The comments language is set in the configuration"))
+	LspCmd.Flags().Bool("diagnostics", true, i18n.Sprintf("Check code when a file is opened or saved and publish diagnostics"))
+}
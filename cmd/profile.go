@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/config"
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/logging"
+	"github.com/seelentov/aifmt/pkg/api"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ProfileName - имя профиля из глобального флага --profile
+var ProfileName string
+
+// ProfileCmd - команда для управления профилями
+var ProfileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: i18n.Sprintf("Manage connection profiles"),
+	Long: i18n.Sprintf(`Manage named profiles. A profile sets the provider, the API address, the API key
+source, the default model, the temperature and the number of retries.
+
+The active profile is selected by the --profile flag, the AIFMT_PROFILE
+environment variable or the 'aifmt profile use' command.`),
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: i18n.Sprintf("List profiles"),
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		profiles := viper.GetStringMap("profiles")
+		if len(profiles) == 0 {
+			i18n.Printf("No profiles configured. Add one with 'aifmt profile add'.\n")
+			return
+		}
+
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		current := activeProfileName()
+		for _, name := range names {
+			p, err := loadProfile(name)
+			if err != nil {
+				exitWithError(err)
+			}
+
+			mark := " "
+			if name == current {
+				mark = "*"
+			}
+			fmt.Printf("%s %s\t%s\t%s\t%s\n", mark, name, p.Provider, p.URL(), p.Model)
+		}
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: i18n.Sprintf("Select the active profile"),
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !viper.IsSet("profiles." + args[0]) {
+			exitWithError(i18n.Errorf("profile %q not found", args[0]))
+		}
+
+		viper.Set("profile", args[0])
+		if err := viper.WriteConfig(); err != nil {
+			exitWithError(i18n.Errorf("error saving the configuration: %w", err))
+		}
+
+		i18n.Printf("Active profile: %s\n", args[0])
+	},
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: i18n.Sprintf("Add or change a profile"),
+	Example: i18n.Sprintf(`  # Personal OpenRouter key
+  aifmt profile add personal --provider openrouter --model deepseek/deepseek-chat:free
+
+  # Corporate gateway with the key from an environment variable
+  aifmt profile add work --provider openai --base-url https://llm.example.com/v1 --api-key-env WORK_LLM_KEY
+
+  # Local Ollama
+  aifmt profile add local --provider ollama --model qwen2.5-coder`),
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		prefix := "profiles." + args[0] + "."
+
+		flags := map[string]string{
+			"provider":        "provider",
+			"base-url":        "base_url",
+			"api-key-env":     "api_key_env",
+			"api-key-command": "api_key_command",
+			"api-key-file":    "api_key_file",
+			"model":           "model",
+			"temperature":     "temperature",
+			"max-retry":       "max_retry",
+		}
+
+		for flag, name := range flags {
+			if !cmd.Flags().Changed(flag) && !(flag == "provider" && !viper.IsSet(prefix+name)) {
+				continue
+			}
+
+			raw := cmd.Flag(flag).Value.String()
+			key, err := config.Lookup(prefix + name)
+			if err != nil {
+				exitWithError(err)
+			}
+			value, err := key.Parse(raw)
+			if err != nil {
+				exitWithError(err)
+			}
+			viper.Set(prefix+name, value)
+		}
+
+		if err := viper.WriteConfig(); err != nil {
+			exitWithError(i18n.Errorf("error saving the configuration: %w", err))
+		}
+
+		i18n.Printf("Profile %s saved\n", args[0])
+	},
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: i18n.Sprintf("Remove a profile"),
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		removed, err := config.Unset(viper.ConfigFileUsed(), "profiles."+args[0])
+		if err != nil {
+			exitWithError(err)
+		}
+		if !removed {
+			exitWithError(i18n.Errorf("profile %q not found", args[0]))
+		}
+
+		if viper.GetString("profile") == args[0] {
+			if _, err := config.Unset(viper.ConfigFileUsed(), "profile"); err != nil {
+				exitWithError(err)
+			}
+		}
+
+		i18n.Printf("Profile %s removed\n", args[0])
+	},
+}
+
+// activeProfileName возвращает имя активного профиля: из флага --profile,
+// переменной окружения AIFMT_PROFILE или конфигурации
+func activeProfileName() string {
+	if ProfileName != "" {
+		return ProfileName
+	}
+	if name := os.Getenv("AIFMT_PROFILE"); name != "" {
+		return name
+	}
+	return viper.GetString("profile")
+}
+
+// loadProfile читает профиль из конфигурации
+func loadProfile(name string) (*config.Profile, error) {
+	if !viper.IsSet("profiles." + name) {
+		return nil, i18n.Errorf("profile %q not found", name)
+	}
+
+	p := &config.Profile{}
+	if err := viper.UnmarshalKey("profiles."+name, p); err != nil {
+		return nil, i18n.Errorf("error reading profile %q: %w", name, err)
+	}
+	p.Name = name
+	if p.Provider == "" {
+		p.Provider = config.ProviderOpenRouter
+	}
+
+	return p, nil
+}
+
+// activeProfile возвращает активный профиль или профиль по умолчанию, построенный из глобальной конфигурации
+func activeProfile() *config.Profile {
+	name := activeProfileName()
+	if name == "" {
+		return &config.Profile{Provider: config.ProviderOpenRouter, BaseURL: viper.GetString("base_url")}
+	}
+
+	p, err := loadProfile(name)
+	if err != nil {
+		exitWithError(err)
+	}
+	return p
+}
+
+// apiClient создает клиент API для активного профиля. Клиент создается один раз
+// на команду и используется всеми горутинами
+func apiClient() *api.Client {
+	p := activeProfile()
+
+	headers := viper.GetStringMapString("headers")
+	for name, value := range p.Headers {
+		headers[name] = value
+	}
+
+	temperature := api.DefaultTemperature
+	if p.Temperature != nil {
+		temperature = *p.Temperature
+	}
+
+	client, err := api.New(api.Config{
+		BaseURL:            p.URL(),
+		Token:              apiKey(p),
+		Temperature:        temperature,
+		Headers:            headers,
+		Timeout:            viper.GetDuration("timeout"),
+		Proxy:              viper.GetString("proxy"),
+		CAFile:             viper.GetString("ca_file"),
+		InsecureSkipVerify: viper.GetBool("insecure_skip_verify"),
+		TLSMinVersion:      viper.GetString("tls_min_version"),
+	})
+	if err != nil {
+		exitWithError(err)
+	}
+	client.HTTPClient.Transport = logging.Transport(client.HTTPClient.Transport, logger(), DebugDir)
+
+	return client
+}
+
+// defaultModel возвращает модель по умолчанию: из активного профиля, глобальной конфигурации или значение флага
+func defaultModel(flagValue string) string {
+	if p := activeProfile(); p.Model != "" {
+		return p.Model
+	}
+	if model := viper.GetString("model"); model != "" {
+		return model
+	}
+	return flagValue
+}
+
+// maxRetries возвращает количество повторных попыток с учетом активного профиля
+func maxRetries() int {
+	if p := activeProfile(); p.MaxRetry != nil {
+		return *p.MaxRetry
+	}
+	return viper.GetInt("max_retry")
+}
+
+func init() {
+	profileAddCmd.Flags().String("provider", config.ProviderOpenRouter, i18n.Sprintf("API provider: %s", strings.Join(config.Providers(), ", ")))
+	profileAddCmd.Flags().String("base-url", "", i18n.Sprintf("API address, the provider address by default"))
+	profileAddCmd.Flags().String("api-key-env", "", i18n.Sprintf("Environment variable with the API key"))
+	profileAddCmd.Flags().String("api-key-command", "", i18n.Sprintf("Command that prints the API key"))
+	profileAddCmd.Flags().String("api-key-file", "", i18n.Sprintf("Path to a file with the API key"))
+	profileAddCmd.Flags().String("model", "", i18n.Sprintf("Default AI model"))
+	profileAddCmd.Flags().Float64("temperature", api.DefaultTemperature, i18n.Sprintf("Generation temperature"))
+	profileAddCmd.Flags().Int("max-retry", 5, i18n.Sprintf("Maximum number of retries"))
+
+	ProfileCmd.AddCommand(profileListCmd, profileUseCmd, profileAddCmd, profileRemoveCmd)
+}
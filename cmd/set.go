@@ -1,10 +1,13 @@
 package cmd
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/seelentov/aifmt/internal/config"
+	"github.com/seelentov/aifmt/internal/i18n"
+	_ "github.com/seelentov/aifmt/internal/i18n/uilang" // Язык интерфейса выбирается до создания команд
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -12,24 +15,13 @@ import (
 // SetCmd - команда для установки значений в конфигурации
 var SetCmd = &cobra.Command{
 	Use:   "set <key> <value>",
-	Short: "Установка значения в конфигурации",
-	Long: `Установка или обновление значения в конфигурационном файле.
-Пример: aifmt set api_token ваш_токен`,
+	Short: i18n.Sprintf("Set a configuration value"),
+	Long: i18n.Sprintf(`Set or update a value in the configuration file.
+Equivalent to 'aifmt config set'.
+Example: aifmt set max_retry 3`),
 	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
-		key := args[0]
-		value := args[1]
-
-		// Установка значения в конфигурации
-		viper.Set(key, value)
-
-		// Сохранение конфигурации
-		if err := viper.WriteConfig(); err != nil {
-			fmt.Printf("Ошибка сохранения конфигурации: %v\n", err)
-			os.Exit(1)
-		}
-
-		fmt.Printf("Значение '%s' успешно установлено для ключа '%s'\n", value, key)
+		setConfigValue(args[0], args[1])
 	},
 }
 
@@ -38,7 +30,7 @@ func InitConfig() {
 	// Получение домашней директории пользователя
 	home, err := os.UserHomeDir()
 	if err != nil {
-		fmt.Printf("Ошибка получения домашней директории: %v\n", err)
+		i18n.Printf("Error getting the home directory: %v\n", err)
 		os.Exit(1)
 	}
 
@@ -49,11 +41,18 @@ func InitConfig() {
 	// Создание конфигурационной директории, если она не существует
 	if _, err := os.Stat(configDir); os.IsNotExist(err) {
 		if err := os.Mkdir(configDir, 0700); err != nil {
-			fmt.Printf("Ошибка создания конфигурационной директории: %v\n", err)
+			i18n.Printf("Error creating the configuration directory: %v\n", err)
 			os.Exit(1)
 		}
 	}
 
+	// Значения по умолчанию для ключей, отсутствующих в конфигурации
+	for _, key := range config.Schema {
+		if key.Default != nil {
+			viper.SetDefault(key.Name, key.Default)
+		}
+	}
+
 	// Настройка Viper для работы с конфигурационным файлом
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
@@ -63,21 +62,22 @@ func InitConfig() {
 	// Чтение конфигурационного файла или создание нового, если он отсутствует
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			fmt.Println("Конфигурационный файл не найден, создается новый в", configPath)
+			i18n.Fprintf(os.Stderr, "Configuration file not found, creating a new one in %s\n", configPath)
 
 			// Установка значений по умолчанию
-			viper.Set("comments_language", "Русский")
-			viper.Set("api_key", "")
-			viper.Set("max_retry", 5)
-			viper.Set("channels", 10)
+			for _, key := range config.Schema {
+				if key.Default != nil {
+					viper.Set(key.Name, key.Default)
+				}
+			}
 
 			// Запись конфигурации в файл
 			if err := viper.SafeWriteConfigAs(configPath); err != nil {
-				fmt.Printf("Ошибка записи конфигурации: %v\n", err)
+				i18n.Printf("Error writing the configuration: %v\n", err)
 				os.Exit(1)
 			}
 		} else {
-			fmt.Printf("Ошибка чтения конфигурации: %v\n", err)
+			i18n.Printf("Error reading the configuration: %v\n", err)
 			os.Exit(1)
 		}
 	}
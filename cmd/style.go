@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/style"
+	"github.com/seelentov/aifmt/internal/syntax"
+
+	"github.com/spf13/cobra"
+)
+
+// StyleCmd - команда для вывода правил стиля, которые добавляются в запрос к ИИ
+var StyleCmd = &cobra.Command{
+	Use:   i18n.Sprintf("style [flags] files..."),
+	Short: i18n.Sprintf("Print the project style rules for files"),
+	Long: i18n.Sprintf(`Print the style rules that aifmt finds in the configuration of the project's
+formatters and linters and adds to the AI prompt: .editorconfig, .prettierrc,
+.golangci.yml, the black and ruff sections of pyproject.toml, .clang-format.
+
+The search goes from the file's directory up to the root of the git repository.
+Adding the rules to the prompt is disabled by the style configuration key or
+the style setting in .aifmt.yaml.`),
+	Example: `  aifmt style main.go
+  aifmt style -l typescript src/app.tsx`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		language, _ := cmd.Flags().GetString("language")
+
+		for _, file := range args {
+			lang := language
+			if lang == "" {
+				lang = syntax.Language(file)
+			}
+
+			// Правила выводятся на языке запросов, в том виде, в котором они попадают в запрос
+			rules, err := style.Discover(file, lang, i18n.Printer(promptLanguage()))
+			if err != nil {
+				exitWithError(err)
+			}
+
+			fmt.Printf("%s:\n", file)
+			if len(rules) == 0 {
+				i18n.Printf("  no style rules found\n")
+				continue
+			}
+			for _, r := range rules {
+				fmt.Printf("  %s (%s)\n", r.Text, filepath.Base(r.Source))
+			}
+		}
+	},
+}
+
+func init() {
+	StyleCmd.Flags().StringP("language", "l", "", i18n.Sprintf("Programming language of the files, detected from the extension by default"))
+}
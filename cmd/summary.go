@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// Причины отмены обработки
+var (
+	errInterrupted = i18n.Error("interrupted by a signal")
+	errTimeout     = i18n.Error("total time limit exceeded (--timeout)")
+)
+
+// commandContext создает контекст команды, который отменяется по сигналу SIGINT или SIGTERM
+// и по истечении timeout, если он больше нуля. После первого сигнала обработка сигналов
+// возвращается к стандартной, поэтому повторный Ctrl-C завершает программу немедленно
+func commandContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case sig := <-signals:
+			i18n.Fprintf(logOut, "Received signal %v, finishing processing. A second signal exits immediately\n", sig)
+			cancel(errInterrupted)
+		case <-ctx.Done():
+		}
+	}()
+
+	if timeout <= 0 {
+		return ctx, func() { cancel(nil) }
+	}
+
+	tctx, tcancel := context.WithTimeoutCause(ctx, timeout, errTimeout)
+	return tctx, func() {
+		tcancel()
+		cancel(nil)
+	}
+}
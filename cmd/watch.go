@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/project"
+	"github.com/seelentov/aifmt/internal/syntax"
+	"github.com/seelentov/aifmt/internal/watch"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// defaultWatchIgnore - каталоги, которые не отслеживаются по умолчанию
+var defaultWatchIgnore = []string{".git/", "node_modules/", "vendor/"}
+
+// WatchCmd - команда для форматирования файлов при сохранении
+var WatchCmd = &cobra.Command{
+	Use:   i18n.Sprintf("watch [flags] [paths...]"),
+	Short: i18n.Sprintf("Format files on save"),
+	Long: i18n.Sprintf(`Watch files and directories (recursively) and format files after they are
+saved. The current directory is watched by default.
+
+A file is processed once it has not changed for --debounce. Changes written by
+the command itself are not processed again. If a file changed during
+formatting, the result is not written and the file is formatted again. The
+project configuration .aifmt.yaml, --ignore patterns and hidden directories
+are taken into account, as well as the .git, node_modules and vendor
+directories.
+
+In review mode (--mode review) changes are only printed, files are not changed.`),
+	Example: i18n.Sprintf(`  # Format files of the current directory on save
+  aifmt watch
+
+  # Only print the proposed changes for the internal directory
+  aifmt watch --mode review internal
+
+  # Exclude generated files
+  aifmt watch --ignore "*.pb.go" --ignore "gen/"`),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+
+		opts := &fmtOptions{explicit: make(map[string]bool)}
+		opts.Language, _ = cmd.Flags().GetString("language")
+		opts.model, _ = cmd.Flags().GetString("model")
+		opts.Mode, _ = cmd.Flags().GetString("mode")
+		opts.Comments, _ = cmd.Flags().GetBool("comments")
+		opts.Skip = true
+		opts.MaxRetries = maxRetries()
+		opts.CommentsLanguage = viper.GetString("comments_language")
+		opts.AllowCommentChanges = !viper.GetBool("check_comments")
+		debounce, _ := cmd.Flags().GetDuration("debounce")
+		ignore, _ := cmd.Flags().GetStringSlice("ignore")
+
+		for _, name := range []string{"language", "model", "mode", "comments"} {
+			opts.explicit[name] = cmd.Flags().Changed(name)
+		}
+		if !opts.explicit["model"] {
+			opts.model = defaultModel(opts.model)
+		}
+
+		if opts.Mode != project.ModeFormat && opts.Mode != project.ModeReview {
+			exitWithError(i18n.Errorf("invalid mode %q, allowed values: %s, %s", opts.Mode, project.ModeFormat, project.ModeReview))
+		}
+
+		ignore = append(ignore, defaultWatchIgnore...)
+		roots := make([]string, 0, len(args))
+		for _, arg := range args {
+			abs, err := filepath.Abs(arg)
+			if err != nil {
+				exitWithError(err)
+			}
+			roots = append(roots, abs)
+		}
+
+		w, err := watch.New(args, watch.Options{
+			Debounce: debounce,
+			Ignore: func(path string, dir bool) bool {
+				return watchIgnored(roots, ignore, path, dir)
+			},
+		})
+		if err != nil {
+			exitWithError(err)
+		}
+		defer w.Close()
+
+		r := newRunner(0)
+		log := r.Logger
+
+		ctx, cancel := commandContext(0)
+		defer cancel()
+
+		log.Info("watching files, press Ctrl-C to stop", "paths", strings.Join(args, ", "), "mode", opts.Mode)
+
+		err = w.Run(ctx, func(file string) {
+			fopts, ignored, err := opts.resolve(file)
+			if ignored || err != nil && opts.Language == "" && syntax.Language(file) == "" {
+				return
+			}
+			if err != nil {
+				log.Error("configuration error", "file", file, "err", err)
+				return
+			}
+
+			content, err := os.ReadFile(file)
+			if err != nil {
+				log.Error("error reading file", "file", file, "err", err)
+				return
+			}
+
+			u, _, err := r.Format(ctx, string(content), file, &fopts.Options)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Error("failed to process file", "file", file, "err", err)
+				}
+				return
+			}
+
+			if fopts.Mode == project.ModeReview || u == string(content) {
+				return
+			}
+
+			// Файл мог быть изменен во время форматирования: такие изменения не перезаписываются,
+			// файл будет обработан заново по событию его изменения
+			current, err := os.ReadFile(file)
+			if err != nil || string(current) != string(content) {
+				log.Warn("file changed during formatting, result not written", "file", file)
+				return
+			}
+
+			w.MarkWritten(file, []byte(u))
+			if err := os.WriteFile(file, []byte(u), 0644); err != nil {
+				log.Error("error writing file", "file", file, "err", err)
+				return
+			}
+			log.Info("file updated", "file", file)
+		})
+		if err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+// watchIgnored проверяет, исключен ли путь из наблюдения: скрытые файлы и каталоги и пути,
+// подходящие под шаблоны относительно одного из отслеживаемых каталогов
+func watchIgnored(roots, patterns []string, path string, dir bool) bool {
+	if strings.HasPrefix(filepath.Base(path), ".") {
+		return true
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		rel = filepath.ToSlash(rel)
+		if dir {
+			rel += "/"
+		}
+		for _, pattern := range patterns {
+			if project.Match(pattern, rel) || dir && project.Match(pattern, strings.TrimSuffix(rel, "/")) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func init() {
+	WatchCmd.Flags().StringP("language", "l", "", i18n.Sprintf("Programming language of the files, detected from the extension by default"))
+	WatchCmd.Flags().StringP("model", "m", "deepseek/deepseek-chat:free", i18n.Sprintf("AI model for formatting. Several comma-separated models can be given"))
+	WatchCmd.Flags().String("mode", project.ModeFormat, i18n.Sprintf("Mode: format - format and write the file, review - only print the proposed changes"))
+	WatchCmd.Flags().BoolP("comments", "c", false, i18n.Sprintf("Add comments to the code. The comments language is set in the configuration"))
+	WatchCmd.Flags().Duration("debounce", watch.DefaultDebounce, i18n.Sprintf("Time to wait after the last change of a file before formatting it"))
+	WatchCmd.Flags().StringSlice("ignore", nil, i18n.Sprintf("Patterns of files and directories not to watch"))
+}
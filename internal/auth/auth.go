@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// Переменные окружения, из которых читается API ключ, в порядке приоритета
+var EnvVars = []string{"AIFMT_API_KEY", "OPENROUTER_API_KEY"}
+
+// ErrNotFound возвращается, если API ключ не найден ни в одном источнике
+var ErrNotFound = i18n.Error("API key not found")
+
+// Source - настройки источников API ключа из конфигурации
+type Source struct {
+	Env     string // Переменная окружения с ключом, проверяется перед остальными
+	Command string // Команда, выводящая ключ в stdout, например "pass show openrouter"
+	File    string // Путь к файлу с ключом
+	Plain   string // Ключ, сохраненный в конфигурации открытым текстом (устаревший способ)
+}
+
+// Resolve возвращает API ключ и описание источника, из которого он получен.
+// Источники проверяются по порядку: переменные окружения, команда, файл,
+// хранилище учетных данных и, наконец, значение из конфигурации
+func Resolve(src Source) (string, string, error) {
+	for _, name := range append([]string{src.Env}, EnvVars...) {
+		if key := strings.TrimSpace(os.Getenv(name)); name != "" && key != "" {
+			return key, i18n.Sprintf("environment variable %s", name), nil
+		}
+	}
+
+	if key, source, err := ResolveExplicit(Source{Command: src.Command, File: src.File}); err != ErrNotFound {
+		return key, source, err
+	}
+
+	if store := DefaultStore(); store != nil {
+		key, err := store.Get()
+		if err == nil && key != "" {
+			return key, store.Name(), nil
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return "", "", err
+		}
+	}
+
+	if src.Plain != "" {
+		return src.Plain, i18n.Sprintf("configuration (plain text)"), nil
+	}
+
+	return "", "", ErrNotFound
+}
+
+// ResolveExplicit возвращает API ключ только из явно указанных источников: переменной
+// окружения, команды и файла. Используется для профилей с собственным источником ключа
+func ResolveExplicit(src Source) (string, string, error) {
+	if src.Env != "" {
+		if key := strings.TrimSpace(os.Getenv(src.Env)); key != "" {
+			return key, i18n.Sprintf("environment variable %s", src.Env), nil
+		}
+	}
+
+	if src.Command != "" {
+		key, err := runCommand(src.Command)
+		if err != nil {
+			return "", "", err
+		}
+		return key, i18n.Sprintf("api_key_command command"), nil
+	}
+
+	if src.File != "" {
+		key, err := readFile(src.File)
+		if err != nil {
+			return "", "", err
+		}
+		return key, i18n.Sprintf("file %s", src.File), nil
+	}
+
+	return "", "", ErrNotFound
+}
+
+// runCommand выполняет команду-помощник и возвращает первую строку ее вывода
+func runCommand(command string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	c := exec.Command("sh", "-c", command)
+	c.Stdout, c.Stderr = &stdout, &stderr
+	if err := c.Run(); err != nil {
+		return "", i18n.Errorf("error running api_key_command: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	key, _, _ := strings.Cut(stdout.String(), "\n")
+	if key = strings.TrimSpace(key); key == "" {
+		return "", i18n.Error("api_key_command printed no API key")
+	}
+
+	return key, nil
+}
+
+// readFile читает ключ из файла. Путь может начинаться с ~
+func readFile(path string) (string, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", i18n.Errorf("error reading api_key_file: %w", err)
+	}
+
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", i18n.Errorf("file %s contains no API key", path)
+	}
+
+	return key, nil
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", i18n.Errorf("error getting the home directory: %w", err)
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
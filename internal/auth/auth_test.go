@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("PATH", "/bin:/usr/bin")
+	for _, name := range EnvVars {
+		t.Setenv(name, "")
+	}
+
+	if _, _, err := Resolve(Source{}); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	if key, _, err := Resolve(Source{Plain: "plain-key"}); err != nil || key != "plain-key" {
+		t.Errorf("plain: got %q, %v", key, err)
+	}
+
+	file := filepath.Join(home, "key")
+	if err := os.WriteFile(file, []byte("file-key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if key, _, err := Resolve(Source{File: file, Plain: "plain-key"}); err != nil || key != "file-key" {
+		t.Errorf("file: got %q, %v", key, err)
+	}
+
+	if key, _, err := Resolve(Source{Command: "echo command-key", File: file}); err != nil || key != "command-key" {
+		t.Errorf("command: got %q, %v", key, err)
+	}
+
+	t.Setenv("OPENROUTER_API_KEY", "env-key")
+	if key, _, err := Resolve(Source{Command: "echo command-key"}); err != nil || key != "env-key" {
+		t.Errorf("env: got %q, %v", key, err)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	store := &fileStore{path: filepath.Join(t.TempDir(), "credentials")}
+
+	if _, err := store.Get(); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.Set("secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(store.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("unexpected permissions %v", info.Mode().Perm())
+	}
+
+	if key, err := store.Get(); err != nil || key != "secret" {
+		t.Errorf("Get = %q, %v", key, err)
+	}
+	if err := store.Delete(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeychainAddCommand(t *testing.T) {
+	cmd, err := keychainAddCommand(`sk-"a\b`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `add-generic-password -U -s aifmt -a api_key -w "sk-\"a\\b"` + "\n"; cmd != want {
+		t.Errorf("got %q, want %q", cmd, want)
+	}
+	if _, err := keychainAddCommand("sk\nquit"); err == nil {
+		t.Error("expected error for key with newline")
+	}
+}
@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+const (
+	service = "aifmt"
+	account = "api_key"
+)
+
+// Store - хранилище API ключа
+type Store interface {
+	Name() string
+	Get() (string, error)
+	Set(key string) error
+	Delete() error
+}
+
+// DefaultStore возвращает хранилище ОС, если оно доступно, иначе файл учетных данных
+// в каталоге конфигурации. Возвращает nil, если домашняя директория недоступна
+func DefaultStore() Store {
+	if keyring := Keyring(); keyring != nil {
+		return &fallbackStore{primary: keyring, secondary: FileStore()}
+	}
+	if file := FileStore(); file != nil {
+		return file
+	}
+	return nil
+}
+
+// Keyring возвращает хранилище учетных данных ОС (Keychain в macOS, Secret Service
+// в Linux через secret-tool) или nil, если оно недоступно
+func Keyring() Store {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err == nil {
+			return &keychain{}
+		}
+	case "linux", "freebsd", "openbsd":
+		if _, err := exec.LookPath("secret-tool"); err == nil {
+			return &secretTool{}
+		}
+	}
+	return nil
+}
+
+// FileStore возвращает хранилище в файле ~/.aifmt/credentials, доступном только владельцу
+func FileStore() Store {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return &fileStore{path: filepath.Join(home, ".aifmt", "credentials")}
+}
+
+// keychain - связка ключей macOS
+type keychain struct{}
+
+func (k *keychain) Name() string {
+	return i18n.Sprintf("macOS keychain")
+}
+
+func (k *keychain) Get() (string, error) {
+	out, err := run(nil, "security", "find-generic-password", "-s", service, "-a", account, "-w")
+	if err != nil {
+		return "", ErrNotFound
+	}
+	return out, nil
+}
+
+// Set передает команду security через стандартный ввод в интерактивном режиме -i, чтобы
+// ключ не попал в аргументы процесса, которые видны другим пользователям
+func (k *keychain) Set(key string) error {
+	cmd, err := keychainAddCommand(key)
+	if err != nil {
+		return err
+	}
+	_, err = run(strings.NewReader(cmd), "security", "-i")
+	return err
+}
+
+// keychainAddCommand возвращает команду add-generic-password для интерактивного режима security.
+// Ключ заключается в кавычки, кавычки и обратная косая черта в нем экранируются
+func keychainAddCommand(key string) (string, error) {
+	if strings.ContainsAny(key, "\r\n") {
+		return "", i18n.Error("the API key cannot contain a line break")
+	}
+	quoted := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(key)
+	return fmt.Sprintf("add-generic-password -U -s %s -a %s -w \"%s\"\n", service, account, quoted), nil
+}
+
+func (k *keychain) Delete() error {
+	if _, err := run(nil, "security", "delete-generic-password", "-s", service, "-a", account); err != nil {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// secretTool - Secret Service (GNOME Keyring, KWallet) через утилиту secret-tool
+type secretTool struct{}
+
+func (s *secretTool) Name() string {
+	return "Secret Service"
+}
+
+func (s *secretTool) Get() (string, error) {
+	out, err := run(nil, "secret-tool", "lookup", "service", service, "account", account)
+	if err != nil || out == "" {
+		return "", ErrNotFound
+	}
+	return out, nil
+}
+
+func (s *secretTool) Set(key string) error {
+	_, err := run(strings.NewReader(key), "secret-tool", "store", "--label=aifmt API key", "service", service, "account", account)
+	return err
+}
+
+func (s *secretTool) Delete() error {
+	_, err := run(nil, "secret-tool", "clear", "service", service, "account", account)
+	return err
+}
+
+// fileStore - файл учетных данных с правами 0600
+type fileStore struct {
+	path string
+}
+
+func (f *fileStore) Name() string {
+	return i18n.Sprintf("file %s", f.path)
+}
+
+func (f *fileStore) Get() (string, error) {
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", i18n.Errorf("error reading %s: %w", f.path, err)
+	}
+
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", ErrNotFound
+	}
+	return key, nil
+}
+
+func (f *fileStore) Set(key string) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return i18n.Errorf("error creating directory %s: %w", filepath.Dir(f.path), err)
+	}
+	if err := os.WriteFile(f.path, []byte(key+"\n"), 0600); err != nil {
+		return i18n.Errorf("error writing %s: %w", f.path, err)
+	}
+	return nil
+}
+
+func (f *fileStore) Delete() error {
+	if err := os.Remove(f.path); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return i18n.Errorf("error removing %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// fallbackStore использует хранилище ОС, а если оно не работает (например, нет сеанса D-Bus) - файл
+type fallbackStore struct {
+	primary   Store
+	secondary Store
+	used      Store
+}
+
+func (f *fallbackStore) Name() string {
+	if f.used != nil {
+		return f.used.Name()
+	}
+	return f.primary.Name()
+}
+
+func (f *fallbackStore) Get() (string, error) {
+	if key, err := f.primary.Get(); err == nil {
+		f.used = f.primary
+		return key, nil
+	}
+	f.used = f.secondary
+	return f.secondary.Get()
+}
+
+func (f *fallbackStore) Set(key string) error {
+	if err := f.primary.Set(key); err == nil {
+		f.used = f.primary
+		return nil
+	}
+	f.used = f.secondary
+	return f.secondary.Set(key)
+}
+
+func (f *fallbackStore) Delete() error {
+	errPrimary := f.primary.Delete()
+	errSecondary := f.secondary.Delete()
+	if errPrimary != nil && errSecondary != nil {
+		return errors.Join(errPrimary, errSecondary)
+	}
+	return nil
+}
+
+// run выполняет команду и возвращает ее вывод без пробельных символов по краям
+func run(stdin *strings.Reader, name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	c := exec.Command(name, args...)
+	if stdin != nil {
+		c.Stdin = stdin
+	}
+	c.Stdout, c.Stderr = &stdout, &stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,151 @@
+// Package batch реализует пакетный режим: запросы на форматирование файлов собираются
+// в файл JSONL в формате Batch API OpenAI, отправляются провайдеру одним заданием,
+// а результаты позже применяются через обычный конвейер форматирования
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/pkg/api"
+)
+
+// Endpoint - адрес API, для которого формируются запросы задания
+const Endpoint = "/v1/chat/completions"
+
+// Состояния задания, совпадают с состояниями Batch API OpenAI
+const (
+	StateValidating = "validating"
+	StateInProgress = "in_progress"
+	StateFinalizing = "finalizing"
+	StateCompleted  = "completed"
+	StateFailed     = "failed"
+	StateExpired    = "expired"
+	StateCancelling = "cancelling"
+	StateCancelled  = "cancelled"
+)
+
+// ErrNoResult возвращается, если в результатах задания нет ответа на запрос
+var ErrNoResult = i18n.Error("the job results contain no response to the request")
+
+// Line - строка входного файла задания
+type Line struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// Output - строка файла результатов задания
+type Output struct {
+	ID       string          `json:"id,omitempty"`
+	CustomID string          `json:"custom_id"`
+	Response *OutputResponse `json:"response"`
+	Error    *OutputError    `json:"error"`
+}
+
+// OutputResponse - ответ API на запрос задания
+type OutputResponse struct {
+	StatusCode int             `json:"status_code"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// OutputError - ошибка выполнения запроса задания
+type OutputError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Status - состояние задания у провайдера
+type Status struct {
+	State     string // Состояние задания
+	Total     int    // Количество запросов
+	Completed int    // Количество выполненных запросов
+	Failed    int    // Количество запросов, завершившихся ошибкой
+}
+
+// Finished сообщает, что задание больше не выполняется
+func (s *Status) Finished() bool {
+	switch s.State {
+	case StateCompleted, StateFailed, StateExpired, StateCancelled:
+		return true
+	}
+	return false
+}
+
+// Backend - провайдер, выполняющий задания
+type Backend interface {
+	// Submit отправляет входной файл задания и возвращает идентификатор задания у провайдера
+	Submit(ctx context.Context, input []byte) (string, error)
+	// Status возвращает состояние задания
+	Status(ctx context.Context, id string) (*Status, error)
+	// Fetch возвращает файл результатов выполненного задания
+	Fetch(ctx context.Context, id string) ([]byte, error)
+}
+
+// Key возвращает ключ запроса к модели, по которому ответ сопоставляется с запросом
+func Key(model string, dialog []*api.Message) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00", model)
+	for _, m := range dialog {
+		fmt.Fprintf(h, "%v\x00%s\x00", m.IsUser, m.Text)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Encode возвращает входной файл задания в формате JSONL
+func Encode(lines []*Line) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, line := range lines {
+		if err := enc.Encode(line); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Answer - ответ модели на запрос задания или ошибка его выполнения
+type Answer struct {
+	Content string
+	Err     error
+}
+
+// Decode разбирает файл результатов задания и возвращает ответы по идентификаторам запросов
+func Decode(data []byte) (map[string]*Answer, error) {
+	res := make(map[string]*Answer)
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+
+		out := &Output{}
+		if err := json.Unmarshal(sc.Bytes(), out); err != nil {
+			return nil, i18n.Errorf("error parsing line %d of the results file: %w", n, err)
+		}
+
+		a := &Answer{}
+		switch {
+		case out.Error != nil:
+			a.Err = i18n.Errorf("request failed: %s %s", out.Error.Code, out.Error.Message)
+		case out.Response == nil:
+			a.Err = i18n.Errorf("%w: no response", api.ErrInvalidResponse)
+		case out.Response.StatusCode != 200:
+			a.Err = &api.StatusError{StatusCode: out.Response.StatusCode, Body: string(out.Response.Body)}
+		default:
+			a.Content, a.Err = api.ParseCompletion(out.Response.Body)
+		}
+		res[out.CustomID] = a
+	}
+
+	return res, sc.Err()
+}
@@ -0,0 +1,262 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seelentov/aifmt/pkg/api"
+	"github.com/seelentov/aifmt/pkg/api/apitest"
+)
+
+func dialogOf(text string) []*api.Message {
+	return []*api.Message{{Text: text, IsUser: true}}
+}
+
+// input возвращает входной файл задания с запросами для указанных текстов
+func input(t *testing.T, client *api.Client, texts ...string) []byte {
+	t.Helper()
+	var lines []*Line
+	for i, text := range texts {
+		body, err := client.RequestBody("test-model", dialogOf(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, &Line{CustomID: fmt.Sprintf("file-%d", i+1), Method: "POST", URL: Endpoint, Body: body})
+	}
+	data, err := Encode(lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestLocal(t *testing.T) {
+	srv := apitest.NewServer(func(req *apitest.Request) apitest.Response {
+		if req.Prompt() == "fail" {
+			return apitest.Response{Status: http.StatusTooManyRequests, Body: `{"error":"rate limit"}`}
+		}
+		return apitest.Response{Content: apitest.Formatted(strings.ToUpper(req.Prompt()))}
+	})
+	defer srv.Close()
+
+	l := &Local{Client: srv.Client(), Dir: t.TempDir()}
+	ctx := context.Background()
+
+	id, err := l.Submit(ctx, input(t, srv.Client(), "a", "fail", "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := l.Status(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Finished() || st.Total != 3 || st.Completed != 2 || st.Failed != 1 {
+		t.Errorf("unexpected status: %+v", st)
+	}
+
+	data, err := l.Fetch(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answers, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := answers["file-1"]; a == nil || a.Err != nil || a.Content != apitest.Formatted("A") {
+		t.Errorf("unexpected answer for file-1: %+v", a)
+	}
+	var statusErr *api.StatusError
+	if a := answers["file-2"]; a == nil || !errors.As(a.Err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("unexpected answer for file-2: %+v", a)
+	}
+}
+
+// fakeBatchAPI - фейковый Batch API OpenAI, выполняющий задание сразу при создании
+type fakeBatchAPI struct {
+	files   map[string]string
+	output  string
+	purpose string
+}
+
+func (f *fakeBatchAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer test" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/v1/files":
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		f.purpose = r.FormValue("purpose")
+		f.files["file-in"] = string(data)
+		fmt.Fprint(w, `{"id":"file-in"}`)
+
+	case r.Method == "POST" && r.URL.Path == "/v1/batches":
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req["input_file_id"] != "file-in" || req["endpoint"] != Endpoint {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		var out []string
+		for _, line := range strings.Split(strings.TrimSpace(f.files["file-in"]), "\n") {
+			l := &Line{}
+			json.Unmarshal([]byte(line), l)
+			resp, _ := json.Marshal(&Output{CustomID: l.CustomID, Response: &OutputResponse{StatusCode: 200, Body: json.RawMessage(apitest.Completion("ok " + l.CustomID))}})
+			out = append(out, string(resp))
+		}
+		f.files["file-out"] = strings.Join(out, "\n")
+		fmt.Fprint(w, `{"id":"batch-1","status":"validating"}`)
+
+	case r.Method == "GET" && r.URL.Path == "/v1/batches/batch-1":
+		fmt.Fprint(w, `{"id":"batch-1","status":"completed","output_file_id":"file-out","request_counts":{"total":2,"completed":2,"failed":0}}`)
+
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/files/") && strings.HasSuffix(r.URL.Path, "/content"):
+		content, ok := f.files[strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/files/"), "/content")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, content)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestOpenAI(t *testing.T) {
+	fake := &fakeBatchAPI{files: make(map[string]string)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	client := api.NewClient("test")
+	client.BaseURL = srv.URL + "/v1"
+	o := &OpenAI{Client: client}
+	ctx := context.Background()
+
+	id, err := o.Submit(ctx, input(t, client, "a", "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "batch-1" || fake.purpose != "batch" {
+		t.Errorf("unexpected id %q or purpose %q", id, fake.purpose)
+	}
+
+	st, err := o.Status(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Finished() || st.Completed != 2 {
+		t.Errorf("unexpected status: %+v", st)
+	}
+
+	data, err := o.Fetch(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answers, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(answers) != 2 || answers["file-2"].Content != "ok file-2" {
+		t.Errorf("unexpected answers: %+v", answers)
+	}
+
+	if _, err := o.Status(ctx, "missing"); err == nil {
+		t.Error("expected error for unknown batch")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	data := `{"custom_id":"ok","response":{"status_code":200,"body":` + apitest.Completion("x") + `}}
+
+{"custom_id":"failed","response":null,"error":{"code":"expired","message":"batch expired"}}
+{"custom_id":"empty","response":null,"error":null}
+`
+	answers, err := Decode([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answers["ok"].Content != "x" || answers["ok"].Err != nil {
+		t.Errorf("unexpected answer: %+v", answers["ok"])
+	}
+	if answers["failed"].Err == nil || !strings.Contains(answers["failed"].Err.Error(), "batch expired") {
+		t.Errorf("expected error, got %+v", answers["failed"])
+	}
+	if !errors.Is(answers["empty"].Err, api.ErrInvalidResponse) {
+		t.Errorf("expected ErrInvalidResponse, got %v", answers["empty"].Err)
+	}
+
+	if _, err := Decode([]byte("not json\n")); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := &Store{Dir: t.TempDir()}
+
+	if jobs, err := s.List(); err != nil || len(jobs) != 0 {
+		t.Fatalf("expected no jobs, got %v, %v", jobs, err)
+	}
+
+	first := &Job{ID: s.NewID(), State: StateValidating, Created: time.Now().Add(-time.Hour)}
+	if err := s.Save(first); err != nil {
+		t.Fatal(err)
+	}
+	second := &Job{ID: s.NewID(), State: StateCompleted, Created: time.Now(), Files: []*File{{CustomID: "file-1", Path: "/a.go"}}}
+	if second.ID == first.ID {
+		t.Fatalf("duplicate id %s", second.ID)
+	}
+	if err := s.Save(second); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[0].ID != second.ID || jobs[0].Files[0].Path != "/a.go" {
+		t.Errorf("unexpected jobs: %+v", jobs)
+	}
+
+	if _, err := s.Load("missing"); err == nil {
+		t.Error("expected error for missing job")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	job := &Job{Files: []*File{
+		{CustomID: "file-1", Key: Key("m", dialogOf("a"))},
+		{CustomID: "file-2", Key: Key("m", dialogOf("b"))},
+	}}
+	r := NewReplay(job, map[string]*Answer{
+		"file-1": {Content: `{"code":"A"}`},
+		"file-2": {Err: errors.New("failed")},
+	})
+	ctx := context.Background()
+
+	var res struct{ Code string }
+	if err := r.GetAnswerContext(ctx, "m", dialogOf("a"), &res); err != nil || res.Code != "A" {
+		t.Errorf("unexpected result %+v, %v", res, err)
+	}
+	if err := r.GetAnswerContext(ctx, "m", dialogOf("b"), &res); err == nil || err.Error() != "failed" {
+		t.Errorf("expected answer error, got %v", err)
+	}
+	if err := r.GetAnswerContext(ctx, "other", dialogOf("a"), &res); !errors.Is(err, ErrNoResult) {
+		t.Errorf("expected ErrNoResult for another model, got %v", err)
+	}
+}
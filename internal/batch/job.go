@@ -0,0 +1,172 @@
+package batch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/runner"
+	"github.com/seelentov/aifmt/pkg/api"
+)
+
+// File - файл, запрос на форматирование которого включен в задание
+type File struct {
+	CustomID string         `json:"custom_id"` // Идентификатор запроса в задании
+	Path     string         `json:"path"`      // Абсолютный путь к файлу
+	Hash     string         `json:"hash"`      // Хеш содержимого файла на момент отправки
+	Key      string         `json:"key"`       // Ключ запроса к модели
+	Options  runner.Options `json:"options"`   // Параметры форматирования
+}
+
+// Job - задание, сохраненное локально
+type Job struct {
+	ID        string    `json:"id"`                // Локальный идентификатор задания
+	Backend   string    `json:"backend"`           // Провайдер, выполняющий задание
+	RemoteID  string    `json:"remote_id"`         // Идентификатор задания у провайдера
+	State     string    `json:"state"`             // Последнее известное состояние
+	Created   time.Time `json:"created"`           // Время отправки
+	Total     int       `json:"total"`             // Количество запросов
+	Completed int       `json:"completed"`         // Количество выполненных запросов
+	Failed    int       `json:"failed"`            // Количество запросов, завершившихся ошибкой
+	Fetched   bool      `json:"fetched"`           // Результаты скачаны
+	Applied   time.Time `json:"applied,omitempty"` // Время применения результатов
+	Files     []*File   `json:"files"`
+}
+
+// Update записывает в задание состояние, полученное от провайдера
+func (j *Job) Update(st *Status) {
+	j.State, j.Total, j.Completed, j.Failed = st.State, st.Total, st.Completed, st.Failed
+}
+
+// Hash возвращает хеш содержимого файла
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Store - локальное хранилище заданий: для каждого задания сохраняются описание,
+// входной файл и файл результатов
+type Store struct {
+	Dir string
+}
+
+// NewID возвращает идентификатор нового задания
+func (s *Store) NewID() string {
+	base := time.Now().Format("20060102-150405")
+	id := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(s.path(id)); errors.Is(err, os.ErrNotExist) {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// Save сохраняет задание
+func (s *Store) Save(job *Job) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return i18n.Errorf("error creating the jobs directory: %w", err)
+	}
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(job.ID), data, 0600)
+}
+
+// Load читает задание
+func (s *Store) Load(id string) (*Job, error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, i18n.Errorf("job %s not found, list the jobs with: aifmt batch status", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	job := &Job{}
+	if err := json.Unmarshal(data, job); err != nil {
+		return nil, i18n.Errorf("error reading job %s: %w", id, err)
+	}
+	return job, nil
+}
+
+// List возвращает все задания, начиная с самых новых
+func (s *Store) List() ([]*Job, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*Job
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		job, err := s.Load(id)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Created.After(jobs[j].Created) })
+	return jobs, nil
+}
+
+// InputPath возвращает путь к входному файлу задания
+func (s *Store) InputPath(id string) string {
+	return filepath.Join(s.Dir, id+".input.jsonl")
+}
+
+// OutputPath возвращает путь к скачанному файлу результатов задания
+func (s *Store) OutputPath(id string) string {
+	return filepath.Join(s.Dir, id+".results.jsonl")
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.Dir, id+".json")
+}
+
+// Replay - модель, отвечающая на запросы ответами из результатов задания. Запрос
+// сопоставляется с ответом по ключу Key, поэтому ответ находится, только если
+// файл и параметры форматирования не изменились после отправки задания
+type Replay struct {
+	answers map[string]*Answer
+}
+
+// NewReplay создает модель из результатов задания
+func NewReplay(job *Job, answers map[string]*Answer) *Replay {
+	r := &Replay{answers: make(map[string]*Answer)}
+	for _, f := range job.Files {
+		if a, ok := answers[f.CustomID]; ok {
+			r.answers[f.Key] = a
+		}
+	}
+	return r
+}
+
+// GetAnswerContext возвращает ответ из результатов задания
+func (r *Replay) GetAnswerContext(ctx context.Context, model string, dialog []*api.Message, target interface{}) error {
+	a, ok := r.answers[Key(model, dialog)]
+	if !ok {
+		return ErrNoResult
+	}
+	if a.Err != nil {
+		return a.Err
+	}
+	return api.Decode(a.Content, target)
+}
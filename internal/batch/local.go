@@ -0,0 +1,120 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/pkg/api"
+)
+
+// Local - локальный заменитель Batch API: запросы задания выполняются по одному обычным API
+// Chat Completions при отправке, а результаты сохраняются в каталоге Dir. Используется
+// для провайдеров без пакетного API, например Ollama, и в тестах
+type Local struct {
+	Client *api.Client // Клиент API для выполнения запросов
+	Dir    string      // Каталог для файлов результатов
+}
+
+// Submit выполняет запросы задания и сохраняет результаты
+func (l *Local) Submit(ctx context.Context, input []byte) (string, error) {
+	var lines []*Line
+	for n, raw := range strings.Split(strings.TrimSpace(string(input)), "\n") {
+		line := &Line{}
+		if err := json.Unmarshal([]byte(raw), line); err != nil {
+			return "", i18n.Errorf("error parsing line %d of the input file: %w", n+1, err)
+		}
+		lines = append(lines, line)
+	}
+
+	outputs := make([]*Output, 0, len(lines))
+	for _, line := range lines {
+		out := &Output{CustomID: line.CustomID}
+
+		body, err := l.Client.Complete(ctx, line.Body)
+		var statusErr *api.StatusError
+		switch {
+		case ctx.Err() != nil:
+			return "", context.Cause(ctx)
+		case err == nil:
+			out.Response = &OutputResponse{StatusCode: 200, Body: body}
+		case errors.As(err, &statusErr):
+			out.Response = &OutputResponse{StatusCode: statusErr.StatusCode, Body: json.RawMessage(quote(statusErr.Body))}
+		default:
+			out.Error = &OutputError{Code: "request_failed", Message: err.Error()}
+		}
+		outputs = append(outputs, out)
+	}
+
+	id := "local-" + time.Now().Format("20060102-150405.000000")
+	data, err := encodeOutputs(outputs)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(l.Dir, 0700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(l.path(id), data, 0600); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// Status возвращает состояние выполненного задания
+func (l *Local) Status(ctx context.Context, id string) (*Status, error) {
+	data, err := os.ReadFile(l.path(id))
+	if err != nil {
+		return nil, i18n.Errorf("job %s not found: %w", id, err)
+	}
+
+	answers, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Status{State: StateCompleted, Total: len(answers)}
+	for _, a := range answers {
+		if a.Err != nil {
+			st.Failed++
+		} else {
+			st.Completed++
+		}
+	}
+	return st, nil
+}
+
+// Fetch возвращает файл результатов задания
+func (l *Local) Fetch(ctx context.Context, id string) ([]byte, error) {
+	return os.ReadFile(l.path(id))
+}
+
+func (l *Local) path(id string) string {
+	return filepath.Join(l.Dir, id+".output.jsonl")
+}
+
+func encodeOutputs(outputs []*Output) ([]byte, error) {
+	var data []byte
+	for _, out := range outputs {
+		line, err := json.Marshal(out)
+		if err != nil {
+			return nil, err
+		}
+		data = append(append(data, line...), '\n')
+	}
+	return data, nil
+}
+
+// quote возвращает тело ответа как JSON: без изменений, если оно корректно, иначе строкой
+func quote(body string) []byte {
+	if json.Valid([]byte(body)) {
+		return []byte(body)
+	}
+	data, _ := json.Marshal(body)
+	return data
+}
@@ -0,0 +1,148 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/pkg/api"
+)
+
+// OpenAI - провайдер с Batch API OpenAI: входной файл загружается через /files,
+// задание создается через /batches, результаты скачиваются из выходного файла
+type OpenAI struct {
+	Client *api.Client // Клиент API, BaseURL указывает на адрес вида https://api.openai.com/v1
+}
+
+// batchObject - задание Batch API
+type batchObject struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	OutputFileID  string `json:"output_file_id"`
+	ErrorFileID   string `json:"error_file_id"`
+	RequestCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+}
+
+// Submit загружает входной файл и создает задание
+func (o *OpenAI) Submit(ctx context.Context, input []byte) (string, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if err := mw.WriteField("purpose", "batch"); err != nil {
+		return "", err
+	}
+	fw, err := mw.CreateFormFile("file", "aifmt-batch.jsonl")
+	if err != nil {
+		return "", err
+	}
+	if _, err := fw.Write(input); err != nil {
+		return "", err
+	}
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := o.call(ctx, "POST", "/files", mw.FormDataContentType(), &body, &file); err != nil {
+		return "", i18n.Errorf("error uploading the job input file: %w", err)
+	}
+
+	req, _ := json.Marshal(map[string]string{
+		"input_file_id":     file.ID,
+		"endpoint":          Endpoint,
+		"completion_window": "24h",
+	})
+	var b batchObject
+	if err := o.call(ctx, "POST", "/batches", "application/json", bytes.NewReader(req), &b); err != nil {
+		return "", i18n.Errorf("error creating the job: %w", err)
+	}
+
+	return b.ID, nil
+}
+
+// Status возвращает состояние задания
+func (o *OpenAI) Status(ctx context.Context, id string) (*Status, error) {
+	b, err := o.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &Status{State: b.Status, Total: b.RequestCounts.Total, Completed: b.RequestCounts.Completed, Failed: b.RequestCounts.Failed}, nil
+}
+
+// Fetch скачивает файл результатов и файл ошибок задания
+func (o *OpenAI) Fetch(ctx context.Context, id string) ([]byte, error) {
+	b, err := o.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	for _, fileID := range []string{b.OutputFileID, b.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		var content bytes.Buffer
+		if err := o.call(ctx, "GET", "/files/"+fileID+"/content", "", nil, &content); err != nil {
+			return nil, i18n.Errorf("error downloading file %s: %w", fileID, err)
+		}
+		data = append(data, content.Bytes()...)
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			data = append(data, '\n')
+		}
+	}
+
+	return data, nil
+}
+
+func (o *OpenAI) get(ctx context.Context, id string) (*batchObject, error) {
+	b := &batchObject{}
+	if err := o.call(ctx, "GET", "/batches/"+id, "", nil, b); err != nil {
+		return nil, i18n.Errorf("error getting the state of job %s: %w", id, err)
+	}
+	return b, nil
+}
+
+// call выполняет запрос к API и разбирает ответ в target. Если target - *bytes.Buffer,
+// в него записывается тело ответа без разбора
+func (o *OpenAI) call(ctx context.Context, method, path, contentType string, body io.Reader, target interface{}) error {
+	req, err := o.Client.NewRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return i18n.Errorf("error reading the response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &api.StatusError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+
+	if buf, ok := target.(*bytes.Buffer); ok {
+		buf.Write(data)
+		return nil
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("%w: %v", api.ErrInvalidResponse, err)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+// Package comments извлекает комментарии из исходного кода, определяет их естественный язык
+// и проверяет, что после форматирования исходные комментарии не удалены и не переведены
+package comments
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// preserved - доля слов комментария, которые должны остаться в комментариях результата,
+// чтобы комментарий считался сохраненным. Допускает исправление опечаток и перенос строк
+const preserved = 0.8
+
+// lexer - синтаксис комментариев и строк языка
+type lexer struct {
+	line   []string    // Начала однострочных комментариев
+	block  [][2]string // Начала и концы многострочных комментариев
+	quotes string      // Символы кавычек строк
+	triple bool        // Строки в тройных кавычках, как в Python
+	chars  bool        // Одинарные кавычки - символьные литералы ('a'), а не строки
+}
+
+var (
+	cLike  = &lexer{line: []string{"//"}, block: [][2]string{{"/*", "*/"}}, quotes: "\"'`", chars: true}
+	jsLike = &lexer{line: []string{"//"}, block: [][2]string{{"/*", "*/"}}, quotes: "\"'`"}
+	script = &lexer{line: []string{"#"}, quotes: "\"'"}
+)
+
+// lexers - синтаксис комментариев по языкам
+var lexers = map[string]*lexer{
+	"go":         cLike,
+	"javascript": jsLike,
+	"typescript": jsLike,
+	"java":       cLike,
+	"kotlin":     cLike,
+	"rust":       cLike,
+	"c":          cLike,
+	"cpp":        cLike,
+	"csharp":     cLike,
+	"swift":      cLike,
+	"scala":      cLike,
+	"dart":       jsLike,
+	"php":        {line: []string{"//", "#"}, block: [][2]string{{"/*", "*/"}}, quotes: "\"'"},
+	"python":     {line: []string{"#"}, quotes: "\"'", triple: true},
+	"ruby":       script,
+	"bash":       script,
+	"yaml":       script,
+	"toml":       script,
+	"sql":        {line: []string{"--"}, block: [][2]string{{"/*", "*/"}}, quotes: "\"'"},
+	"css":        {block: [][2]string{{"/*", "*/"}}, quotes: "\"'"},
+	"html":       {block: [][2]string{{"<!--", "-->"}}},
+}
+
+// Extract возвращает тексты комментариев кода без символов комментария. Для неизвестного языка возвращается nil
+func Extract(language, code string) []string {
+	lx, ok := lexers[strings.ToLower(language)]
+	if !ok {
+		return nil
+	}
+
+	var res []string
+	for i := 0; i < len(code); {
+		rest := code[i:]
+
+		if prefix := hasAny(rest, lx.line); prefix != "" {
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			res = append(res, strings.TrimSpace(rest[len(prefix):end]))
+			i += end
+			continue
+		}
+
+		if block, ok := hasBlock(rest, lx.block); ok {
+			body := rest[len(block[0]):]
+			end := strings.Index(body, block[1])
+			if end < 0 {
+				end = len(body)
+			}
+			res = append(res, cleanBlock(body[:end]))
+			i += len(block[0]) + end + len(block[1])
+			continue
+		}
+
+		if strings.IndexByte(lx.quotes, rest[0]) >= 0 {
+			i += lx.skipString(rest)
+			continue
+		}
+
+		i++
+	}
+	return res
+}
+
+// skipString возвращает длину строкового литерала в начале s
+func (lx *lexer) skipString(s string) int {
+	q := s[0]
+
+	if lx.triple && len(s) >= 3 && s[1] == q && s[2] == q {
+		if end := strings.Index(s[3:], s[:3]); end >= 0 {
+			return end + 6
+		}
+		return len(s)
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && q != '`':
+			i++
+		case s[i] == q:
+			return i + 1
+		case s[i] == '\n' && q != '`':
+			return i
+		}
+		// Одинарная кавычка без близкой пары - не символьный литерал, а, например, время жизни в Rust
+		if q == '\'' && lx.chars && i >= 12 {
+			return 1
+		}
+	}
+	if q == '\'' && lx.chars {
+		return 1
+	}
+	return len(s)
+}
+
+func hasAny(s string, prefixes []string) string {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+func hasBlock(s string, blocks [][2]string) ([2]string, bool) {
+	for _, b := range blocks {
+		if strings.HasPrefix(s, b[0]) {
+			return b, true
+		}
+	}
+	return [2]string{}, false
+}
+
+// cleanBlock удаляет звездочки в начале строк многострочного комментария
+func cleanBlock(body string) string {
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(strings.TrimSpace(line), "*")
+	}
+	return strings.TrimSpace(strings.Join(strings.Fields(strings.Join(lines, "\n")), " "))
+}
+
+// words возвращает слова текста в нижнем регистре
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Lost возвращает комментарии исходного кода, которых нет в отформатированном коде: удаленные
+// или переведенные на другой язык. Комментарий считается сохраненным, если большая часть его
+// слов есть в комментариях отформатированного кода, поэтому перенос строк, исправление опечаток
+// и перемещение комментария допускаются
+func Lost(language, original, formatted string) []string {
+	orig := Extract(language, original)
+	if len(orig) == 0 {
+		return nil
+	}
+
+	present := make(map[string]bool)
+	for _, c := range Extract(language, formatted) {
+		for _, w := range words(c) {
+			present[w] = true
+		}
+	}
+
+	var lost []string
+	for _, c := range orig {
+		ws := words(c)
+		if len(ws) == 0 {
+			continue
+		}
+		found := 0
+		for _, w := range ws {
+			if present[w] {
+				found++
+			}
+		}
+		if float64(found) < preserved*float64(len(ws)) {
+			lost = append(lost, c)
+		}
+	}
+	return lost
+}
+
+// Check возвращает ошибку, если в отформатированном коде удалены или переведены исходные комментарии
+func Check(language, original, formatted string) error {
+	lost := Lost(language, original, formatted)
+	if len(lost) == 0 {
+		return nil
+	}
+
+	const shown = 3
+	quoted := make([]string, 0, shown)
+	for _, c := range lost[:min(len(lost), shown)] {
+		if r := []rune(c); len(r) > 60 {
+			c = string(r[:60]) + "..."
+		}
+		quoted = append(quoted, fmt.Sprintf("%q", c))
+	}
+	msg := strings.Join(quoted, ", ")
+	if len(lost) > shown {
+		msg += i18n.Sprintf(" and %d more", len(lost)-shown)
+	}
+	return i18n.Errorf("original comments were removed or translated: %s", msg)
+}
+
+// Strip возвращает код без комментариев и пробельных символов, чтобы сравнить код, в котором
+// изменились только комментарии и форматирование. Строки в тройных кавычках, которые стоят
+// в начале строки, как docstring в Python, считаются комментариями. Для неизвестного языка
+// возвращается false
+func Strip(language, code string) (string, bool) {
+	lx, ok := lexers[strings.ToLower(language)]
+	if !ok {
+		return "", false
+	}
+
+	var b strings.Builder
+	lineStart := true
+	for i := 0; i < len(code); {
+		rest := code[i:]
+
+		if prefix := hasAny(rest, lx.line); prefix != "" {
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			i += end
+			continue
+		}
+
+		if block, ok := hasBlock(rest, lx.block); ok {
+			end := strings.Index(rest[len(block[0]):], block[1])
+			if end < 0 {
+				end = len(rest) - len(block[0]) - len(block[1])
+			}
+			i += len(block[0]) + end + len(block[1])
+			continue
+		}
+
+		if strings.IndexByte(lx.quotes, rest[0]) >= 0 {
+			n := lx.skipString(rest)
+			docstring := lx.triple && lineStart && n >= 6 && rest[1] == rest[0] && rest[2] == rest[0]
+			if !docstring {
+				b.WriteString(rest[:n])
+			}
+			lineStart = false
+			i += n
+			continue
+		}
+
+		switch c := rest[0]; {
+		case c == '\n':
+			lineStart = true
+		case unicode.IsSpace(rune(c)):
+		default:
+			b.WriteByte(c)
+			lineStart = false
+		}
+		i++
+	}
+	return b.String(), true
+}
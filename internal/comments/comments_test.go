@@ -0,0 +1,119 @@
+package comments
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+func TestExtract(t *testing.T) {
+	for _, tc := range []struct {
+		language, code string
+		want           []string
+	}{
+		{"go", "package main // пакет\n\n/*\n * Многострочный\n * комментарий\n */\nvar s = \"// не комментарий\" + `/* и это */`\n", []string{"пакет", "Многострочный комментарий"}},
+		{"rust", "fn f<'a>(s: &'a str) -> char { 'x' } // время жизни\n", []string{"время жизни"}},
+		{"javascript", "const url = 'http://example.com' // адрес\n", []string{"адрес"}},
+		{"python", "def f():\n    \"\"\"Документация # не комментарий\"\"\"\n    return '#' # решетка\n", []string{"решетка"}},
+		{"sql", "SELECT '--' -- выборка\n/* блок */", []string{"выборка", "блок"}},
+		{"unknown", "// комментарий", nil},
+	} {
+		if got := Extract(tc.language, tc.code); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Extract(%s) = %q, want %q", tc.language, got, tc.want)
+		}
+	}
+}
+
+func TestLost(t *testing.T) {
+	original := `package main
+
+// Format форматирует код и возвращает результат
+func Format() {} // точка входа
+
+//go:generate stringer -type=Mode
+`
+	for _, tc := range []struct {
+		name, formatted string
+		lost            int
+	}{
+		{"unchanged", original, 0},
+		{"rewrapped and moved", "package main\n\n// Format форматирует код\n// и возвращает результат\nfunc Format() {\n\t// точка входа\n}\n\n//go:generate stringer -type=Mode\n", 0},
+		{"typo fixed", strings.Replace(original, "возвращает результат", "возвращает результаты", 1), 0},
+		{"deleted", "package main\n\n// Format форматирует код и возвращает результат\nfunc Format() {}\n\n//go:generate stringer -type=Mode\n", 1},
+		{"translated", "package main\n\n// Format formats the code and returns the result\nfunc Format() {} // entry point\n\n//go:generate stringer -type=Mode\n", 2},
+	} {
+		if got := Lost("go", original, tc.formatted); len(got) != tc.lost {
+			t.Errorf("%s: lost %q, want %d comments", tc.name, got, tc.lost)
+		}
+	}
+
+	if err := Check("go", original, "package main\n"); err == nil || !strings.Contains(err.Error(), "точка входа") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	for _, tc := range []struct {
+		comments []string
+		want     string
+	}{
+		{[]string{"Format форматирует код", "Ошибка чтения файла", "return nil"}, "Russian"},
+		{[]string{"Format formats the code", "go:generate stringer", "Функция"}, "English"},
+		{[]string{"Функція форматує код і повертає результат"}, "Ukrainian"},
+		{[]string{"Gibt die Größe zurück", "Prüft den Schlüssel"}, "German"},
+		{[]string{"格式化代码并返回结果"}, "Chinese"},
+		{[]string{"コードを整形して結果を返します"}, "Japanese"},
+		{[]string{"x", "TODO"}, ""},
+		{nil, ""},
+	} {
+		if got := Detect(tc.comments); got != tc.want {
+			t.Errorf("Detect(%q) = %q, want %q", tc.comments, got, tc.want)
+		}
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	code := "// Format formats the code\nfunc Format() {}\n"
+	if got := Language("Русский", "go", code, i18n.English); got != "Русский" {
+		t.Errorf("explicit language must be used, got %q", got)
+	}
+	if got := Language(Auto, "go", code, i18n.English); got != "English" {
+		t.Errorf("expected detected language, got %q", got)
+	}
+	if got := Language(Auto, "go", code, i18n.Russian); got != "Английский" {
+		t.Errorf("expected detected language in the prompt language, got %q", got)
+	}
+	if got := Language("", "go", "func Format() {}\n", i18n.English); got != Fallback {
+		t.Errorf("expected fallback language, got %q", got)
+	}
+	if got := Language("", "go", "func Format() {}\n", i18n.Russian); got != "Русский" {
+		t.Errorf("expected the prompt language as fallback, got %q", got)
+	}
+}
+
+func TestStrip(t *testing.T) {
+	for _, tc := range []struct {
+		language, a, b string
+		same           bool
+	}{
+		{"javascript", "function f(a) { return a }", "/**\n * Возвращает аргумент.\n * @param {number} a\n */\nfunction f(a) {\n  return a // аргумент\n}\n", true},
+		{"javascript", "function f(a) { return a }", "/** Возвращает аргумент */\nfunction f(a) { return a + 1 }", false},
+		{"javascript", "const s = '// нет'", "const s = '// да'", false},
+		{"python", "def f(a):\n    return a\n", "def f(a):\n    \"\"\"Возвращает аргумент.\n\n    Args:\n        a: аргумент.\n    \"\"\"\n    return a\n", true},
+		{"python", "s = \"\"\"текст\"\"\"\n", "s = \"\"\"другой текст\"\"\"\n", false},
+	} {
+		a, ok := Strip(tc.language, tc.a)
+		if !ok {
+			t.Fatalf("Strip(%s) = false, want true", tc.language)
+		}
+		b, _ := Strip(tc.language, tc.b)
+		if (a == b) != tc.same {
+			t.Errorf("Strip(%s): %q == %q is %v, want %v", tc.language, a, b, a == b, tc.same)
+		}
+	}
+	if _, ok := Strip("unknown", "код"); ok {
+		t.Error("Strip(unknown) = true, want false")
+	}
+}
@@ -0,0 +1,151 @@
+package comments
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+
+	"golang.org/x/text/language"
+)
+
+// Auto - значение настройки языка комментариев, при котором используется язык существующих комментариев
+const Auto = "auto"
+
+// Fallback - язык комментариев, если язык существующих комментариев определить не удалось
+// и язык запроса к ИИ не русский. Для русского запроса используется русский язык
+const Fallback = "English"
+
+// minLetters - минимальное количество букв в комментариях для определения языка
+const minLetters = 10
+
+// latinMarks - буквы, характерные для языков с латинским алфавитом
+var latinMarks = []struct {
+	language string
+	letters  string
+}{
+	{"German", "äöüß"},
+	{"French", "àâçèéêëîïôœùûÿ"},
+	{"Spanish", "áíñóú¿¡"},
+	{"Polish", "ąćęłńśźż"},
+	{"Portuguese", "ãõ"},
+	{"Czech", "čďěňřšťůž"},
+	{"Turkish", "ğış"},
+}
+
+// directives - начала служебных комментариев, которые не учитываются при определении языка
+var directives = []string{"go:", "+build", "nolint", "!", "-*-", "eslint", "prettier-ignore", "noqa", "type:", "pylint:", "fmt:", "@ts-", "#region", "#endregion"}
+
+// Detect определяет преобладающий естественный язык комментариев. Каждый комментарий
+// голосует за язык своего алфавита, поэтому закомментированный код и имена из кода
+// в отдельных комментариях не меняют результат. Язык возвращается английским названием,
+// которое служит ключом каталога сообщений. Если язык определить не удалось, возвращается
+// пустая строка
+func Detect(comments []string) string {
+	votes := make(map[string]int)
+	letters := 0
+
+	for _, c := range comments {
+		if isDirective(c) {
+			continue
+		}
+		lang, n := detectOne(c)
+		if lang != "" {
+			votes[lang]++
+			letters += n
+		}
+	}
+	if letters < minLetters {
+		return ""
+	}
+
+	best, count := "", 0
+	for lang, n := range votes {
+		if n > count || n == count && lang < best {
+			best, count = lang, n
+		}
+	}
+	return best
+}
+
+// detectOne определяет язык одного комментария по алфавиту и характерным буквам.
+// Возвращает язык и количество букв
+func detectOne(text string) (string, int) {
+	var cyrillic, latin, han, kana, hangul, other int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.IsLetter(r):
+			other++
+		}
+	}
+
+	total := cyrillic + latin + han + kana + hangul + other
+	lower := strings.ToLower(text)
+	switch max(cyrillic, latin, han+kana, hangul, other) {
+	case 0:
+		return "", 0
+	case cyrillic:
+		if strings.ContainsAny(lower, "іїєґ") {
+			return "Ukrainian", total
+		}
+		return "Russian", total
+	case latin:
+		best, count := "English", 0
+		for _, m := range latinMarks {
+			n := 0
+			for _, r := range lower {
+				if strings.ContainsRune(m.letters, r) {
+					n++
+				}
+			}
+			if n > count {
+				best, count = m.language, n
+			}
+		}
+		return best, total
+	case han + kana:
+		if kana > 0 {
+			return "Japanese", total
+		}
+		return "Chinese", total
+	case hangul:
+		return "Korean", total
+	}
+	return "", 0
+}
+
+func isDirective(comment string) bool {
+	for _, d := range directives {
+		if strings.HasPrefix(comment, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// Language возвращает язык комментариев для кода: setting, если язык задан явно, иначе язык
+// существующих комментариев кода, а если в коде нет комментариев - язык запроса prompt
+// или Fallback. Определенный язык называется на языке запроса
+func Language(setting, language, code string, prompt language.Tag) string {
+	if setting != "" && !strings.EqualFold(setting, Auto) {
+		return setting
+	}
+	detected := Detect(Extract(language, code))
+	if detected == "" {
+		detected = Fallback
+		if prompt == i18n.Russian {
+			detected = "Russian"
+		}
+	}
+	return i18n.Printer(prompt).Sprintf(detected)
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReadFile читает файл конфигурации в виде вложенной карты. Отсутствующий файл считается пустым
+func ReadFile(path string) (map[string]interface{}, error) {
+	settings := make(map[string]interface{})
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, i18n.Errorf("error reading configuration %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, i18n.Errorf("error parsing configuration %s: %w", path, err)
+	}
+
+	return settings, nil
+}
+
+// WriteFile записывает настройки в файл конфигурации с правами только для владельца
+func WriteFile(path string, settings map[string]interface{}) error {
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return i18n.Errorf("error encoding the configuration: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return i18n.Errorf("error writing configuration %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Unset удаляет ключ из файла конфигурации. Вложенные ключи указываются через точку.
+// Возвращает false, если ключ отсутствовал в файле
+func Unset(path, key string) (bool, error) {
+	settings, err := ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	if !remove(settings, strings.Split(strings.ToLower(key), ".")) {
+		return false, nil
+	}
+
+	return true, WriteFile(path, settings)
+}
+
+// remove удаляет ключ из вложенной карты, удаляя опустевшие разделы
+func remove(settings map[string]interface{}, path []string) bool {
+	for name, value := range settings {
+		if strings.ToLower(name) != path[0] {
+			continue
+		}
+
+		if len(path) == 1 {
+			delete(settings, name)
+			return true
+		}
+
+		nested, ok := value.(map[string]interface{})
+		if !ok || !remove(nested, path[1:]) {
+			return false
+		}
+		if len(nested) == 0 {
+			delete(settings, name)
+		}
+		return true
+	}
+
+	return false
+}
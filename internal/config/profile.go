@@ -0,0 +1,65 @@
+package config
+
+import (
+	"sort"
+)
+
+// Провайдеры API
+const (
+	ProviderOpenRouter = "openrouter"
+	ProviderOpenAI     = "openai"
+	ProviderOllama     = "ollama"
+)
+
+// providerURLs - адреса API провайдеров по умолчанию
+var providerURLs = map[string]string{
+	ProviderOpenRouter: "https://openrouter.ai/api/v1",
+	ProviderOpenAI:     "https://api.openai.com/v1",
+	ProviderOllama:     "http://localhost:11434/v1",
+}
+
+// Providers возвращает список поддерживаемых провайдеров
+func Providers() []string {
+	res := make([]string, 0, len(providerURLs))
+	for name := range providerURLs {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Profile - именованный набор настроек подключения: провайдер, адрес API, источник ключа и параметры модели
+type Profile struct {
+	Name          string   `mapstructure:"-" yaml:"-"`
+	Provider      string   `mapstructure:"provider" yaml:"provider,omitempty"`
+	BaseURL       string   `mapstructure:"base_url" yaml:"base_url,omitempty"`
+	APIKeyEnv     string   `mapstructure:"api_key_env" yaml:"api_key_env,omitempty"`
+	APIKeyCommand string   `mapstructure:"api_key_command" yaml:"api_key_command,omitempty"`
+	APIKeyFile    string   `mapstructure:"api_key_file" yaml:"api_key_file,omitempty"`
+	Model         string   `mapstructure:"model" yaml:"model,omitempty"`
+	Temperature   *float64 `mapstructure:"temperature" yaml:"temperature,omitempty"`
+	MaxRetry      *int     `mapstructure:"max_retry" yaml:"max_retry,omitempty"`
+
+	Headers map[string]string `mapstructure:"headers" yaml:"headers,omitempty"`
+}
+
+// URL возвращает адрес API профиля: явно заданный или адрес провайдера по умолчанию
+func (p *Profile) URL() string {
+	if p.BaseURL != "" {
+		return p.BaseURL
+	}
+	if url, ok := providerURLs[p.Provider]; ok {
+		return url
+	}
+	return providerURLs[ProviderOpenRouter]
+}
+
+// HasKeySource сообщает, задан ли в профиле собственный источник API ключа
+func (p *Profile) HasKeySource() bool {
+	return p.APIKeyEnv != "" || p.APIKeyCommand != "" || p.APIKeyFile != ""
+}
+
+// KeyRequired сообщает, требуется ли API ключ для провайдера профиля
+func (p *Profile) KeyRequired() bool {
+	return p.Provider != ProviderOllama
+}
@@ -0,0 +1,344 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// Типы значений конфигурации
+const (
+	TypeString   = "string"
+	TypeInt      = "int"
+	TypeFloat    = "float"
+	TypeDuration = "duration"
+	TypeBool     = "bool"
+	TypeList     = "list"
+)
+
+// Key описывает ключ конфигурации. Сегмент "*" в имени соответствует любому сегменту ключа
+type Key struct {
+	Name        string
+	Type        string
+	Description string      // Описание ключа на английском, переводится при выводе
+	Secret      bool        // Значение скрывается при выводе
+	Default     interface{} // Значение по умолчанию, если есть
+	Min         *int        // Минимальное значение для целых чисел
+	Values      []string    // Допустимые значения для строк
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+// Schema - все поддерживаемые ключи конфигурации
+var Schema = []*Key{
+	{Name: "api_key", Type: TypeString, Secret: true, Description: "OpenRouter API key in plain text (deprecated, use aifmt auth login)"},
+	{Name: "api_key_command", Type: TypeString, Description: "Command that prints the API key, e.g. \"pass show openrouter\""},
+	{Name: "api_key_file", Type: TypeString, Description: "Path to a file with the API key"},
+	{Name: "model", Type: TypeString, Description: "Default AI model"},
+	{Name: "fallback_models", Type: TypeList, Description: "Comma-separated fallback models, tried in order when the main model is unavailable"},
+	{Name: "comments_language", Type: TypeString, Default: "auto", Description: "Language of code comments, auto - the language of the file's existing comments"},
+	{Name: "prompt_language", Type: TypeString, Values: []string{"en", "ru"}, Description: "Language of AI prompts, the interface language by default"},
+	{Name: "check_comments", Type: TypeBool, Default: true, Description: "Reject results that remove or translate the original comments"},
+	{Name: "style", Type: TypeBool, Default: true, Description: "Add style rules from .editorconfig, .prettierrc, .golangci.yml, pyproject.toml and .clang-format to the prompt"},
+	{Name: "formatters.*", Type: TypeString, Description: "Language code formatter run before and after the AI: a command reading the code from standard input ({file} - the file path), builtin or off"},
+	{Name: "max_retry", Type: TypeInt, Default: 5, Min: intPtr(0), Description: "Maximum number of retries"},
+	{Name: "channels", Type: TypeInt, Default: 10, Min: intPtr(1), Description: "Number of files processed in parallel"},
+	{Name: "base_url", Type: TypeString, Description: "API address, unless set by the profile"},
+	{Name: "headers.*", Type: TypeString, Description: "Additional HTTP request header"},
+	{Name: "timeout", Type: TypeDuration, Default: "5m", Description: "Request timeout, e.g. 90s or 5m"},
+	{Name: "proxy", Type: TypeString, Description: "Proxy server address, taken from HTTPS_PROXY and similar environment variables by default"},
+	{Name: "ca_file", Type: TypeString, Description: "File with additional root certificates in PEM format"},
+	{Name: "insecure_skip_verify", Type: TypeBool, Default: false, Description: "Do not verify the server certificate"},
+	{Name: "tls_min_version", Type: TypeString, Values: []string{"1.0", "1.1", "1.2", "1.3"}, Description: "Minimum TLS version"},
+	{Name: "profile", Type: TypeString, Description: "Active profile"},
+	{Name: "profiles.*.provider", Type: TypeString, Values: Providers(), Description: "Profile provider"},
+	{Name: "profiles.*.base_url", Type: TypeString, Description: "Profile API address"},
+	{Name: "profiles.*.api_key_env", Type: TypeString, Description: "Environment variable with the profile API key"},
+	{Name: "profiles.*.api_key_command", Type: TypeString, Description: "Command that prints the profile API key"},
+	{Name: "profiles.*.api_key_file", Type: TypeString, Description: "Path to a file with the profile API key"},
+	{Name: "profiles.*.model", Type: TypeString, Description: "Profile AI model"},
+	{Name: "profiles.*.temperature", Type: TypeFloat, Description: "Profile generation temperature"},
+	{Name: "profiles.*.headers.*", Type: TypeString, Description: "Additional HTTP request header of the profile"},
+	{Name: "profiles.*.max_retry", Type: TypeInt, Min: intPtr(0), Description: "Maximum number of retries of the profile"},
+}
+
+// Lookup возвращает описание ключа. Для неизвестного ключа возвращается ошибка с подсказкой
+func Lookup(name string) (*Key, error) {
+	name = strings.ToLower(name)
+	for _, key := range Schema {
+		if key.match(name) {
+			return key, nil
+		}
+	}
+
+	if s := suggest(name); s != nil {
+		return nil, i18n.Errorf("unknown key %q, did you mean %q (%s)", name, s.Name, s.Describe())
+	}
+	return nil, i18n.Errorf("unknown key %q, list the keys with: aifmt config list", name)
+}
+
+// match проверяет, соответствует ли имя ключа описанию с учетом сегментов "*"
+func (k *Key) match(name string) bool {
+	want := strings.Split(k.Name, ".")
+	got := strings.Split(name, ".")
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if want[i] != "*" && want[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Describe возвращает описание ключа на языке интерфейса
+func (k *Key) Describe() string {
+	return i18n.Sprintf(k.Description)
+}
+
+// isPrefix проверяет, является ли имя началом ключа из схемы (например, раздел profiles.work)
+func isPrefix(name string) bool {
+	got := strings.Split(name, ".")
+	for _, key := range Schema {
+		want := strings.Split(key.Name, ".")
+		if len(want) <= len(got) {
+			continue
+		}
+		ok := true
+		for i := range got {
+			if want[i] != "*" && want[i] != got[i] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse преобразует строковое значение из командной строки в значение нужного типа
+func (k *Key) Parse(raw string) (interface{}, error) {
+	var v interface{}
+
+	switch k.Type {
+	case TypeInt:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, i18n.Errorf("key %s: expected an integer, got %q", k.Name, raw)
+		}
+		v = n
+	case TypeFloat:
+		f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return nil, i18n.Errorf("key %s: expected a number, got %q", k.Name, raw)
+		}
+		v = f
+	case TypeDuration:
+		if _, err := time.ParseDuration(strings.TrimSpace(raw)); err != nil {
+			return nil, i18n.Errorf("key %s: expected a duration such as 90s or 5m, got %q", k.Name, raw)
+		}
+		v = strings.TrimSpace(raw)
+	case TypeBool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, i18n.Errorf("key %s: expected true or false, got %q", k.Name, raw)
+		}
+		v = b
+	case TypeList:
+		list := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v = list
+	default:
+		v = raw
+	}
+
+	if err := k.Check(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Check проверяет тип и допустимость значения, прочитанного из файла конфигурации
+func (k *Key) Check(v interface{}) error {
+	switch k.Type {
+	case TypeInt:
+		n, ok := v.(int)
+		if !ok {
+			return i18n.Errorf("key %s: expected an integer, got %s", k.Name, describe(v))
+		}
+		if k.Min != nil && n < *k.Min {
+			return i18n.Errorf("key %s: the value must be at least %d, got %d", k.Name, *k.Min, n)
+		}
+	case TypeFloat:
+		switch v.(type) {
+		case float64, int:
+		default:
+			return i18n.Errorf("key %s: expected a number, got %s", k.Name, describe(v))
+		}
+	case TypeDuration:
+		str, ok := v.(string)
+		if !ok {
+			return i18n.Errorf("key %s: expected a duration such as 90s or 5m, got %s", k.Name, describe(v))
+		}
+		if _, err := time.ParseDuration(str); err != nil {
+			return i18n.Errorf("key %s: expected a duration such as 90s or 5m, got %q", k.Name, str)
+		}
+	case TypeBool:
+		if _, ok := v.(bool); !ok {
+			return i18n.Errorf("key %s: expected true or false, got %s", k.Name, describe(v))
+		}
+	case TypeList:
+		switch list := v.(type) {
+		case []string:
+		case []interface{}:
+			for _, item := range list {
+				if _, ok := item.(string); !ok {
+					return i18n.Errorf("key %s: list items must be strings, got %s", k.Name, describe(item))
+				}
+			}
+		default:
+			return i18n.Errorf("key %s: expected a list, got %s", k.Name, describe(v))
+		}
+	default:
+		str, ok := v.(string)
+		if !ok {
+			return i18n.Errorf("key %s: expected a string, got %s", k.Name, describe(v))
+		}
+		if len(k.Values) > 0 && !slices.Contains(k.Values, str) {
+			return i18n.Errorf("key %s: invalid value %q, allowed values: %s", k.Name, str, strings.Join(k.Values, ", "))
+		}
+	}
+	return nil
+}
+
+// Format возвращает значение ключа для вывода, скрывая секреты, если showSecrets не установлен
+func (k *Key) Format(v interface{}, showSecrets bool) string {
+	if v == nil {
+		return ""
+	}
+
+	s := fmt.Sprint(v)
+	switch list := v.(type) {
+	case []string:
+		s = strings.Join(list, ",")
+	case []interface{}:
+		items := make([]string, 0, len(list))
+		for _, item := range list {
+			items = append(items, fmt.Sprint(item))
+		}
+		s = strings.Join(items, ",")
+	}
+
+	if k.Secret && !showSecrets {
+		return Mask(s)
+	}
+	return s
+}
+
+// Mask скрывает секретное значение, оставляя видимыми только края длинных значений
+func Mask(s string) string {
+	if s == "" {
+		return ""
+	}
+	if len(s) < 12 {
+		return "****"
+	}
+	return s[:4] + "****" + s[len(s)-4:]
+}
+
+// Validate проверяет настройки, прочитанные из файла конфигурации, и возвращает все найденные ошибки
+func Validate(settings map[string]interface{}) []error {
+	var errs []error
+	validate("", settings, &errs)
+	return errs
+}
+
+func validate(prefix string, settings map[string]interface{}, errs *[]error) {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		full := strings.ToLower(prefix + name)
+		value := settings[name]
+
+		if key, err := Lookup(full); err == nil {
+			if err := key.Check(value); err != nil {
+				*errs = append(*errs, err)
+			}
+			continue
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok && isPrefix(full) {
+			validate(full+".", nested, errs)
+			continue
+		}
+
+		_, err := Lookup(full)
+		*errs = append(*errs, err)
+	}
+}
+
+// describe возвращает описание значения для сообщений об ошибках
+func describe(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return i18n.Sprintf("string %q", v)
+	case nil:
+		return i18n.Sprintf("empty value")
+	default:
+		return fmt.Sprintf("%v (%T)", v, v)
+	}
+}
+
+// suggest возвращает наиболее похожий ключ из схемы
+func suggest(name string) *Key {
+	var best *Key
+	bestDist := 3
+	for _, key := range Schema {
+		if d := distance(name, key.Name); d < bestDist {
+			best, bestDist = key, d
+		}
+	}
+	return best
+}
+
+// distance вычисляет расстояние Левенштейна между строками
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+func TestParse(t *testing.T) {
+	key, err := Lookup("max_retry")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := key.Parse("3")
+	if err != nil || v != 3 {
+		t.Errorf("Parse(3) = %v, %v", v, err)
+	}
+	if _, err := key.Parse("три"); err == nil {
+		t.Error("expected error for non-integer value")
+	}
+	if _, err := key.Parse("-1"); err == nil {
+		t.Error("expected error for negative value")
+	}
+}
+
+func TestLookupSuggestion(t *testing.T) {
+	_, err := Lookup("max_retyr")
+	if err == nil || !strings.Contains(err.Error(), "max_retry") || !strings.Contains(err.Error(), "Maximum number of retries") {
+		t.Errorf("expected suggestion, got %v", err)
+	}
+}
+
+func TestDescriptions(t *testing.T) {
+	ru := i18n.Printer(i18n.Russian)
+	for _, key := range Schema {
+		if key.Description == "" || ru.Sprintf(key.Description) == key.Description {
+			t.Errorf("key %s: description %q is missing or not translated", key.Name, key.Description)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	errs := Validate(map[string]interface{}{
+		"api_key":   "sk-or-v1-secret",
+		"max_retry": "3",
+		"unknown":   true,
+	})
+	if len(errs) != 2 {
+		t.Errorf("expected 2 errors, got %v", errs)
+	}
+}
+
+func TestMask(t *testing.T) {
+	if got := Mask("sk-or-v1-1234567890"); got != "sk-o****7890" {
+		t.Errorf("Mask = %q", got)
+	}
+	if got := Mask("short"); got != "****" {
+		t.Errorf("Mask = %q", got)
+	}
+}
+
+func TestUnset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := WriteFile(path, map[string]interface{}{"model": "a", "max_retry": 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := Unset(path, "model")
+	if err != nil || !removed {
+		t.Fatalf("Unset = %v, %v", removed, err)
+	}
+
+	settings, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := settings["model"]; ok || settings["max_retry"] != 3 {
+		t.Errorf("unexpected settings after unset: %v", settings)
+	}
+}
@@ -0,0 +1,144 @@
+package consensus
+
+import (
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/entity"
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/syntax"
+)
+
+// Стратегии выбора результата из нескольких вариантов
+const (
+	StrategyAgreement    = "agreement"     // Вариант, совпадающий с наибольшим количеством других
+	StrategyJudge        = "judge"         // Вариант, выбранный моделью-судьей
+	StrategySmallestDiff = "smallest-diff" // Вариант с наименьшим количеством измененных строк
+)
+
+// Strategies возвращает список поддерживаемых стратегий выбора
+func Strategies() []string {
+	return []string{StrategyAgreement, StrategyJudge, StrategySmallestDiff}
+}
+
+// Candidate - вариант отформатированного кода, полученный от модели
+type Candidate struct {
+	Model   string           // Модель, вернувшая вариант
+	Code    string           // Отформатированный код
+	Updates []*entity.Update // Предложенные изменения
+	Err     error            // Ошибка получения или проверки синтаксиса варианта
+	Group   int              // Номер группы совпадающих вариантов, начиная с 1. 0 - вариант отброшен
+	Changed int              // Количество измененных строк относительно исходного кода
+}
+
+// Valid сообщает, прошел ли вариант проверки
+func (c *Candidate) Valid() bool {
+	return c.Err == nil
+}
+
+// Analyze проверяет синтаксис вариантов, объединяет совпадающие варианты в группы и считает
+// количество измененных строк. Синтаксис проверяется, только если исходный код корректен,
+// иначе варианты для фрагментов кода были бы всегда отброшены
+func Analyze(language, original string, candidates []*Candidate) {
+	checkSyntax := syntax.Check(language, original) == nil
+
+	var groups []string
+	for _, c := range candidates {
+		if c.Err == nil && c.Code == "" {
+			c.Err = i18n.Error("empty response")
+		}
+		if c.Err == nil && checkSyntax {
+			c.Err = syntax.Check(language, c.Code)
+		}
+		if c.Err != nil {
+			continue
+		}
+
+		c.Changed = Distance(original, c.Code)
+
+		normalized := syntax.Normalize(language, c.Code)
+		c.Group = len(groups) + 1
+		for i, g := range groups {
+			if g == normalized {
+				c.Group = i + 1
+				break
+			}
+		}
+		if c.Group > len(groups) {
+			groups = append(groups, normalized)
+		}
+	}
+}
+
+// Agreement возвращает индекс варианта из самой большой группы совпадающих вариантов.
+// При равенстве размеров групп выбирается вариант с наименьшим количеством изменений.
+// Если корректных вариантов нет, возвращается -1
+func Agreement(candidates []*Candidate) int {
+	sizes := make(map[int]int)
+	for _, c := range candidates {
+		if c.Valid() {
+			sizes[c.Group]++
+		}
+	}
+
+	best := -1
+	for i, c := range candidates {
+		if !c.Valid() {
+			continue
+		}
+		if best < 0 || sizes[c.Group] > sizes[candidates[best].Group] ||
+			sizes[c.Group] == sizes[candidates[best].Group] && c.Changed < candidates[best].Changed {
+			best = i
+		}
+	}
+	return best
+}
+
+// SmallestDiff возвращает индекс корректного варианта с наименьшим количеством измененных строк.
+// Если корректных вариантов нет, возвращается -1
+func SmallestDiff(candidates []*Candidate) int {
+	best := -1
+	for i, c := range candidates {
+		if c.Valid() && (best < 0 || c.Changed < candidates[best].Changed) {
+			best = i
+		}
+	}
+	return best
+}
+
+// Distance возвращает количество добавленных и удаленных строк, необходимых для получения b из a.
+// Строки сравниваются без учета пробелов в конце
+func Distance(a, b string) int {
+	x, y := lines(a), lines(b)
+
+	// Длина наибольшей общей подпоследовательности строк, хранятся только две строки таблицы
+	prev := make([]int, len(y)+1)
+	cur := make([]int, len(y)+1)
+	for i := 1; i <= len(x); i++ {
+		for j := 1; j <= len(y); j++ {
+			switch {
+			case x[i-1] == y[j-1]:
+				cur[j] = prev[j-1] + 1
+			case prev[j] >= cur[j-1]:
+				cur[j] = prev[j]
+			default:
+				cur[j] = cur[j-1]
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return len(x) + len(y) - 2*prev[len(y)]
+}
+
+func lines(s string) []string {
+	s = strings.TrimRight(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if s == "" {
+		return nil
+	}
+
+	res := strings.Split(s, "\n")
+	for i, line := range res {
+		res[i] = strings.TrimRight(line, " \t")
+	}
+	return res
+}
@@ -0,0 +1,70 @@
+package consensus
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"a\nb\nc\n", "a\nb\nc", 0},
+		{"a\nb\nc\n", "a\nx\nc\n", 2},
+		{"a\nb\n", "a\nb\nc\n", 1},
+		{"", "a\n", 1},
+		{"a  \n", "a\n", 0},
+	}
+
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeAndPick(t *testing.T) {
+	original := "package main\n\nfunc main() {\n\tprintln(1)\n}\n"
+
+	candidates := []*Candidate{
+		{Model: "a", Code: "package main\n\nfunc main() {\n\tprintln(2)\n\tprintln(3)\n}\n"},
+		{Model: "b", Code: "package main\n\nfunc main() {\n    println(2)\n    println(3)\n}"},
+		{Model: "c", Code: "package main\n\nfunc main() {\n\tprintln(2)\n}\n"},
+		{Model: "d", Code: "package main\n\nfunc main() {\n"},
+		{Model: "e", Err: errors.New("timeout")},
+		{Model: "f"},
+	}
+
+	Analyze("go", original, candidates)
+
+	if candidates[0].Group != 1 || candidates[1].Group != 1 || candidates[2].Group != 2 {
+		t.Errorf("unexpected groups: %d %d %d", candidates[0].Group, candidates[1].Group, candidates[2].Group)
+	}
+	for _, c := range candidates[3:] {
+		if c.Valid() || c.Group != 0 {
+			t.Errorf("candidate %s must be rejected", c.Model)
+		}
+	}
+
+	if got := Agreement(candidates); got != 0 {
+		t.Errorf("Agreement = %d, want 0", got)
+	}
+	if got := SmallestDiff(candidates); got != 2 {
+		t.Errorf("SmallestDiff = %d, want 2", got)
+	}
+
+	if Agreement(candidates[3:]) != -1 || SmallestDiff(candidates[3:]) != -1 {
+		t.Error("expected -1 without valid candidates")
+	}
+}
+
+func TestAnalyzeInvalidOriginal(t *testing.T) {
+	// Фрагмент кода без объявления пакета не проверяется парсером
+	candidates := []*Candidate{{Code: "func main() {}\n"}}
+	Analyze("go", "func main() {\n}\n", candidates)
+
+	if !candidates[0].Valid() {
+		t.Errorf("candidate must not be rejected when original is not valid: %v", candidates[0].Err)
+	}
+}
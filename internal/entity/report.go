@@ -0,0 +1,35 @@
+package entity
+
+// FileResult представляет результат обработки файла для отчета о форматировании.
+type FileResult struct {
+	Path       string       `json:"path"`                 // Путь к файлу
+	Model      string       `json:"model,omitempty"`      // Модель ИИ, результат которой использован
+	Updates    []*Update    `json:"updates"`              // Предложенные изменения
+	Strategy   string       `json:"strategy,omitempty"`   // Стратегия выбора из нескольких вариантов
+	Reason     string       `json:"reason,omitempty"`     // Причина выбора варианта моделью-судьей
+	Candidates []*Candidate `json:"candidates,omitempty"` // Варианты, из которых выбирался результат
+	Reviews    []*Review    `json:"reviews,omitempty"`    // Раунды проверки результата моделью-рецензентом
+
+	// Verification - результат проверки командой --verify-cmd: passed, reverted, retried, baseline-failed или aborted
+	Verification string `json:"verification,omitempty"`
+}
+
+// Candidate представляет вариант результата, полученный от модели при выборе из нескольких вариантов.
+type Candidate struct {
+	Model    string `json:"model"`           // Модель ИИ
+	Valid    bool   `json:"valid"`           // Прошел ли вариант проверку синтаксиса
+	Error    string `json:"error,omitempty"` // Причина, по которой вариант отброшен
+	Group    int    `json:"group,omitempty"` // Номер группы совпадающих вариантов
+	Changed  int    `json:"changed"`         // Количество измененных строк относительно исходного кода
+	Selected bool   `json:"selected"`        // Выбран ли вариант в качестве результата
+}
+
+// Review представляет раунд проверки результата моделью-рецензентом.
+type Review struct {
+	Round    int    `json:"round"`            // Номер раунда, начиная с 1
+	Model    string `json:"model"`            // Модель-рецензент
+	Checks   string `json:"checks"`           // Результаты проверок, переданные рецензенту
+	Approved bool   `json:"approved"`         // Приняты ли изменения без исправлений
+	Issues   string `json:"issues,omitempty"` // Найденные рецензентом проблемы
+	Error    string `json:"error,omitempty"`  // Ошибка запроса к рецензенту
+}
@@ -0,0 +1,99 @@
+// Package formatter реализует детерминированные форматеры кода, которые применяются к исходному
+// коду перед запросом к ИИ и к каждому результату ИИ, чтобы записанный файл всегда был
+// в каноническом виде, а изменения содержали только существенные правки ИИ
+package formatter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// Значения описания форматера
+const (
+	Builtin = "builtin" // Встроенный форматер языка
+	Off     = "off"     // Без форматера
+)
+
+// FilePlaceholder заменяется в команде форматера путем к файлу
+const FilePlaceholder = "{file}"
+
+// Formatter - детерминированный форматер кода
+type Formatter interface {
+	// Format возвращает отформатированный код. file используется для поиска конфигурации
+	// форматера и определения языка, файл не читается и не записывается
+	Format(ctx context.Context, file, code string) (string, error)
+}
+
+// builtins - встроенные форматеры языков
+var builtins = map[string]Formatter{
+	"go": Go{},
+}
+
+// New возвращает форматер для языка по описанию spec: пустая строка или Builtin - встроенный
+// форматер языка, если он есть, Off - без форматера, иначе - команда оболочки. Если форматера
+// нет, возвращается nil
+func New(language, spec string) (Formatter, error) {
+	switch strings.TrimSpace(spec) {
+	case "":
+		return builtins[language], nil
+	case Builtin:
+		f, ok := builtins[language]
+		if !ok {
+			return nil, i18n.Errorf("no builtin formatter for %s", language)
+		}
+		return f, nil
+	case Off:
+		return nil, nil
+	}
+	return &Command{Command: spec}, nil
+}
+
+// Command - форматер, запускающий команду оболочки: код передается на стандартный ввод,
+// результат читается со стандартного вывода. FilePlaceholder в команде заменяется путем к файлу,
+// команда выполняется в каталоге файла. Например:
+//
+//	prettier --stdin-filepath {file}
+//	black -q -
+//	rustfmt --emit stdout
+type Command struct {
+	Command string
+}
+
+// Format запускает команду форматера
+func (c *Command) Format(ctx context.Context, file, code string) (string, error) {
+	line := strings.ReplaceAll(c.Command, FilePlaceholder, shellQuote(filepath.Base(file)))
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", line)
+	cmd.Dir = filepath.Dir(file)
+	cmd.Stdin = strings.NewReader(code)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", context.Cause(ctx)
+		}
+		msg := strings.TrimSpace(stderr.String())
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && msg != "" {
+			return "", i18n.Errorf("formatter %q error: %s", c.Command, msg)
+		}
+		return "", i18n.Errorf("formatter %q error: %w", c.Command, err)
+	}
+
+	if stdout.Len() == 0 && strings.TrimSpace(code) != "" {
+		return "", i18n.Errorf("formatter %q printed no code", c.Command)
+	}
+	return stdout.String(), nil
+}
+
+// shellQuote возвращает строку в одинарных кавычках для оболочки
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
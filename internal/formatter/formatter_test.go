@@ -0,0 +1,147 @@
+package formatter
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGo(t *testing.T) {
+	for _, tc := range []struct {
+		name, code, want string
+	}{
+		{
+			name: "whitespace",
+			code: "package main\nfunc main(){\n  println( 1 )\n}\n",
+			want: "package main\n\nfunc main() {\n\tprintln(1)\n}\n",
+		},
+		{
+			name: "mixed group",
+			code: `package main
+
+import (
+	"github.com/spf13/cobra"
+	"os"
+	"fmt" // вывод
+	x "example.com/x" // алиас
+)
+`,
+			want: `package main
+
+import (
+	"fmt" // вывод
+	"os"
+
+	x "example.com/x" // алиас
+	"github.com/spf13/cobra"
+)
+`,
+		},
+		{
+			name: "existing groups",
+			code: `package main
+
+import (
+	"os"
+
+	"example.com/app/internal"
+
+	"github.com/spf13/cobra"
+)
+`,
+			want: `package main
+
+import (
+	"os"
+
+	"example.com/app/internal"
+
+	"github.com/spf13/cobra"
+)
+`,
+		},
+		{
+			name: "cgo",
+			code: "package main\n\n// #include <stdio.h>\nimport \"C\"\n\nimport (\n\t\"unsafe\"\n\t\"example.com/x\"\n)\n",
+			want: "package main\n\n// #include <stdio.h>\nimport \"C\"\n\nimport (\n\t\"unsafe\"\n\n\t\"example.com/x\"\n)\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Go{}.Format(context.Background(), "main.go", tc.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := (Go{}).Format(context.Background(), "main.go", "package main\nfunc {"); err == nil {
+		t.Error("expected error for invalid code")
+	}
+}
+
+func TestCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "it's.txt")
+
+	f := &Command{Command: "tr a-z A-Z && echo " + FilePlaceholder}
+	got, err := f.Format(ctx, file, "abc\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "ABC\nit's.txt\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+
+	_, err = (&Command{Command: "echo bad input >&2; exit 1"}).Format(ctx, file, "abc")
+	if err == nil || !strings.Contains(err.Error(), "bad input") {
+		t.Errorf("expected error with stderr, got %v", err)
+	}
+
+	if _, err := (&Command{Command: "cat >/dev/null"}).Format(ctx, file, "abc"); err == nil {
+		t.Error("expected error for empty output")
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, tc := range []struct {
+		language, spec string
+		want           string
+		err            bool
+	}{
+		{"go", "", "formatter.Go", false},
+		{"go", Builtin, "formatter.Go", false},
+		{"go", Off, "<nil>", false},
+		{"python", "", "<nil>", false},
+		{"python", Builtin, "", true},
+		{"python", "black -q -", "*formatter.Command", false},
+	} {
+		f, err := New(tc.language, tc.spec)
+		if (err != nil) != tc.err {
+			t.Errorf("New(%q, %q) error = %v", tc.language, tc.spec, err)
+			continue
+		}
+		if got := typeName(f); !tc.err && got != tc.want {
+			t.Errorf("New(%q, %q) = %s, want %s", tc.language, tc.spec, got, tc.want)
+		}
+	}
+}
+
+func typeName(f Formatter) string {
+	switch f.(type) {
+	case nil:
+		return "<nil>"
+	case Go:
+		return "formatter.Go"
+	case *Command:
+		return "*formatter.Command"
+	}
+	return "unknown"
+}
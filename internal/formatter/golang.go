@@ -0,0 +1,131 @@
+package formatter
+
+import (
+	"context"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// Go - встроенный форматер Go: go/format и разделение импортов стандартной библиотеки
+// и остальных пакетов на отдельные группы, как в goimports. Существующие группы импортов,
+// разделенные пустыми строками, сохраняются, внутри групп импорты сортируются
+type Go struct{}
+
+// Format форматирует код Go
+func (Go) Format(ctx context.Context, file, code string) (string, error) {
+	src, err := format.Source([]byte(code))
+	if err != nil {
+		return "", i18n.Errorf("Go formatting error: %w", err)
+	}
+
+	grouped, changed := groupImports(src)
+	if !changed {
+		return string(src), nil
+	}
+
+	src, err = format.Source(grouped)
+	if err != nil {
+		return "", i18n.Errorf("Go formatting error: %w", err)
+	}
+	return string(src), nil
+}
+
+// importBlock - импорт в блоке import: строки с первой строки комментария до последней строки импорта
+type importBlock struct {
+	first, last int
+	std         bool
+}
+
+// groupImports разделяет группы импортов, в которых смешаны пакеты стандартной библиотеки
+// и остальные пакеты. src должен быть отформатирован go/format
+func groupImports(src []byte) ([]byte, bool) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return src, false
+	}
+
+	lines := strings.SplitAfter(string(src), "\n")
+	line := func(p token.Pos) int { return fset.Position(p).Line - 1 }
+	changed := false
+
+	// Блоки обрабатываются с конца, чтобы номера строк предыдущих блоков не менялись
+	for d := len(f.Decls) - 1; d >= 0; d-- {
+		decl, ok := f.Decls[d].(*ast.GenDecl)
+		if !ok || decl.Tok != token.IMPORT || !decl.Lparen.IsValid() {
+			continue
+		}
+
+		var blocks []importBlock
+		valid := true
+		for _, spec := range decl.Specs {
+			is := spec.(*ast.ImportSpec)
+			path, _ := strconv.Unquote(is.Path.Value)
+			if path == "C" {
+				valid = false
+				break
+			}
+
+			b := importBlock{first: line(is.Pos()), last: line(is.End()), std: !strings.Contains(strings.Split(path, "/")[0], ".")}
+			if is.Doc != nil {
+				b.first = line(is.Doc.Pos())
+			}
+			if is.Comment != nil {
+				b.last = line(is.Comment.End())
+			}
+			// Несколько импортов на одной строке не переставляются
+			if len(blocks) > 0 && b.first <= blocks[len(blocks)-1].last {
+				valid = false
+				break
+			}
+			blocks = append(blocks, b)
+		}
+		if !valid {
+			continue
+		}
+
+		// Группы - импорты на соседних строках
+		for end := len(blocks); end > 0; {
+			start := end - 1
+			for start > 0 && blocks[start-1].last+1 == blocks[start].first {
+				start--
+			}
+
+			if group := blocks[start:end]; mixed(group) {
+				var std, other []string
+				for _, b := range group {
+					text := strings.Join(lines[b.first:b.last+1], "")
+					if b.std {
+						std = append(std, text)
+					} else {
+						other = append(other, text)
+					}
+				}
+
+				replaced := append(append(std, "\n"), other...)
+				first, last := group[0].first, group[len(group)-1].last
+				lines = append(lines[:first], append(replaced, lines[last+1:]...)...)
+				changed = true
+			}
+			end = start
+		}
+	}
+
+	return []byte(strings.Join(lines, "")), changed
+}
+
+// mixed сообщает, что в группе есть и пакеты стандартной библиотеки, и остальные пакеты
+func mixed(group []importBlock) bool {
+	var std, other bool
+	for _, b := range group {
+		std = std || b.std
+		other = other || !b.std
+	}
+	return std && other
+}
@@ -0,0 +1,136 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// Repo - репозиторий git, команды выполняются в его корневом каталоге
+type Repo struct {
+	Root string // Корневой каталог рабочей копии
+}
+
+// Open находит репозиторий, содержащий каталог dir
+func Open(dir string) (*Repo, error) {
+	out, err := run(dir, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	return &Repo{Root: strings.TrimSpace(out)}, nil
+}
+
+// HooksDir возвращает каталог хуков с учетом настройки core.hooksPath
+func (r *Repo) HooksDir() (string, error) {
+	out, err := r.git(nil, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+
+	dir := strings.TrimSpace(out)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(r.Root, dir)
+	}
+	return dir, nil
+}
+
+// StagedFiles возвращает пути (относительно корня) добавленных, измененных и переименованных
+// файлов в индексе
+func (r *Repo) StagedFiles() ([]string, error) {
+	out, err := r.git(nil, "diff", "--cached", "--name-only", "--diff-filter=ACMR", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, file := range strings.Split(out, "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// StagedContent возвращает содержимое файла в индексе
+func (r *Repo) StagedContent(path string) ([]byte, error) {
+	out, err := r.git(nil, "show", ":"+filepath.ToSlash(path))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+// PartiallyStaged сообщает, есть ли в рабочей копии файла изменения, не добавленные в индекс
+func (r *Repo) PartiallyStaged(path string) (bool, error) {
+	_, err := r.git(nil, "diff", "--quiet", "--", filepath.ToSlash(path))
+	if err == nil {
+		return false, nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return true, nil
+	}
+	return false, err
+}
+
+// Stage записывает содержимое в индекс для файла, не изменяя рабочую копию
+func (r *Repo) Stage(path string, content []byte) error {
+	path = filepath.ToSlash(path)
+
+	out, err := r.git(nil, "ls-files", "--stage", "--", path)
+	if err != nil {
+		return err
+	}
+	mode, _, ok := strings.Cut(strings.TrimSpace(out), " ")
+	if !ok {
+		return i18n.Errorf("file %s is not in the index", path)
+	}
+
+	hash, err := r.git(content, "hash-object", "-w", "--stdin", "--path", path)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.git(nil, "update-index", "--cacheinfo", mode+","+strings.TrimSpace(hash)+","+path)
+	return err
+}
+
+func (r *Repo) git(stdin []byte, args ...string) (string, error) {
+	return run(r.Root, stdin, args...)
+}
+
+// gitError - ошибка выполнения команды git с ее выводом в stderr
+type gitError struct {
+	args   []string
+	err    error
+	stderr string
+}
+
+func (e *gitError) Error() string {
+	return i18n.Sprintf("error running git %s: %v: %s", strings.Join(e.args, " "), e.err, e.stderr)
+}
+
+func (e *gitError) Unwrap() error {
+	return e.err
+}
+
+func run(dir string, stdin []byte, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	c.Stdout, c.Stderr = &stdout, &stderr
+	if stdin != nil {
+		c.Stdin = bytes.NewReader(stdin)
+	}
+
+	if err := c.Run(); err != nil {
+		return "", &gitError{args: args, err: err, stderr: strings.TrimSpace(stderr.String())}
+	}
+	return stdout.String(), nil
+}
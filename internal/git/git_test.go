@@ -0,0 +1,119 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// newRepo создает репозиторий с зафиксированным файлом main.go
+func newRepo(t *testing.T) *Repo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git не установлен")
+	}
+
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "test"},
+	} {
+		if _, err := run(dir, nil, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n")
+	if _, err := run(dir, nil, "add", "main.go"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(dir, nil, "commit", "-q", "-m", "init"); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repo
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStagedFiles(t *testing.T) {
+	repo := newRepo(t)
+
+	writeFile(t, filepath.Join(repo.Root, "main.go"), "package main\n\nfunc main() {}\n")
+	writeFile(t, filepath.Join(repo.Root, "new file.go"), "package main\n")
+	writeFile(t, filepath.Join(repo.Root, "untracked.go"), "package main\n")
+	if _, err := repo.git(nil, "add", "main.go", "new file.go"); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := repo.StagedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0] != "main.go" || files[1] != "new file.go" {
+		t.Errorf("unexpected staged files: %q", files)
+	}
+}
+
+func TestPartiallyStagedAndStage(t *testing.T) {
+	repo := newRepo(t)
+	file := filepath.Join(repo.Root, "main.go")
+
+	writeFile(t, file, "package main\n\nfunc main() {}\n")
+	if _, err := repo.git(nil, "add", "main.go"); err != nil {
+		t.Fatal(err)
+	}
+
+	partial, err := repo.PartiallyStaged("main.go")
+	if err != nil || partial {
+		t.Fatalf("PartiallyStaged = %v, %v, want false", partial, err)
+	}
+
+	writeFile(t, file, "package main\n\nfunc main() {}\n\n// unstaged\n")
+	partial, err = repo.PartiallyStaged("main.go")
+	if err != nil || !partial {
+		t.Fatalf("PartiallyStaged = %v, %v, want true", partial, err)
+	}
+
+	staged, err := repo.StagedContent("main.go")
+	if err != nil || string(staged) != "package main\n\nfunc main() {}\n" {
+		t.Fatalf("unexpected staged content %q, %v", staged, err)
+	}
+
+	if err := repo.Stage("main.go", []byte("package main\n\nfunc main() {\n}\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	staged, _ = repo.StagedContent("main.go")
+	if string(staged) != "package main\n\nfunc main() {\n}\n" {
+		t.Errorf("index was not updated: %q", staged)
+	}
+
+	worktree, _ := os.ReadFile(file)
+	if string(worktree) != "package main\n\nfunc main() {}\n\n// unstaged\n" {
+		t.Errorf("working copy must not change: %q", worktree)
+	}
+}
+
+func TestHooksDir(t *testing.T) {
+	repo := newRepo(t)
+
+	dir, err := repo.HooksDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != filepath.Join(repo.Root, ".git", "hooks") {
+		t.Errorf("unexpected hooks dir %s", dir)
+	}
+}
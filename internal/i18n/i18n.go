@@ -0,0 +1,178 @@
+// Package i18n выбирает язык интерфейса и переводит сообщения командной строки и запросы к ИИ.
+// Ключами каталога служат сообщения на английском языке, который используется по умолчанию,
+// переводы регистрируются в каталоге golang.org/x/text/message
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Поддерживаемые языки
+var (
+	English = language.English
+	Russian = language.Russian
+)
+
+// Supported - поддерживаемые языки, первый используется по умолчанию
+var Supported = []language.Tag{English, Russian}
+
+var matcher = language.NewMatcher(Supported)
+
+// current - язык интерфейса
+var current atomic.Pointer[language.Tag]
+
+// Parse возвращает поддерживаемый язык для названия языка или локали, например "ru",
+// "en-US" или значения переменной LANG "ru_RU.UTF-8". Для "C", "POSIX" и неизвестных
+// языков возвращается false
+func Parse(s string) (language.Tag, bool) {
+	s, _, _ = strings.Cut(s, ".")
+	s, _, _ = strings.Cut(s, "@")
+	s = strings.ReplaceAll(strings.TrimSpace(s), "_", "-")
+	if s == "" || s == "C" || s == "POSIX" {
+		return English, false
+	}
+
+	tag, err := language.Parse(s)
+	if err != nil {
+		return English, false
+	}
+	_, i, conf := matcher.Match(tag)
+	if conf == language.No {
+		return English, false
+	}
+	return Supported[i], true
+}
+
+// Detect определяет язык интерфейса по флагу --ui-lang в аргументах командной строки,
+// затем по переменным окружения LC_ALL, LC_MESSAGES и LANG. По умолчанию используется английский
+func Detect(args []string, getenv func(string) string) language.Tag {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		value, ok := strings.CutPrefix(arg, "--ui-lang=")
+		if !ok && arg == "--ui-lang" && i+1 < len(args) {
+			value, ok = args[i+1], true
+		}
+		if ok {
+			if tag, ok := Parse(value); ok {
+				return tag
+			}
+			return English
+		}
+	}
+
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := getenv(name); value != "" {
+			tag, _ := Parse(value)
+			return tag
+		}
+	}
+	return English
+}
+
+// Set устанавливает язык интерфейса
+func Set(tag language.Tag) {
+	current.Store(&tag)
+}
+
+// Lang возвращает язык интерфейса
+func Lang() language.Tag {
+	if tag := current.Load(); tag != nil {
+		return *tag
+	}
+	return English
+}
+
+// Printer возвращает принтер сообщений на языке tag. Неопределенный язык соответствует английскому
+func Printer(tag language.Tag) *message.Printer {
+	return message.NewPrinter(tag)
+}
+
+// Sprintf переводит сообщение на язык интерфейса и форматирует его
+func Sprintf(format string, args ...interface{}) string {
+	return Printer(Lang()).Sprintf(format, args...)
+}
+
+// Printf выводит переведенное сообщение в стандартный вывод
+func Printf(format string, args ...interface{}) {
+	Printer(Lang()).Printf(format, args...)
+}
+
+// Fprintf выводит переведенное сообщение в w
+func Fprintf(w io.Writer, format string, args ...interface{}) {
+	Printer(Lang()).Fprintf(w, format, args...)
+}
+
+// Fprintln выводит переведенное сообщение в w с переводом строки
+func Fprintln(w io.Writer, format string, args ...interface{}) {
+	fmt.Fprintln(w, Sprintf(format, args...))
+}
+
+// Errorf возвращает ошибку с переведенным сообщением. Как и в fmt.Errorf, ошибки для глагола %w
+// обертываются и доступны для errors.Is и errors.As
+func Errorf(format string, args ...interface{}) error {
+	wrapped := wrappedArgs(format, args)
+	msg := Sprintf(strings.ReplaceAll(format, "%w", "%v"), args...)
+	if len(wrapped) == 0 {
+		return errors.New(msg)
+	}
+	return &wrapError{msg: msg, errs: wrapped}
+}
+
+// Error возвращает ошибку, сообщение которой переводится на язык интерфейса при каждом
+// обращении. Используется для ошибок, объявленных переменными пакета
+func Error(msg string) error {
+	return localized(msg)
+}
+
+type localized string
+
+func (e localized) Error() string {
+	return Sprintf(string(e))
+}
+
+// wrapError - ошибка Errorf, обертывающая ошибки для глаголов %w
+type wrapError struct {
+	msg  string
+	errs []error
+}
+
+func (e *wrapError) Error() string {
+	return e.msg
+}
+
+func (e *wrapError) Unwrap() []error {
+	return e.errs
+}
+
+// wrappedArgs возвращает ошибки из args, соответствующие глаголам %w в format
+func wrappedArgs(format string, args []interface{}) []error {
+	var errs []error
+	n := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		// Пропуск флагов, ширины и точности до буквы глагола
+		for i++; i < len(format) && strings.IndexByte("+-# 0123456789.", format[i]) >= 0; i++ {
+		}
+		if i >= len(format) || format[i] == '%' {
+			continue
+		}
+		if format[i] == 'w' && n < len(args) {
+			if err, ok := args[n].(error); ok {
+				errs = append(errs, err)
+			}
+		}
+		n++
+	}
+	return errs
+}
@@ -0,0 +1,95 @@
+package i18n
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value string
+		want  language.Tag
+		ok    bool
+	}{
+		{"ru", Russian, true},
+		{"ru_RU.UTF-8", Russian, true},
+		{"en_US.UTF-8", English, true},
+		{"en-GB", English, true},
+		{"C.UTF-8", English, false},
+		{"POSIX", English, false},
+		{"de_DE", English, false},
+		{"", English, false},
+	}
+	for _, tt := range tests {
+		if got, ok := Parse(tt.value); got != tt.want || ok != tt.ok {
+			t.Errorf("Parse(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	env := map[string]string{"LANG": "ru_RU.UTF-8"}
+	getenv := func(name string) string { return env[name] }
+
+	tests := []struct {
+		args []string
+		want language.Tag
+	}{
+		{nil, Russian},
+		{[]string{"fmt", "--ui-lang", "en", "main.go"}, English},
+		{[]string{"fmt", "--ui-lang=en"}, English},
+		{[]string{"fmt", "--", "--ui-lang=en"}, Russian},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.args, getenv); got != tt.want {
+			t.Errorf("Detect(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+
+	env = map[string]string{"LC_ALL": "C", "LANG": "ru_RU.UTF-8"}
+	if got := Detect(nil, getenv); got != English {
+		t.Errorf("LC_ALL must take precedence over LANG, got %v", got)
+	}
+}
+
+// TestRussian проверяет, что переводы используют те же аргументы, что и исходные сообщения
+func TestRussian(t *testing.T) {
+	verb := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+	for key, msg := range russian {
+		if got, want := verb.FindAllString(msg, -1), verb.FindAllString(key, -1); !reflect.DeepEqual(got, want) {
+			t.Errorf("translation of %q uses %q, want %q", key, got, want)
+		}
+	}
+
+	if got := Printer(Russian).Sprintf("Error reading standard input: %v", "EOF"); got != "Ошибка чтения стандартного ввода: EOF" {
+		t.Errorf("unexpected translation %q", got)
+	}
+	if got := Printer(language.Und).Sprintf("Error reading standard input: %v", "EOF"); got != "Error reading standard input: EOF" {
+		t.Errorf("unexpected default message %q", got)
+	}
+}
+
+func TestErrorf(t *testing.T) {
+	defer Set(Lang())
+	Set(Russian)
+
+	cause := errors.New("EOF")
+	err := Errorf("Failed to format file %s: %w", "main.go", cause)
+	if !errors.Is(err, cause) {
+		t.Errorf("%v does not wrap %v", err, cause)
+	}
+	if got, want := err.Error(), "Не удалось отформатировать файл main.go: EOF"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Сообщение Error переводится при каждом выводе
+	err = Error("result rejected by a check")
+	Set(English)
+	if got, want := err.Error(), "result rejected by a check"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,804 @@
+package i18n
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// russian - переводы сообщений на русский язык. Ключ - сообщение на английском языке
+var russian = map[string]string{
+	// Командная строка
+	`AIFMT is a command line tool that uses AI to format and improve your code.\nIt supports many programming languages and AI models.`: `AIFMT - это инструмент командной строки, использующий ИИ для форматирования и улучшения вашего кода.\nПоддерживает множество языков программирования и моделей ИИ.`,
+	"A tool for formatting code with AI":                                          "Инструмент для форматирования кода с помощью ИИ",
+	"Connection profile (AIFMT_PROFILE or the configuration by default)":          "Профиль подключения (по умолчанию из AIFMT_PROFILE или конфигурации)",
+	"Error executing the command: %v\n":                                           "Ошибка выполнения команды: %v\n",
+	"Interface language: en or ru (LC_ALL, LC_MESSAGES or LANG by default)":       "Язык интерфейса: en или ru (по умолчанию из LC_ALL, LC_MESSAGES или LANG)",
+	"Verbose output: -v for debug messages, -vv for every API request":            "Подробный вывод: -v - отладочные сообщения, -vv - каждый запрос к API",
+	"Print only warnings and errors":                                              "Выводить только предупреждения и ошибки",
+	"Log format: text or json":                                                    "Формат сообщений: text или json",
+	"Directory to save every API request and response to, with API keys redacted": "Каталог для сохранения каждого запроса к API и ответа на него, ключи API скрываются",
+	`Set or update a value in the configuration file.
+Equivalent to 'aifmt config set'.
+Example: aifmt set max_retry 3`: `Установка или обновление значения в конфигурационном файле.
+Равнозначно команде 'aifmt config set'.
+Пример: aifmt set max_retry 3`,
+	"Set a configuration value":                                "Установка значения в конфигурации",
+	"Error getting the home directory: %v\n":                   "Ошибка получения домашней директории: %v\n",
+	"Error creating the configuration directory: %v\n":         "Ошибка создания конфигурационной директории: %v\n",
+	"Error writing the configuration: %v\n":                    "Ошибка записи конфигурации: %v\n",
+	"Error reading the configuration: %v\n":                    "Ошибка чтения конфигурации: %v\n",
+	"Configuration file not found, creating a new one in %s\n": "Конфигурационный файл не найден, создается новый в %s\n",
+	`Format one or more source files using AI.
+You can choose the programming language and the AI model to use.
+If the token is not configured, you will be asked to set it.
+
+If "-" is given instead of a file or the --stdin flag is set, the code is read
+from standard input and the result is written to standard output. All messages
+in this mode go to stderr, so aifmt can be used as a filter in editors and
+pipelines.
+
+For each file the project configuration .aifmt.yaml is looked up in the file's
+directory and above. It can set the language, model, mode, additional
+instructions, comments and exclusions, including per-path settings for
+matching patterns. Explicit flags take precedence.
+
+Files are processed in parallel, the number of files processed at once is set
+by the channels configuration key. On interrupt (Ctrl-C) or when --timeout
+expires, AI requests are cancelled, no new files are started, and a list of
+processed, skipped and interrupted files is printed at the end. A second
+Ctrl-C exits immediately.`: `Форматирование одного или нескольких файлов с кодом с использованием ИИ.
+Вы можете указать язык программирования и модель ИИ для использования.
+Если токен не настроен, вам будет предложено его установить.
+
+Если вместо файла указан "-" или флаг --stdin, код читается из стандартного
+ввода, а результат выводится в стандартный вывод. Все сообщения в этом
+режиме выводятся в stderr, что позволяет использовать aifmt как фильтр
+в редакторах и конвейерах.
+
+Для каждого файла ищется конфигурация проекта .aifmt.yaml в каталоге файла
+и выше по дереву каталогов. Она может задавать язык, модель, режим работы,
+дополнительные инструкции, комментарии и исключения, в том числе отдельно
+для путей, подходящих под шаблоны. Явно указанные флаги имеют приоритет.
+
+Файлы обрабатываются параллельно, количество одновременно обрабатываемых
+файлов задается ключом конфигурации channels. По сигналу прерывания (Ctrl-C)
+или по истечении --timeout запросы к ИИ отменяются, новые файлы не начинают
+обрабатываться, а в конце выводится список обработанных, пропущенных и
+прерванных файлов. Повторный Ctrl-C завершает программу немедленно.`,
+	`  # Format a Go file
+  aifmt fmt -l go main.go
+
+  # Format several Python files with a specific model
+  aifmt fmt -l python --model claude-2 *.py
+
+  # Format with language auto-detection
+  aifmt fmt script.js
+
+  # Format taking other files into account
+  aifmt fmt -w -l go *.go
+
+  # Only print the proposed changes without writing files
+  aifmt fmt --mode review *.go
+
+  # Format only lines 40 to 85 or a single function
+  aifmt fmt -l go --lines 40:85 main.go
+  aifmt fmt -l go --func Server.Run server.go
+
+  # Pick the best of three candidates by agreement or with a judge model
+  aifmt fmt --samples 3 main.go
+  aifmt fmt --models deepseek/deepseek-chat:free,qwen/qwen-2.5-coder-32b-instruct:free --pick judge main.go
+
+  # Review the result with another model (up to two rounds of fixes)
+  aifmt fmt --refine 2 --reviewer-model qwen/qwen-2.5-coder-32b-instruct:free main.go
+
+  # Check the build and tests after formatting and revert changes that break them
+  aifmt fmt --verify-cmd "go build ./... && go test ./..." --verify-retries 1 *.go
+
+  # Time limits: at most 2 minutes per file and 10 minutes for the whole command
+  aifmt fmt --file-timeout 2m --timeout 10m *.go
+
+  # Format files staged in the git index (used by the pre-commit hook)
+  aifmt fmt --hook
+  aifmt fmt --hook --check
+
+  # Format standard input (e.g. from Vim: :%%!aifmt fmt -l go -)
+  cat main.go | aifmt fmt -l go -
+  aifmt fmt --stdin --stdin-filename main.go < main.go`: `  # Форматирование Go файла
+  aifmt fmt -l go main.go
+
+  # Форматирование нескольких Python файлов с указанной моделью
+  aifmt fmt -l python --model claude-2 *.py
+
+  # Форматирование с автоопределением языка
+  aifmt fmt script.js
+
+  # Форматирование с учетом контекста других файлов
+  aifmt fmt -w -l go *.go
+
+  # Только вывод предложенных изменений без записи в файлы
+  aifmt fmt --mode review *.go
+
+  # Форматирование только строк с 40 по 85 или одной функции
+  aifmt fmt -l go --lines 40:85 main.go
+  aifmt fmt -l go --func Server.Run server.go
+
+  # Выбор лучшего из трех вариантов по совпадению или моделью-судьей
+  aifmt fmt --samples 3 main.go
+  aifmt fmt --models deepseek/deepseek-chat:free,qwen/qwen-2.5-coder-32b-instruct:free --pick judge main.go
+
+  # Проверка результата другой моделью (до двух раундов исправлений)
+  aifmt fmt --refine 2 --reviewer-model qwen/qwen-2.5-coder-32b-instruct:free main.go
+
+  # Проверка сборки и тестов после форматирования с отменой изменений, которые их нарушают
+  aifmt fmt --verify-cmd "go build ./... && go test ./..." --verify-retries 1 *.go
+
+  # Ограничение времени: не более 2 минут на файл и 10 минут на всю команду
+  aifmt fmt --file-timeout 2m --timeout 10m *.go
+
+  # Форматирование файлов, добавленных в индекс git (используется хуком pre-commit)
+  aifmt fmt --hook
+  aifmt fmt --hook --check
+
+  # Форматирование стандартного ввода (например, из Vim: :%%!aifmt fmt -l go -)
+  cat main.go | aifmt fmt -l go -
+  aifmt fmt --stdin --stdin-filename main.go < main.go`,
+	"fmt [flags] [files...]":                            "fmt [флаги] [файлы...]",
+	"Format code using AI":                              "Форматирование кода с помощью ИИ",
+	"Error: invalid mode %q, allowed values: %s, %s\n":  "Ошибка: некорректный режим %q, допустимые значения: %s, %s\n",
+	"Error: --samples must be at least 1":               "Ошибка: значение --samples должно быть не меньше 1",
+	"Error: --refine must not be negative":              "Ошибка: значение --refine не может быть отрицательным",
+	"Error: invalid strategy %q, allowed values: %s\n":  "Ошибка: некорректная стратегия %q, допустимые значения: %s\n",
+	"Error: --lines and --func cannot be used together": "Ошибка: флаги --lines и --func нельзя использовать одновременно",
+	"The comments language is not configured. Please run 'aifmt set comments_language <language>' first.": "Язык комментариев не настроен. Пожалуйста, сначала выполните 'aifmt set comments_language язык'.",
+	"Error: --check can only be used with --hook":                                                         "Ошибка: флаг --check используется только вместе с --hook",
+	"Error: no files to process":                                                                          "Ошибка: не указаны файлы для обработки",
+	"Loaded %d files for context\n":                                                                       "Загружено %d файлов для контекста\n",
+	"Error: no AI model specified for %s":                                                                 "Ошибка: не указана модель ИИ для %s",
+	"Error: no programming language specified for %s":                                                     "Ошибка: не указан язык программирования для %s",
+	"Error: --func is only supported for go (%s)":                                                         "Ошибка: флаг --func поддерживается только для языка go (%s)",
+	"Error reading style rules for %s: %v\n":                                                              "Ошибка чтения правил стиля для %s: %v\n",
+	"Error parsing pattern %s: %v\n":                                                                      "Ошибка при разборе шаблона %s: %v\n",
+	"Error reading standard input: %v":                                                                    "Ошибка чтения стандартного ввода: %v",
+	"File %s skipped by the project configuration\n":                                                      "Файл %s пропущен согласно конфигурации проекта\n",
+	"Processing %s (Language: %s, Model: %s, Context: %v)...\n":                                           "Обработка %s (Язык: %s, Модель: %s, Контекст: %v)...\n",
+	"Processing of %s interrupted: %v":                                                                    "Обработка %s прервана: %v",
+	"Error writing to standard output: %v":                                                                "Ошибка записи в стандартный вывод: %v",
+	"Error reading context file %s: %v\n":                                                                 "Ошибка чтения контекстного файла %s: %v\n",
+	"Error encoding changes as JSON: %s\n":                                                                "Ошибка при приведении изменений в строку JSON: %s\n",
+	"Error writing to %s: %v\n":                                                                           "Ошибка записи в %s: %v\n",
+	"Formatting report written to %s\n":                                                                   "Отчет о форматировании записан в %s\n",
+	"Programming language of the files":                                                                   "Язык программирования файлов",
+	"AI model for formatting. Several comma-separated models can be given: the next one is used on failure": "Модель ИИ для форматирования. Можно указать несколько моделей через запятую: при ошибке используется следующая",
+	"Mode: format - format and write the file, review - only print the proposed changes":                    "Режим работы: format - форматирование с записью в файл, review - только вывод предложенных изменений",
+	"Use other files as context when formatting":                                                            "Использовать контекст других файлов при форматировании",
+	"Add comments to the code. The comments language is set in the configuration":                           "Добавить в код комментарии. Язык комментариев настраивается в конфигурации",
+	"Write the formatting results to a file":                                                                "Запись результатов форматирования в файл",
+	"Do not retry when processing a file fails":                                                             "Не повторять попытки при ошибках обработки файлов",
+	"Format only the given line range as start:end, e.g. 40:85":                                             "Форматировать только указанный диапазон строк в формате начало:конец, например 40:85",
+	"Format only the given Go function (Type.Method for methods)":                                           "Форматировать только указанную функцию Go (для методов - Тип.Метод)",
+	"Read code from standard input and write the result to standard output":                                 "Читать код из стандартного ввода и выводить результат в стандартный вывод",
+	"Number of candidates requested from each model to pick the best one":                                   "Количество вариантов, запрашиваемых у каждой модели, для выбора лучшего",
+	"Comma-separated models to request candidates from":                                                     "Модели через запятую, у которых запрашиваются варианты для выбора лучшего",
+	"Judge model for the judge strategy, the main model by default":                                         "Модель-судья для стратегии judge, по умолчанию основная модель",
+	"Number of review rounds by a reviewer model before writing":                                            "Количество раундов проверки результата моделью-рецензентом перед записью",
+	"Reviewer model for --refine, the model that produced the result by default":                            "Модель-рецензент для --refine, по умолчанию модель, давшая результат",
+	"Command that checks the project after files are written, e.g. \"go build ./... && go test ./...\"":     "Команда проверки проекта после записи файлов, например \"go build ./... && go test ./...\"",
+	"Number of retries for files that broke the check, with the check output added to the prompt":           "Количество повторных попыток форматирования файлов, нарушивших проверку, с выводом ошибок в запросе",
+	"Time limit for the whole command, e.g. 10m (0 - no limit)":                                             "Общее ограничение времени работы команды, например 10m (0 - без ограничения)",
+	"Time limit for processing one file, e.g. 2m (0 - no limit)":                                            "Ограничение времени обработки одного файла, например 2m (0 - без ограничения)",
+	"Format files staged in the git index and stage the result (pre-commit hook mode)":                      "Форматировать файлы, добавленные в индекс git, и добавить результат в индекс (режим хука pre-commit)",
+	"With --hook: only check formatting and fail if files need changes":                                     "Вместе с --hook: только проверить форматирование и завершиться с ошибкой, если файлы требуют изменений",
+	"File name for code read from standard input (used to detect the language and in the report)":           "Имя файла для кода из стандартного ввода (используется для определения языка и в отчете)",
+	"Candidate selection strategy: %s":                                                                      "Стратегия выбора варианта: %s",
+
+	// Запросы к ИИ
+	"Fix this code: ```%s\n%s\n```. Fix the errors and optimize it. Your answer must contain only a json object, without any text before or after it, in the following format: {code:(new code), updates:(array of changes)[{code:(the part of the code you decided to change), description:(reason for the change)}]}!.": "Исправь этот код: ```%s\n%s\n```. Устрани ошибки, проведи оптимизацию. В твоем ответе обязательно должен быть только json объект, без текста до или после в следующем формате: {code:(новый код), updates:(массив изменений)[{code:(часть кода, которую ты решил изменить), description:(причина изменения)}]}!.",
+	"Also comment the code. The comments language must be: %s":                                                                                   "Так же закоментируй код. Язык должен быть: %s",
+	"Do not add new comments to the code, keep the existing ones":                                                                                "Не добавляй в код новых комментариев, оставь уже имеющиеся",
+	"Additional requirements: %s":                                                                                                                "Дополнительные требования: %s",
+	"Also take into account other files of the same project. I will send them as separate messages: ":                                            "Так же учти и другие файлы этого же проекта. Я пришлю тебе список в виде отдельных сообщений: ",
+	"Change only lines %s to %s (numbered from 1), return all other code in the code field unchanged. Lines that may be changed: ```%s\n%s\n```": "Изменяй только строки с %s по %s (нумерация с 1), весь остальной код верни в поле code без изменений. Строки, которые можно изменять: ```%s\n%s\n```",
+	"Review the proposed fix of %s code. Original code: ```%s\n%s\n```. Proposed code: ```%s\n%s\n```. Results of the checks of the proposed code: %s. Make sure the proposed code is correct, preserves the behavior of the original code, does not remove existing comments and has no regressions. Your answer must contain only a json object, without any text before or after it, in the following format: {approved:(true if the changes can be accepted without fixes), issues:(found problems), code:(the whole fixed code if approved is false), updates:(array of fixes)[{code:(the part of the code you fixed), description:(reason for the fix)}]}!.": "Проверь предложенное исправление кода на языке %s. Исходный код: ```%s\n%s\n```. Предложенный код: ```%s\n%s\n```. Результаты проверок предложенного кода: %s. Убедись, что предложенный код корректен, сохраняет поведение исходного кода, не удаляет имеющиеся комментарии и не содержит регрессий. В твоем ответе обязательно должен быть только json объект, без текста до или после в следующем формате: {approved:(true, если изменения можно принять без исправлений), issues:(найденные проблемы), code:(исправленный код целиком, если approved равно false), updates:(массив исправлений)[{code:(часть кода, которую ты исправил), description:(причина исправления)}]}!.",
+	"Additional code requirements: %s": "Дополнительные требования к коду: %s",
+	"Below is the original %s code and %d candidate fixes, each in a separate message. Choose the best candidate: correct, preserving the behavior of the program, without unnecessary changes. Your answer must contain only a json object, without any text before or after it, in the following format: {choice:(candidate number, starting from 1), reason:(reason for the choice)}!. Original code: ```%s\n%s\n```": "Ниже исходный код на языке %s и %d вариантов его исправления, каждый в отдельном сообщении. Выбери лучший вариант: корректный, сохраняющий поведение программы, без лишних изменений. В твоем ответе обязательно должен быть только json объект, без текста до или после в следующем формате: {choice:(номер варианта, начиная с 1), reason:(причина выбора)}!. Исходный код: ```%s\n%s\n```",
+	"Candidate %d:\n```%s\n%s\n```":                        "Вариант %d:\n```%s\n%s\n```",
+	"Use the documentation comment format accepted for %s": "Используй принятый для языка %s формат документирующих комментариев",
+	"public symbols": "публичные символы",
+	"the module, public classes, functions and methods (names without a leading underscore)": "модуль, публичные классы, функции и методы (имена без подчеркивания в начале)",
+	"Document only %s, leave other symbols untouched":                                        "Документируй только %s, остальные символы не трогай",
+	"Document all symbols, including unexported and private ones":                            "Документируй все символы, включая неэкспортируемые и приватные",
+	"Add or update documentation comments in this code: ```%s\n%s\n```. %s. %s. Do not change the code itself: only comments may change, the code formatting must stay the same. Keep existing comments, update them only if they do not match the code or the convention. Comments language: %s. Your answer must contain only a json object, without any text before or after it, in the following format: {code:(new code), updates:(array of changes)[{code:(the part of the code you decided to change), description:(reason for the change)}]}!": "Добавь или обнови документирующие комментарии в этом коде: ```%s\n%s\n```. %s. %s. Не изменяй сам код: меняться могут только комментарии, форматирование кода должно остаться прежним. Существующие комментарии сохрани, обновляй их только если они не соответствуют коду или соглашению. Язык комментариев: %s. В твоем ответе обязательно должен быть только json объект, без текста до или после в следующем формате: {code:(новый код), updates:(массив изменений)[{code:(часть кода, которую ты решил изменить), description:(причина изменения)}]}!",
+	"Use GoDoc conventions: // line comments directly before the declaration, full sentences, the first sentence starts with the name of the identifier (for example, \"// Format formats ...\"), the package comment starts with \"Package name\"":                                                                                                                                                                                                                                                                                                    "Используй соглашения GoDoc: комментарий строками // непосредственно перед объявлением, полные предложения, первое предложение начинается с имени идентификатора (например, \"// Format форматирует ...\"), комментарий пакета начинается со слов \"Package имя\"",
+	"exported Go symbols (capitalized names) and the package":                                                "экспортируемые символы Go (имена с заглавной буквы) и пакет",
+	"Use JSDoc: /** */ blocks before the declaration with @param {type} name, @returns {type}, @throws tags": "Используй JSDoc: блоки /** */ перед объявлением с тегами @param {тип} имя, @returns {тип}, @throws",
+	"exported functions, classes, methods and constants":                                                     "экспортируемые функции, классы, методы и константы",
+	"Use TSDoc: /** */ blocks before the declaration with @param name - description, @returns, @throws tags, without types in tags since they are declared in the code": "Используй TSDoc: блоки /** */ перед объявлением с тегами @param имя - описание, @returns, @throws, без типов в тегах, так как они указаны в коде",
+	"exported functions, classes, interfaces, types and their public members":                                                                                           "экспортируемые функции, классы, интерфейсы, типы и их публичные члены",
+	"Use Javadoc: /** */ blocks before the declaration, the first sentence is a short summary, @param, @return, @throws tags":                                           "Используй Javadoc: блоки /** */ перед объявлением, первое предложение - краткое описание, теги @param, @return, @throws",
+	"public and protected classes, methods and fields":                                                                                                                  "публичные и защищенные классы, методы и поля",
+	"Use KDoc: /** */ blocks before the declaration with @param, @return, @throws tags":                                                                                 "Используй KDoc: блоки /** */ перед объявлением с тегами @param, @return, @throws",
+	"public classes, functions and properties":                                                                                                                          "публичные классы, функции и свойства",
+	"Use rustdoc: /// comments before the item and //! for the module, # Errors, # Panics and # Examples sections where applicable":                                     "Используй rustdoc: комментарии /// перед элементом и //! для модуля, разделы # Errors, # Panics и # Examples, если они применимы",
+	"items marked pub": "элементы с модификатором pub",
+	"Use /// XML comments with <summary>, <param>, <returns>, <exception> tags":                        "Используй XML-комментарии /// с тегами <summary>, <param>, <returns>, <exception>",
+	"public and protected types and members":                                                           "публичные и защищенные типы и члены",
+	"Use Doxygen: /** */ blocks before the declaration with @brief, @param, @return tags":              "Используй Doxygen: блоки /** */ перед объявлением с тегами @brief, @param, @return",
+	"functions and types not declared static":                                                          "функции и типы, не объявленные как static",
+	"Use Doxygen: /** */ blocks before the declaration with @brief, @param, @return, @throws tags":     "Используй Doxygen: блоки /** */ перед объявлением с тегами @brief, @param, @return, @throws",
+	"public classes, functions and methods":                                                            "публичные классы, функции и методы",
+	"Use PHPDoc: /** */ blocks before the declaration with @param type $name, @return, @throws tags":   "Используй PHPDoc: блоки /** */ перед объявлением с тегами @param тип $имя, @return, @throws",
+	"Use YARD: # comments before the declaration with @param [Type] name, @return [Type], @raise tags": "Используй YARD: комментарии # перед объявлением с тегами @param [Тип] имя, @return [Тип], @raise",
+	"public classes, modules and methods":                                                              "публичные классы, модули и методы",
+	"Use Google style docstrings: a triple double quoted string as the first statement of the module, class or function, a short summary on the first line, Args:, Returns:, Raises: sections":                          "Используй docstring в стиле Google: строка в тройных двойных кавычках первой инструкцией модуля, класса или функции, краткое описание в первой строке, разделы Args:, Returns:, Raises:",
+	"Use NumPy style docstrings: a triple double quoted string as the first statement of the module, class or function, a short summary on the first line, Parameters, Returns, Raises sections underlined with dashes": "Используй docstring в стиле NumPy: строка в тройных двойных кавычках первой инструкцией модуля, класса или функции, краткое описание в первой строке, разделы Parameters, Returns, Raises с подчеркиванием из дефисов",
+	"the original code does not pass the syntax check, so the check was not performed":                                                                                                                                  "исходный код не проходит проверку синтаксиса, поэтому проверка не выполнялась",
+	"the syntax is correct":                "синтаксис корректен",
+	"syntax check is not available for %s": "проверка синтаксиса для языка %s недоступна",
+	"syntax error: %v":                     "синтаксическая ошибка: %v",
+	"The previous formatting of this file broke the build or tests of the project. Output of the check command: ```\n%s\n```. Fix the code so that the check passes.": "Предыдущий вариант форматирования этого файла нарушил сборку или тесты проекта. Вывод команды проверки: ```\n%s\n```. Исправь код так, чтобы проверка проходила.",
+
+	// Сообщения о ходе обработки
+	"requesting candidates": "запрос вариантов",
+	"candidate discarded":   "вариант отброшен",
+	"candidate":             "вариант",
+	"candidate chosen":      "выбран вариант",
+	"judge model error, falling back to agreement":               "ошибка модели-судьи, используется выбор по совпадению",
+	"retrying formatting":                                        "повторная попытка форматирования",
+	"file formatted by a fallback model":                         "файл отформатирован резервной моделью",
+	"switching to a fallback model":                              "переход к резервной модели",
+	"formatting error":                                           "ошибка форматирования",
+	"proposed change":                                            "предложенное изменение",
+	"reviewing the result":                                       "проверка результата рецензентом",
+	"reviewer model error":                                       "ошибка модели-рецензента",
+	"reviewer approved the result":                               "рецензент принял результат",
+	"reviewer fix rejected":                                      "исправление рецензента отклонено",
+	"reviewer fixed the result":                                  "рецензент исправил результат",
+	"reviewer fix failed the checks, using the previous version": "исправление рецензента не прошло проверки, используется предыдущая версия",
+	"file skipped by the project configuration":                  "файл пропущен согласно конфигурации проекта",
+	"processing file":                                            "обработка файла",
+	"error reading file":                                         "ошибка чтения файла",
+	"retrying file read":                                         "повторное чтение файла",
+	"failed to read file":                                        "не удалось прочитать файл",
+	"failed to process file":                                     "не удалось обработать файл",
+	"file reviewed, changes not written":                         "файл проверен, изменения не записаны",
+	"error writing file":                                         "ошибка записи файла",
+	"retrying file write":                                        "повторная запись файла",
+	"failed to write file":                                       "не удалось записать файл",
+	"file updated":                                               "файл обновлен",
+	"operation failed":                                           "ошибка операции",
+	"verifying changes":                                          "проверка изменений",
+	"verification aborted":                                       "проверка прервана",
+	"verification passed":                                        "проверка пройдена",
+	"verification failed":                                        "проверка не пройдена",
+	"verification fails without aifmt changes too, changes kept": "проверка не проходит и без изменений aifmt, изменения сохранены",
+	"changes break verification and were reverted":               "изменения нарушают проверку и отменены",
+	"reformatting with the verification errors":                  "повторное форматирование с учетом ошибок проверки",
+	"failed to reformat file":                                    "не удалось отформатировать файл повторно",
+	"file reformatted and passed verification":                   "файл отформатирован повторно и прошел проверку",
+
+	// Ошибки обработки файлов
+	"Failed to format file %s: no valid candidates":  "Не удалось отформатировать файл %s: нет корректных вариантов",
+	"empty AI response":                              "ответ ИИ пуст",
+	"result rejected by a check":                     "результат отклонен проверкой",
+	"Error selecting the line range in %s: %v":       "Ошибка выбора диапазона строк в %s: %v",
+	"Formatter error for %s: %v":                     "Ошибка форматера для %s: %v",
+	"the code was changed, not only the comments":    "изменен код, а не только комментарии",
+	"Failed to format file %s: %w":                   "Не удалось отформатировать файл %s: %w",
+	"File %s skipped: %w":                            "Файл %s пропущен: %w",
+	"Failed to format file %s after %d attempts: %w": "Не удалось отформатировать файл %s после %d попыток: %w",
+	"invalid line range %q, expected start:end":      "некорректный диапазон строк %q, ожидается формат начало:конец",
+	"invalid line range %q: lines are numbered from 1 and the end cannot be before the start": "некорректный диапазон строк %q: строки нумеруются с 1, конец не может быть меньше начала",
+	"The result for %s did not pass the checks":                                               "Результат для %s не прошел проверки",
+	"file processing time exceeded (--file-timeout)":                                          "превышено время обработки файла (--file-timeout)",
+	"Total: %d processed, %d skipped, %d failed, %d aborted\n":                                "Итого: обработано %d, пропущено %d, с ошибкой %d, прервано %d\n",
+	"Skipped": "Пропущены",
+	"Failed":  "С ошибкой",
+	"Aborted": "Прерваны",
+	"maximum number of attempts reached (%d): %v":     "достигнуто максимальное количество попыток (%d): %v",
+	"changes reverted: the --verify-cmd check failed": "изменения отменены: проверка --verify-cmd не пройдена",
+	"Error writing %s: %v":                            "Ошибка записи в %s: %v",
+
+	// Журнал
+	"API request": "запрос к API",
+	"error writing the request to the debug directory": "ошибка записи запроса в каталог отладки",
+
+	// Ошибки сервиса
+	"the judge model picked a nonexistent candidate":                             "модель-судья выбрала несуществующий вариант",
+	"empty response from the reviewer model":                                     "пустой ответ модели-рецензента",
+	"the reviewer model rejected the changes but returned no corrected code: %s": "модель-рецензент отклонила изменения, но не вернула исправленный код: %s",
+	"invalid line range %d:%d, the file has %d lines":                            "некорректный диапазон строк %d:%d, в файле %d строк",
+	"the AI changed code outside lines %d:%d":                                    "ИИ изменил код за пределами строк %d:%d",
+	"the AI changed line %d outside the range %d:%d":                             "ИИ изменил строку %d за пределами диапазона %d:%d",
+	"error parsing Go code: %w":                                                  "ошибка разбора Go кода: %w",
+	"function %s not found":                                                      "функция %s не найдена",
+	"invalid log format %q, allowed values: %s":                                  "некорректный формат сообщений %q, допустимые значения: %s",
+
+	// Команда style
+	"style [flags] files...":                  "style [флаги] файлы...",
+	"Print the project style rules for files": "Вывод правил стиля проекта для файлов",
+	"Print the style rules that aifmt finds in the configuration of the project's\nformatters and linters and adds to the AI prompt: .editorconfig, .prettierrc,\n.golangci.yml, the black and ruff sections of pyproject.toml, .clang-format.\n\nThe search goes from the file's directory up to the root of the git repository.\nAdding the rules to the prompt is disabled by the style configuration key or\nthe style setting in .aifmt.yaml.": "Вывод правил стиля, которые aifmt находит в конфигурации средств форматирования\nи линтеров проекта и добавляет в запрос к ИИ: .editorconfig, .prettierrc,\n.golangci.yml, разделы black и ruff файла pyproject.toml, .clang-format.\n\nПоиск выполняется от каталога файла до корня репозитория git. Добавление\nправил в запрос отключается ключом конфигурации style или параметром style\nв .aifmt.yaml.",
+	"Programming language of the files, detected from the extension by default": "Язык программирования файлов, по умолчанию определяется по расширению",
+
+	// Команда style
+	"  no style rules found\n": "  правила стиля не найдены\n",
+
+	// Прерывание обработки
+	"interrupted by a signal":               "прервано сигналом",
+	"total time limit exceeded (--timeout)": "превышено общее время выполнения (--timeout)",
+	"Received signal %v, finishing processing. A second signal exits immediately\n": "Получен сигнал %v, завершение обработки. Повторный сигнал завершит программу немедленно\n",
+
+	// Команда lsp
+	"Start a Language Server Protocol server": "Запуск сервера Language Server Protocol",
+	"Start a Language Server Protocol server over standard input and output.\nThe server supports formatting a document or a selected range, code actions\nbased on the changes proposed by AI, and diagnostics published when a file\nis opened or saved.": "Запуск сервера Language Server Protocol через стандартные ввод и вывод.\nСервер поддерживает форматирование документа и выделенного диапазона,\nдействия с кодом на основе предложенных ИИ изменений и диагностики,\nпубликуемые при открытии и сохранении файла.",
+	"  # Start the server (usually done by the editor)\n  aifmt lsp\n\n  # Start without checking code when files are opened and saved\n  aifmt lsp --diagnostics=false":                                                                                    "  # Запуск сервера (обычно выполняется редактором)\n  aifmt lsp\n\n  # Запуск без проверки кода при открытии и сохранении файлов\n  aifmt lsp --diagnostics=false",
+	"AI model for formatting": "Модель ИИ для форматирования",
+	"Check code when a file is opened or saved and publish diagnostics": "Проверять код при открытии и сохранении файла и публиковать диагностики",
+
+	// Команда lsp
+	"LSP server error: %v\n": "Ошибка сервера LSP: %v\n",
+
+	// Команда doc
+	"doc [flags] files...":   "doc [флаги] файлы...",
+	"Document code using AI": "Документирование кода с помощью ИИ",
+	"Add and update documentation comments following the conventions of the\nlanguage: GoDoc for Go (sentences starting with the identifier name), Google or\nNumPy style docstrings for Python, JSDoc and TSDoc for JavaScript and\nTypeScript, Javadoc for Java, and KDoc, rustdoc, C# XML comments, Doxygen,\nPHPDoc and YARD for the other languages.\n\nBy default only exported and public symbols are documented, the --all flag\ndocuments all symbols.\n\nThe code itself must not change: for Go the syntax trees of the original and\nthe new code are compared ignoring comments, for the other languages the code\nis compared without comments and whitespace. A result that changes the code is\nrejected, and the file is retried or passed to a fallback model.\n\nThe comments language is set by the comments_language configuration key, as\nfor the fmt command. The project configuration .aifmt.yaml sets the language,\nmodel, mode, additional instructions and exclusions.": "Добавление и обновление документирующих комментариев по соглашениям языка:\nGoDoc для Go (предложения, начинающиеся с имени идентификатора), docstring\nв стиле Google или NumPy для Python, JSDoc и TSDoc для JavaScript и TypeScript,\nJavadoc для Java, а также KDoc, rustdoc, XML-комментарии C#, Doxygen, PHPDoc\nи YARD для остальных языков.\n\nПо умолчанию документируются только экспортируемые и публичные символы,\nфлаг --all включает документирование всех символов.\n\nСам код меняться не должен: для Go синтаксические деревья исходного и нового\nкода сравниваются без учета комментариев, для остальных языков сравнивается\nкод без комментариев и пробелов. Результат, в котором изменился код,\nотклоняется, и выполняется повторная попытка или переход к резервной модели.\n\nЯзык комментариев задается ключом конфигурации comments_language, как и для\nкоманды fmt. Конфигурация проекта .aifmt.yaml задает язык, модель, режим,\nдополнительные инструкции и исключения.",
+	"AI model. Several comma-separated models can be given: the next one is used on failure":  "Модель ИИ. Можно указать несколько моделей через запятую: при ошибке используется следующая",
+	"Mode: format - write the comments to the file, review - only print the proposed changes": "Режим работы: format - запись комментариев в файл, review - только вывод предложенных изменений",
+	"Document all symbols, not only exported and public ones":                                 "Документировать все символы, а не только экспортируемые и публичные",
+	"Write the results to a file": "Запись результатов в файл",
+
+	// Команда doc
+	"Error: invalid style %q, allowed values: %s\n": "Ошибка: некорректный стиль %q, допустимые значения: %s\n",
+	"Python docstring style: %s":                    "Стиль docstring Python: %s",
+
+	// Команда doc
+	"  # Document exported Go symbols\n  aifmt doc *.go\n\n  # Document all Python functions in NumPy style\n  aifmt doc --all --style numpy app.py\n\n  # Only print the proposed comments without writing files\n  aifmt doc --mode review src/*.ts": "  # Документирование экспортируемых символов Go\n  aifmt doc *.go\n\n  # Документирование всех функций Python в стиле NumPy\n  aifmt doc --all --style numpy app.py\n\n  # Только вывод предложенных комментариев без записи в файлы\n  aifmt doc --mode review src/*.ts",
+
+	// Команда auth
+	"The API key was not stored\n": "API ключ не был сохранен\n",
+	"API key removed\n":            "API ключ удален\n",
+	"Warning: the key is still set in the environment variable %s\n":                                         "Внимание: ключ по-прежнему задан в переменной окружения %s\n",
+	"The API key is not configured. Run 'aifmt auth login' or set the AIFMT_API_KEY environment variable.\n": "API ключ не настроен. Выполните 'aifmt auth login' или задайте переменную окружения AIFMT_API_KEY.\n",
+	"Profile: %s\n":             "Профиль: %s\n",
+	"API key: %s\nSource: %s\n": "API ключ: %s\nИсточник: %s\n",
+	"Warning: the configuration stores api_key in plain text. Run 'aifmt auth login' to move it to the credential store.\n": "Внимание: в конфигурации сохранен ключ api_key в открытом виде. Выполните 'aifmt auth login', чтобы перенести его в хранилище учетных данных.\n",
+	"The API token is not configured. Please run 'aifmt auth login' or set the AIFMT_API_KEY environment variable first.\n": "API токен не настроен. Пожалуйста, сначала выполните 'aifmt auth login' или задайте переменную окружения AIFMT_API_KEY.\n",
+	"the credential store is not available": "хранилище учетных данных недоступно",
+	"error saving the API key: %w":          "ошибка сохранения API ключа: %w",
+	"API key %s saved: %s\n":                "API ключ %s сохранен: %s\n",
+	"error reading the API key: %w":         "ошибка чтения API ключа: %w",
+	"no API key entered":                    "API ключ не введен",
+	"no API key passed to standard input":   "API ключ не передан в стандартный ввод",
+	"Enter the API key: ":                   "Введите API ключ: ",
+
+	// Команда auth
+	"Manage the API key": "Управление API ключом",
+	`Manage the OpenRouter API key.
+
+The key is looked up in the following order:
+  1. the AIFMT_API_KEY and OPENROUTER_API_KEY environment variables;
+  2. the command from the api_key_command configuration key (e.g. "pass show openrouter");
+  3. the file from the api_key_file configuration key;
+  4. the OS credential store, or the ~/.aifmt/credentials file if it is not available;
+  5. the api_key configuration key (deprecated, plain text).`: `Управление API ключом OpenRouter.
+
+Ключ ищется в следующем порядке:
+  1. переменные окружения AIFMT_API_KEY и OPENROUTER_API_KEY;
+  2. команда из ключа конфигурации api_key_command (например, "pass show openrouter");
+  3. файл из ключа конфигурации api_key_file;
+  4. хранилище учетных данных ОС или файл ~/.aifmt/credentials, если оно недоступно;
+  5. ключ api_key в конфигурации (устаревший способ, открытый текст).`,
+	"Save the API key to the credential store": "Сохранение API ключа в хранилище учетных данных",
+	"Save the API key to the OS credential store (macOS keychain, Secret Service\non Linux). If the store is not available, the key is saved to the\n~/.aifmt/credentials file accessible only by the owner.\nThe key is read from standard input. The api_key stored in plain text in the\nconfiguration is removed afterwards.": "Сохранение API ключа в хранилище учетных данных ОС (связка ключей macOS,\nSecret Service в Linux). Если хранилище недоступно, ключ сохраняется в файл\n~/.aifmt/credentials с правами доступа только для владельца.\nКлюч читается из стандартного ввода. Ключ api_key, сохраненный в конфигурации\nоткрытым текстом, после этого удаляется.",
+	"  # Type the key on the keyboard\n  aifmt auth login\n\n  # Pass the key from another program\n  pass show openrouter | aifmt auth login":                                                                                                                                                                                    "  # Ввод ключа с клавиатуры\n  aifmt auth login\n\n  # Передача ключа из другой программы\n  pass show openrouter | aifmt auth login",
+	"Remove the API key from the credential store and the configuration": "Удаление API ключа из хранилища учетных данных и конфигурации",
+	"Print the source of the API key":                                    "Вывод источника API ключа",
+
+	// Хранение API ключа
+	"API key not found":                       "API ключ не найден",
+	"environment variable %s":                 "переменная окружения %s",
+	"configuration (plain text)":              "конфигурация (открытый текст)",
+	"api_key_command command":                 "команда api_key_command",
+	"file %s":                                 "файл %s",
+	"error running api_key_command: %w: %s":   "ошибка выполнения api_key_command: %w: %s",
+	"api_key_command printed no API key":      "api_key_command не вывела API ключ",
+	"error reading api_key_file: %w":          "ошибка чтения api_key_file: %w",
+	"file %s contains no API key":             "файл %s не содержит API ключ",
+	"error getting the home directory: %w":    "ошибка получения домашней директории: %w",
+	"macOS keychain":                          "связка ключей macOS",
+	"the API key cannot contain a line break": "ключ API не может содержать перевод строки",
+	"error reading %s: %w":                    "ошибка чтения %s: %w",
+	"error creating directory %s: %w":         "ошибка создания каталога %s: %w",
+	"error writing %s: %w":                    "ошибка записи %s: %w",
+	"error removing %s: %w":                   "ошибка удаления %s: %w",
+
+	// Команда config
+	"Key '%s' is not set in the configuration\n": "Ключ '%s' не задан в конфигурации\n",
+	"error reading the configuration: %w":        "ошибка чтения конфигурации: %w",
+	"Key '%s' removed from the configuration\n":  "Ключ '%s' удален из конфигурации\n",
+	"%s = %v (unknown key)\n":                    "%s = %v (неизвестный ключ)\n",
+	"error starting the editor: %w":              "ошибка запуска редактора: %w",
+	"error saving the configuration: %w":         "ошибка сохранения конфигурации: %w",
+	"Value '%s' set for key '%s'\n":              "Значение '%s' успешно установлено для ключа '%s'\n",
+	"Configuration %s is valid\n":                "Конфигурация %s корректна\n",
+	"Errors in configuration %s:\n":              "Ошибки в конфигурации %s:\n",
+	"Error: %v\n":                                "Ошибка: %v\n",
+
+	// Команда config
+	"Manage the configuration": "Управление конфигурацией",
+	"View, change and validate the global aifmt configuration.\nValues are checked against a schema: unknown keys and values of the wrong type\nare rejected. Secret values such as api_key are hidden when printed.": "Просмотр, изменение и проверка глобальной конфигурации aifmt.\nЗначения проверяются по схеме: неизвестные ключи и значения неверного типа\nотклоняются. Секретные значения, такие как api_key, при выводе скрываются.",
+	"Print the value of a key": "Вывод значения ключа",
+	"Set the value of a key":   "Установка значения ключа",
+	"Set the value of a key in the configuration. The value is converted to the key\ntype: integers, true/false for booleans, comma-separated lists.": "Установка значения ключа в конфигурации. Значение преобразуется к типу ключа:\nцелые числа, true/false для логических значений, списки через запятую.",
+	"Remove a key from the configuration":      "Удаление ключа из конфигурации",
+	"Print all configuration keys":             "Вывод всех ключей конфигурации",
+	"Print the path of the configuration file": "Вывод пути к файлу конфигурации",
+	"Edit the configuration in a text editor":  "Редактирование конфигурации в текстовом редакторе",
+	"Open the configuration file in the editor from the VISUAL or EDITOR environment\nvariable (vi by default) and validate it after the editor exits.": "Открывает файл конфигурации в редакторе из переменной окружения VISUAL или EDITOR\n(по умолчанию vi) и проверяет его после закрытия редактора.",
+	"Validate the configuration file": "Проверка файла конфигурации",
+	"Show secret values":              "Показывать секретные значения",
+
+	// Команда profile
+	"No profiles configured. Add one with 'aifmt profile add'.\n": "Профили не настроены. Добавьте профиль командой 'aifmt profile add'.\n",
+	"profile %q not found":         "профиль %q не найден",
+	"Active profile: %s\n":         "Активный профиль: %s\n",
+	"Profile %s saved\n":           "Профиль %s сохранен\n",
+	"Profile %s removed\n":         "Профиль %s удален\n",
+	"error reading profile %q: %w": "ошибка чтения профиля %q: %w",
+	"API provider: %s":             "Провайдер API: %s",
+
+	// Команда profile
+	"Manage connection profiles": "Управление профилями подключения",
+	"Manage named profiles. A profile sets the provider, the API address, the API key\nsource, the default model, the temperature and the number of retries.\n\nThe active profile is selected by the --profile flag, the AIFMT_PROFILE\nenvironment variable or the 'aifmt profile use' command.": "Управление именованными профилями. Профиль задает провайдера, адрес API,\nисточник API ключа, модель по умолчанию, температуру и количество повторных попыток.\n\nАктивный профиль выбирается флагом --profile, переменной окружения AIFMT_PROFILE\nили командой 'aifmt profile use'.",
+	"List profiles":             "Вывод списка профилей",
+	"Select the active profile": "Выбор активного профиля",
+	"Add or change a profile":   "Добавление или изменение профиля",
+	"  # Personal OpenRouter key\n  aifmt profile add personal --provider openrouter --model deepseek/deepseek-chat:free\n\n  # Corporate gateway with the key from an environment variable\n  aifmt profile add work --provider openai --base-url https://llm.example.com/v1 --api-key-env WORK_LLM_KEY\n\n  # Local Ollama\n  aifmt profile add local --provider ollama --model qwen2.5-coder": "  # Личный ключ OpenRouter\n  aifmt profile add personal --provider openrouter --model deepseek/deepseek-chat:free\n\n  # Корпоративный шлюз с ключом из переменной окружения\n  aifmt profile add work --provider openai --base-url https://llm.example.com/v1 --api-key-env WORK_LLM_KEY\n\n  # Локальный Ollama\n  aifmt profile add local --provider ollama --model qwen2.5-coder",
+	"Remove a profile": "Удаление профиля",
+	"API address, the provider address by default": "Адрес API, по умолчанию адрес провайдера",
+	"Environment variable with the API key":        "Переменная окружения с API ключом",
+	"Command that prints the API key":              "Команда, выводящая API ключ",
+	"Path to a file with the API key":              "Путь к файлу с API ключом",
+	"Default AI model":                             "Модель ИИ по умолчанию",
+	"Generation temperature":                       "Температура генерации",
+	"Maximum number of retries":                    "Максимальное количество повторных попыток",
+
+	// Команда batch
+	"invalid mode %q, allowed values: %s, %s": "некорректный режим %q, допустимые значения: %s, %s",
+	"error parsing pattern":                   "ошибка при разборе шаблона",
+	"configuration error":                     "ошибка конфигурации",
+	"no files to submit":                      "нет файлов для отправки",
+	"submitting job":                          "отправка задания",
+	"Job %s submitted. Check its state with: aifmt batch status %s\n": "Задание %s отправлено. Проверка состояния: aifmt batch status %s\n",
+	"No jobs\n": "Заданий нет\n",
+	"JOB\tBACKEND\tSTATE\tFILES\tSUBMITTED\tAPPLIED\n":                       "ЗАДАНИЕ\tПРОВАЙДЕР\tСОСТОЯНИЕ\tФАЙЛОВ\tОТПРАВЛЕНО\tПРИМЕНЕНО\n",
+	"Job %s: %s, %d of %d completed, %d failed\n":                            "Задание %s: %s, выполнено %d из %d, с ошибкой %d\n",
+	"Results of job %s saved to %s. Apply them with: aifmt batch apply %s\n": "Результаты задания %s сохранены в %s. Применение: aifmt batch apply %s\n",
+	"error reading the job results: %w":                                      "ошибка чтения результатов задания: %w",
+	"file changed after the job was submitted, result not applied":           "файл изменен после отправки задания, результат не применен",
+	"syntax error: %w": "синтаксическая ошибка: %w",
+	"job %s is still running (state: %s, %d of %d completed)": "задание %s еще выполняется (состояние: %s, выполнено %d из %d)",
+	"unknown job backend %q, allowed values: %s, %s":          "неизвестный провайдер заданий %q, допустимые значения: %s, %s",
+
+	// Команда batch
+	"Batch formatting of a large number of files": "Пакетное форматирование большого количества файлов",
+	"Batch formatting: requests for all files are sent to the provider as one job\nin the OpenAI Batch API format (JSONL), and the results are applied later,\nwhen the job is done. This is cheaper and does not require waiting for a\nresponse for every file.\n\nJobs are stored in the ~/.aifmt/batches directory. A result is applied only to\nfiles that have not changed since the job was submitted, and it goes through\nthe usual checks: syntax, the verify_cmd command and the report.\n\nThe openai backend uses the Batch API. The local backend runs the requests one\nby one with the regular API when the job is submitted and is suitable for\nproviders without a batch API (OpenRouter, Ollama) and for testing.": "Пакетное форматирование: запросы для всех файлов отправляются провайдеру\nодним заданием в формате Batch API OpenAI (JSONL), а результаты применяются\nпозже, когда задание будет выполнено. Это дешевле и не требует ожидания ответа\nна каждый файл.\n\nЗадания хранятся в каталоге ~/.aifmt/batches. Результат применяется только\nк файлам, которые не изменились после отправки задания, и проходит обычную\nпроверку: синтаксис, команду verify_cmd и запись отчета.\n\nПровайдер openai использует Batch API. Провайдер local выполняет запросы\nпо одному обычным API при отправке задания и подходит для провайдеров\nбез пакетного API (OpenRouter, Ollama) и для проверки.",
+	`  # Submit a job for all Go files
+  aifmt batch submit -l go $(git ls-files '*.go')
+
+  # State of all jobs or of one job
+  aifmt batch status
+  aifmt batch status 20250101-120000
+
+  # Download and apply the results
+  aifmt batch fetch 20250101-120000
+  aifmt batch apply 20250101-120000 --verify-cmd "go build ./..."`: `  # Отправка задания для всех Go файлов
+  aifmt batch submit -l go $(git ls-files '*.go')
+
+  # Состояние всех заданий или одного задания
+  aifmt batch status
+  aifmt batch status 20250101-120000
+
+  # Скачивание и применение результатов
+  aifmt batch fetch 20250101-120000
+  aifmt batch apply 20250101-120000 --verify-cmd "go build ./..."`,
+	"submit [flags] files...":                "submit [флаги] файлы...",
+	"Submit a job to format files":           "Отправка задания на форматирование файлов",
+	"status [job]":                           "status [задание]",
+	"Job state":                              "Состояние заданий",
+	"fetch job":                              "fetch задание",
+	"Download the results of a finished job": "Скачивание результатов выполненного задания",
+	"apply job":                              "apply задание",
+	"Apply the job results to files":         "Применение результатов задания к файлам",
+	"Mode for applying the results: format - write the files, review - only print the proposed changes":             "Режим применения результатов: format - запись в файлы, review - только вывод предложенных изменений",
+	"Job backend: openai or local, openai for an OpenAI profile by default, local otherwise":                        "Провайдер заданий: openai или local, по умолчанию openai для профиля OpenAI, иначе local",
+	"Override the mode given at submission: format or review":                                                       "Изменить режим, указанный при отправке: format или review",
+	"Command that checks the project after files are written, overrides the command from the project configuration": "Команда проверки проекта после записи файлов, заменяет команду из конфигурации проекта",
+
+	// Команда hook
+	"hook %s already exists. Use --force to replace it (a backup will be created)": "хук %s уже существует. Используйте --force, чтобы заменить его (будет создана резервная копия)",
+	"error backing up the hook: %w":                                      "ошибка создания резервной копии хука: %w",
+	"Existing hook saved to %s\n":                                        "Существующий хук сохранен в %s\n",
+	"error creating the hooks directory: %w":                             "ошибка создания каталога хуков: %w",
+	"error writing the hook: %w":                                         "ошибка записи хука: %w",
+	"pre-commit hook installed: %s\n":                                    "Хук pre-commit установлен: %s\n",
+	"The pre-commit hook is not installed\n":                             "Хук pre-commit не установлен\n",
+	"hook %s was not installed by aifmt and will not be removed":         "хук %s установлен не aifmt и не будет удален",
+	"error removing the hook: %w":                                        "ошибка удаления хука: %w",
+	"pre-commit hook removed: %s\n":                                      "Хук pre-commit удален: %s\n",
+	"error restoring the hook: %w":                                       "ошибка восстановления хука: %w",
+	"Previous hook restored from %s\n":                                   "Восстановлен предыдущий хук из %s\n",
+	"the current directory is not a git repository: %w":                  "текущий каталог не является репозиторием git: %w",
+	"error reading file from the index":                                  "ошибка чтения файла из индекса",
+	"file needs formatting":                                              "файл требует форматирования",
+	"failed to stage file":                                               "не удалось добавить файл в индекс",
+	"Files need formatting: %s. Run 'aifmt fmt --hook' and commit again": "Файлы требуют форматирования: %s. Выполните 'aifmt fmt --hook' и повторите коммит",
+	"file has unstaged changes: only the staged version was formatted and staged, the working copy is unchanged": "файл содержит неиндексированные изменения: отформатирована и добавлена в индекс только индексированная версия, рабочая копия не изменена",
+	"file formatted and staged": "файл отформатирован и добавлен в индекс",
+
+	// Команда hook
+	"Manage the git pre-commit hook": "Управление хуком git pre-commit",
+	"Install and remove the git pre-commit hook that runs 'aifmt fmt --hook'\nfor the staged files before every commit.":                                                 "Установка и удаление хука git pre-commit, который перед каждым коммитом\nзапускает 'aifmt fmt --hook' для файлов, добавленных в индекс.",
+	"Install the pre-commit hook into the current repository":                                                                                                            "Установка хука pre-commit в текущий репозиторий",
+	"  # Format the staged files before a commit\n  aifmt hook install\n\n  # Check only: the commit is rejected if files need formatting\n  aifmt hook install --check": "  # Форматирование добавленных в индекс файлов перед коммитом\n  aifmt hook install\n\n  # Только проверка: коммит отклоняется, если файлы требуют форматирования\n  aifmt hook install --check",
+	"Remove the pre-commit hook from the current repository":                                                                                                             "Удаление хука pre-commit из текущего репозитория",
+	"Only check formatting and reject the commit without changing files":                                                                                                 "Только проверять форматирование и отклонять коммит, не изменяя файлы",
+	"Replace an existing pre-commit hook, keeping a backup of it":                                                                                                        "Заменить существующий хук pre-commit, сохранив его резервную копию",
+
+	// Команда watch
+	"watching files, press Ctrl-C to stop":               "наблюдение за файлами, для завершения нажмите Ctrl-C",
+	"file changed during formatting, result not written": "файл изменен во время форматирования, результат не записан",
+
+	// Команда watch
+	"watch [flags] [paths...]": "watch [флаги] [пути...]",
+	"Format files on save":     "Форматирование файлов при сохранении",
+	"Watch files and directories (recursively) and format files after they are\nsaved. The current directory is watched by default.\n\nA file is processed once it has not changed for --debounce. Changes written by\nthe command itself are not processed again. If a file changed during\nformatting, the result is not written and the file is formatted again. The\nproject configuration .aifmt.yaml, --ignore patterns and hidden directories\nare taken into account, as well as the .git, node_modules and vendor\ndirectories.\n\nIn review mode (--mode review) changes are only printed, files are not changed.": "Наблюдение за файлами и каталогами (рекурсивно) и форматирование файлов\nпосле их сохранения. По умолчанию отслеживается текущий каталог.\n\nФайл обрабатывается после того, как он не изменялся в течение --debounce.\nИзменения, записанные самой командой, повторно не обрабатываются. Если файл\nбыл изменен во время форматирования, результат не записывается, и файл\nформатируется заново. Учитываются конфигурация проекта .aifmt.yaml, шаблоны\n--ignore и скрытые каталоги, а также каталоги .git, node_modules и vendor.\n\nВ режиме review (--mode review) изменения только выводятся, файлы не изменяются.",
+	`  # Format files of the current directory on save
+  aifmt watch
+
+  # Only print the proposed changes for the internal directory
+  aifmt watch --mode review internal
+
+  # Exclude generated files
+  aifmt watch --ignore "*.pb.go" --ignore "gen/"`: `  # Форматирование файлов текущего каталога при сохранении
+  aifmt watch
+
+  # Только вывод предложенных изменений для каталога internal
+  aifmt watch --mode review internal
+
+  # Исключение сгенерированных файлов
+  aifmt watch --ignore "*.pb.go" --ignore "gen/"`,
+	"AI model for formatting. Several comma-separated models can be given": "Модель ИИ для форматирования. Можно указать несколько моделей через запятую",
+	"Time to wait after the last change of a file before formatting it":    "Время ожидания после последнего изменения файла перед форматированием",
+	"Patterns of files and directories not to watch":                       "Шаблоны файлов и каталогов, которые не нужно отслеживать",
+
+	// Проверка комментариев
+	" and %d more": " и еще %d",
+	"original comments were removed or translated: %s": "удалены или переведены исходные комментарии: %s",
+
+	// Выбор варианта
+	"empty response": "пустой ответ",
+
+	// Форматеры
+	"no builtin formatter for %s":  "нет встроенного форматера для языка %s",
+	"formatter %q error: %s":       "ошибка форматера %q: %s",
+	"formatter %q error: %w":       "ошибка форматера %q: %w",
+	"formatter %q printed no code": "форматер %q не вывел код",
+
+	// Форматеры
+	"Go formatting error: %w": "ошибка форматирования Go: %w",
+
+	// Git
+	"file %s is not in the index":  "файл %s отсутствует в индексе",
+	"error running git %s: %v: %s": "ошибка выполнения git %s: %v: %s",
+
+	// Конфигурация проекта
+	"error getting the absolute path of %s: %w":     "ошибка получения абсолютного пути %s: %w",
+	"error checking file %s: %w":                    "ошибка проверки файла %s: %w",
+	"error reading project configuration %s: %w":    "ошибка чтения конфигурации проекта %s: %w",
+	"error parsing project configuration %s: %w":    "ошибка разбора конфигурации проекта %s: %w",
+	"invalid mode %q in %s, allowed values: %s, %s": "некорректный режим %q в %s, допустимые значения: %s, %s",
+
+	// Проверка синтаксиса
+	"error parsing the original code: %w":                               "ошибка разбора исходного кода: %w",
+	"error parsing the new code: %w":                                    "ошибка разбора нового кода: %w",
+	"code changed at line %d: %s instead of %s":                         "изменен код в строке %d: %s вместо %s",
+	"code changed: the new code has %d syntax tree nodes instead of %d": "изменен код: в новом коде %d узлов синтаксического дерева вместо %d",
+
+	// Проверка синтаксиса
+	"syntax check is not supported": "проверка синтаксиса не поддерживается",
+	"syntax error: %s":              "синтаксическая ошибка: %s",
+
+	// Наблюдение за файлами
+	"error creating the watcher: %w":         "ошибка создания наблюдателя: %w",
+	"error getting information about %s: %w": "ошибка получения информации о %s: %w",
+	"error watching %s: %w":                  "ошибка наблюдения за %s: %w",
+	"error watching files: %w":               "ошибка наблюдения за файлами: %w",
+
+	// Сервер LSP
+	"error reading the header: %w":      "ошибка чтения заголовка: %w",
+	"invalid Content-Length header: %w": "некорректный заголовок Content-Length: %w",
+	"error reading the message: %w":     "ошибка чтения сообщения: %w",
+	"method not supported: %s":          "метод не поддерживается: %s",
+	"document is not open: %s":          "документ не открыт: %s",
+	"aifmt: apply all changes":          "aifmt: применить все изменения",
+	"aifmt: error checking %s: %v":      "aifmt: ошибка проверки %s: %v",
+	"request cancelled":                 "запрос отменен",
+
+	// Пакетные задания
+	"the job results contain no response to the request": "в результатах задания нет ответа на запрос",
+	"error parsing line %d of the results file: %w":      "ошибка разбора строки %d файла результатов: %w",
+	"request failed: %s %s":                              "ошибка выполнения запроса: %s %s",
+	"%w: no response":                                    "%w: нет ответа",
+
+	// Пакетные задания
+	"error creating the jobs directory: %w":                    "ошибка создания каталога заданий: %w",
+	"job %s not found, list the jobs with: aifmt batch status": "задание %s не найдено, список заданий: aifmt batch status",
+	"error reading job %s: %w":                                 "ошибка чтения задания %s: %w",
+
+	// Пакетные задания
+	"error parsing line %d of the input file: %w": "ошибка разбора строки %d входного файла: %w",
+	"job %s not found: %w":                        "задание %s не найдено: %w",
+
+	// Пакетные задания
+	"error uploading the job input file: %w": "ошибка загрузки входного файла задания: %w",
+	"error creating the job: %w":             "ошибка создания задания: %w",
+	"error downloading file %s: %w":          "ошибка скачивания файла %s: %w",
+	"error getting the state of job %s: %w":  "ошибка получения состояния задания %s: %w",
+	"error reading the response: %w":         "ошибка чтения ответа: %w",
+
+	// Файл конфигурации
+	"error reading configuration %s: %w":   "ошибка чтения конфигурации %s: %w",
+	"error parsing configuration %s: %w":   "ошибка разбора конфигурации %s: %w",
+	"error encoding the configuration: %w": "ошибка сериализации конфигурации: %w",
+	"error writing configuration %s: %w":   "ошибка записи конфигурации %s: %w",
+
+	// Схема конфигурации
+	"unknown key %q, did you mean %q (%s)":                  "неизвестный ключ %q, возможно, имелся в виду %q (%s)",
+	"unknown key %q, list the keys with: aifmt config list": "неизвестный ключ %q, список ключей: aifmt config list",
+	"key %s: expected an integer, got %q":                   "ключ %s: ожидается целое число, получено %q",
+	"key %s: expected a number, got %q":                     "ключ %s: ожидается число, получено %q",
+	"key %s: expected a duration such as 90s or 5m, got %q": "ключ %s: ожидается длительность, например 90s или 5m, получено %q",
+	"key %s: expected true or false, got %q":                "ключ %s: ожидается true или false, получено %q",
+	"key %s: expected an integer, got %s":                   "ключ %s: ожидается целое число, получено %s",
+	"key %s: the value must be at least %d, got %d":         "ключ %s: значение должно быть не меньше %d, получено %d",
+	"key %s: expected a number, got %s":                     "ключ %s: ожидается число, получено %s",
+	"key %s: expected a duration such as 90s or 5m, got %s": "ключ %s: ожидается длительность, например 90s или 5m, получено %s",
+	"key %s: expected true or false, got %s":                "ключ %s: ожидается true или false, получено %s",
+	"key %s: list items must be strings, got %s":            "ключ %s: элементы списка должны быть строками, получено %s",
+	"key %s: expected a list, got %s":                       "ключ %s: ожидается список, получено %s",
+	"key %s: expected a string, got %s":                     "ключ %s: ожидается строка, получено %s",
+	"key %s: invalid value %q, allowed values: %s":          "ключ %s: недопустимое значение %q, допустимые значения: %s",
+	"string %q":   "строка %q",
+	"empty value": "пустое значение",
+
+	// Правила стиля
+	"error parsing %s: %w": "ошибка разбора %s: %w",
+
+	// Описания ключей конфигурации
+	"OpenRouter API key in plain text (deprecated, use aifmt auth login)":                                                                          "API ключ OpenRouter в открытом виде (устаревший способ, используйте aifmt auth login)",
+	"Command that prints the API key, e.g. \"pass show openrouter\"":                                                                               "Команда, выводящая API ключ, например \"pass show openrouter\"",
+	"Comma-separated fallback models, tried in order when the main model is unavailable":                                                           "Резервные модели через запятую, используются по порядку, если основная модель недоступна",
+	"Language of code comments, auto - the language of the file's existing comments":                                                               "Язык комментариев в коде, auto - язык существующих комментариев файла",
+	"Language of AI prompts, the interface language by default":                                                                                    "Язык запросов к ИИ, по умолчанию язык интерфейса",
+	"Reject results that remove or translate the original comments":                                                                                "Отклонять результаты, в которых удалены или переведены исходные комментарии",
+	"Add style rules from .editorconfig, .prettierrc, .golangci.yml, pyproject.toml and .clang-format to the prompt":                               "Добавлять в запрос правила стиля из .editorconfig, .prettierrc, .golangci.yml, pyproject.toml и .clang-format",
+	"Language code formatter run before and after the AI: a command reading the code from standard input ({file} - the file path), builtin or off": "Форматер кода языка до и после ИИ: команда, получающая код на стандартный ввод ({file} - путь к файлу), builtin или off",
+	"Number of files processed in parallel":                                                                                                        "Количество параллельно обрабатываемых файлов",
+	"API address, unless set by the profile":                                                                                                       "Адрес API, если не задан профилем",
+	"Additional HTTP request header":                                                                                                               "Дополнительный заголовок HTTP запросов",
+	"Request timeout, e.g. 90s or 5m":                                                                                                              "Время ожидания ответа на запрос, например 90s или 5m",
+	"Proxy server address, taken from HTTPS_PROXY and similar environment variables by default":                                                    "Адрес прокси-сервера, по умолчанию из переменных окружения HTTPS_PROXY и др.",
+	"File with additional root certificates in PEM format":                                                                                         "Файл с дополнительными корневыми сертификатами в формате PEM",
+	"Do not verify the server certificate":                                                                                                         "Не проверять сертификат сервера",
+	"Minimum TLS version":                                                                                                                          "Минимальная версия TLS",
+	"Active profile":                                                                                                                               "Активный профиль",
+	"Profile provider":                                                                                                                             "Провайдер профиля",
+	"Profile API address":                                                                                                                          "Адрес API профиля",
+	"Environment variable with the profile API key":                                                                                                "Переменная окружения с API ключом профиля",
+	"Command that prints the profile API key":                                                                                                      "Команда, выводящая API ключ профиля",
+	"Path to a file with the profile API key":                                                                                                      "Путь к файлу с API ключом профиля",
+	"Profile AI model":                              "Модель ИИ профиля",
+	"Profile generation temperature":                "Температура генерации профиля",
+	"Additional HTTP request header of the profile": "Дополнительный заголовок HTTP запросов профиля",
+	"Maximum number of retries of the profile":      "Максимальное количество повторных попыток профиля",
+
+	// Описания правил стиля в запросе
+	"Follow the style rules set by the project configuration:":             "Соблюдай правила стиля, заданные конфигурацией проекта:",
+	"line length at most %d characters":                                    "длина строки не более %d символов",
+	"tab indentation":                                                      "отступы табуляцией",
+	"indentation with %d spaces":                                           "отступы пробелами шириной %d",
+	"space indentation":                                                    "отступы пробелами",
+	"tab width %s":                                                         "ширина табуляции %s",
+	"%s line endings":                                                      "окончания строк %s",
+	"%s encoding":                                                          "кодировка %s",
+	"no trailing whitespace":                                               "без пробелов в конце строк",
+	"the file ends with a newline":                                         "файл заканчивается переводом строки",
+	"no newline at the end of the file":                                    "без перевода строки в конце файла",
+	"semicolons at the end of statements":                                  "точка с запятой в конце инструкций",
+	"no semicolons at the end of statements except where required":         "без точки с запятой в конце инструкций, кроме обязательных случаев",
+	"single quotes for strings":                                            "одинарные кавычки для строк",
+	"double quotes for strings":                                            "двойные кавычки для строк",
+	"single quotes in JSX attributes":                                      "одинарные кавычки в атрибутах JSX",
+	"no trailing commas":                                                   "без завершающих запятых",
+	"trailing commas in multiline objects and arrays":                      "завершающие запятые в многострочных объектах и массивах",
+	"trailing commas wherever valid, including function parameters":        "завершающие запятые везде, где это допустимо, включая параметры функций",
+	"no spaces inside object braces: {a: 1}":                               "без пробелов внутри фигурных скобок объектов: {a: 1}",
+	"no parentheses around a single arrow function parameter":              "без скобок вокруг единственного параметра стрелочной функции",
+	"always parentheses around arrow function parameters":                  "скобки вокруг параметров стрелочной функции всегда",
+	"quotes in object property names: %s":                                  "кавычки в именах свойств объектов: %s",
+	"%s style (clang-format)":                                              "стиль %s (clang-format)",
+	"no line length limit":                                                 "без ограничения длины строки",
+	"opening brace on the same line":                                       "открывающая фигурная скобка на той же строке",
+	"opening brace on its own line":                                        "открывающая фигурная скобка на отдельной строке",
+	"%s brace style":                                                       "расстановка фигурных скобок в стиле %s",
+	"pointer symbol attached to the type: int* p":                          "символ указателя прижат к типу: int* p",
+	"pointer symbol attached to the name: int *p":                          "символ указателя прижат к имени: int *p",
+	"pointer symbol surrounded by spaces: int * p":                         "символ указателя отделен пробелами: int * p",
+	"#include directives and imports are sorted":                           "директивы #include и импорты отсортированы",
+	"a function body is never put on the same line as its declaration":     "тело функции не записывается на одной строке с объявлением",
+	"case labels indented relative to switch":                              "метки case с отступом относительно switch",
+	"case labels at the switch level":                                      "метки case на уровне switch",
+	"no indentation inside namespace":                                      "без отступа внутри namespace",
+	"indentation inside namespace":                                         "отступ внутри namespace",
+	"no space before parentheses":                                          "без пробела перед круглыми скобками",
+	"formatting by the black rules":                                        "форматирование по правилам black",
+	"string quotes are left as they are":                                   "кавычки строк не заменяются",
+	"a trailing comma keeps the construct split across lines":              "завершающая запятая сохраняет разбиение конструкции по строкам",
+	"compatibility with Python versions: %s":                               "совместимость с версиями Python: %s",
+	"compatibility with Python %s":                                         "совместимость с версией Python %s",
+	"imports sorted and grouped by the isort rules":                        "импорты отсортированы и сгруппированы по правилам isort",
+	"docstrings for public modules, classes and functions":                 "docstring у публичных модулей, классов и функций",
+	"docstrings for public modules, classes and functions in the %s style": "docstring у публичных модулей, классов и функций в стиле %s",
+	"modern Python syntax (pyupgrade)":                                     "современный синтаксис Python (pyupgrade)",
+	"type annotations for function parameters and results":                 "аннотации типов у параметров и результатов функций",
+	"project packages in a separate import group: %s":                      "пакеты проекта в отдельной группе импортов: %s",
+	"the code must pass ruff with the rules: %s":                           "код должен проходить проверку ruff с правилами: %s",
+	"ruff rules that are not checked: %s":                                  "правила ruff, которые не проверяются: %s",
+	"formatting by the strict gofumpt rules":                               "форматирование по строгим правилам gofumpt",
+	"imports sorted and grouped as by goimports: the standard library in a separate group": "импорты отсортированы и сгруппированы как в goimports: стандартная библиотека отдельной группой",
+	"comments end with a period": "комментарии заканчиваются точкой",
+	"errors are compared with errors.Is and errors.As and wrapped with %%w":                        "ошибки сравниваются через errors.Is и errors.As, оборачиваются через %%w",
+	"errors from other packages are wrapped with context":                                          "ошибки из других пакетов оборачиваются с контекстом",
+	"no naked returns in functions with named results":                                             "без return без значений в функциях с именованными результатами",
+	"a blank line before return and break":                                                         "пустая строка перед return и break",
+	"no blank lines at the start and end of blocks":                                                "без пустых строк в начале и в конце блоков",
+	"names and comments follow the Go conventions: comments on exported names start with the name": "имена и комментарии по соглашениям Go: комментарии к экспортируемым именам начинаются с имени",
+	"no misspelled English words":                                                                  "без опечаток в английских словах",
+	"repeated strings are extracted into constants":                                                "повторяющиеся строки вынесены в константы",
+	"no constructs that gocritic warns about":                                                      "без конструкций, о которых предупреждает gocritic",
+	"slices of known length are created with the needed capacity":                                  "срезы с известной длиной создаются с нужной емкостью",
+	"no unused function parameters":                                                                "без неиспользуемых параметров функций",
+	"nolint directives name the linter and the reason":                                             "директивы nolint с указанием линтера и причины",
+	"no names that shadow predeclared identifiers":                                                 "без имен, совпадающих со встроенными идентификаторами",
+	"imports of packages with the prefix %s in a separate group after external packages":           "импорты пакетов с префиксом %s отдельной группой после внешних пакетов",
+	"import group order (gci): %s":                                                                 "порядок групп импортов (gci): %s",
+	"functions at most %d lines long":                                                              "функции не длиннее %d строк",
+	"function complexity (%s) at most %d":                                                          "сложность функций (%s) не выше %d",
+	"%s spelling of English words":                                                                 "английские слова в написании %s",
+	"the code must pass the golangci-lint linters: %s":                                             "код должен проходить проверку линтерами golangci-lint: %s",
+
+	// Названия языков комментариев
+	"Russian":    "Русский",
+	"English":    "Английский",
+	"Ukrainian":  "Украинский",
+	"German":     "Немецкий",
+	"French":     "Французский",
+	"Spanish":    "Испанский",
+	"Polish":     "Польский",
+	"Portuguese": "Португальский",
+	"Czech":      "Чешский",
+	"Turkish":    "Турецкий",
+	"Japanese":   "Японский",
+	"Chinese":    "Китайский",
+	"Korean":     "Корейский",
+}
+
+// init регистрирует переводы. Errorf передает принтеру сообщение с %v вместо %w, поэтому
+// сообщения ошибок регистрируются в том же виде
+func init() {
+	for key, msg := range russian {
+		message.SetString(language.Russian, strings.ReplaceAll(key, "%w", "%v"), strings.ReplaceAll(msg, "%w", "%v"))
+	}
+}
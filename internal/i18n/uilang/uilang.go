@@ -0,0 +1,14 @@
+// Package uilang определяет язык интерфейса командной строки при импорте. Пакет импортируется
+// только командами aifmt: описания команд переводятся при их создании, поэтому язык должен быть
+// выбран до инициализации пакета cmd. Библиотеки его не импортируют и по умолчанию работают на английском
+package uilang
+
+import (
+	"os"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+func init() {
+	i18n.Set(i18n.Detect(os.Args[1:], os.Getenv))
+}
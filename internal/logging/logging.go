@@ -0,0 +1,190 @@
+// Package logging создает структурированный логгер log/slog с уровнем подробности из флагов
+// командной строки и сохраняет запросы к API вместе с ответами для диагностики
+package logging
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// Форматы сообщений
+const (
+	FormatText = "text" // Текст для чтения человеком
+	FormatJSON = "json" // Объект JSON в каждой строке
+)
+
+// LevelTrace - уровень сообщений о каждом запросе к API, включается флагом -vv
+const LevelTrace = slog.LevelDebug - 4
+
+// Options - параметры логгера
+type Options struct {
+	Verbosity int    // Подробность: 0 - обычные сообщения, 1 - отладочные (-v), 2 - запросы к API (-vv)
+	Quiet     bool   // Выводить только предупреждения и ошибки
+	Format    string // FormatText или FormatJSON, по умолчанию FormatText
+}
+
+// Formats возвращает поддерживаемые форматы сообщений
+func Formats() []string {
+	return []string{FormatText, FormatJSON}
+}
+
+// Level возвращает минимальный уровень выводимых сообщений
+func (o Options) Level() slog.Level {
+	switch {
+	case o.Quiet:
+		return slog.LevelWarn
+	case o.Verbosity >= 2:
+		return LevelTrace
+	case o.Verbosity == 1:
+		return slog.LevelDebug
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// New создает логгер, выводящий сообщения в w
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	level := opts.Level()
+
+	switch opts.Format {
+	case "", FormatText:
+		return slog.New(&textHandler{mu: &sync.Mutex{}, w: w, level: level}), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level, ReplaceAttr: replaceLevel})), nil
+	default:
+		return nil, i18n.Errorf("invalid log format %q, allowed values: %s", opts.Format, strings.Join(Formats(), ", "))
+	}
+}
+
+// Discard возвращает логгер, который не выводит сообщений
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// replaceLevel заменяет название уровня LevelTrace, которое slog выводит как DEBUG-4
+func replaceLevel(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 {
+		a.Value = slog.StringValue(levelName(a.Value.Any().(slog.Level)))
+	}
+	return a
+}
+
+func levelName(l slog.Level) string {
+	if l == LevelTrace {
+		return "TRACE"
+	}
+	return l.String()
+}
+
+// textHandler выводит сообщение одной строкой с атрибутами ключ=значение. Сообщение переводится
+// на язык интерфейса, в формате JSON оно выводится на английском языке, чтобы не зависеть от
+// локали. Уровень указывается для всех сообщений, кроме информационных, многострочные значения
+// выводятся после строки сообщения
+type textHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Level
+	attrs  []slog.Attr
+	groups []string
+}
+
+func (h *textHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b bytes.Buffer
+	var blocks []string
+
+	if r.Level != slog.LevelInfo {
+		b.WriteString(levelName(r.Level))
+		b.WriteString(" ")
+	}
+	b.WriteString(i18n.Sprintf(r.Message))
+
+	var add func(prefix string, a slog.Attr)
+	add = func(prefix string, a slog.Attr) {
+		a.Value = a.Value.Resolve()
+		if a.Equal(slog.Attr{}) {
+			return
+		}
+		if a.Value.Kind() == slog.KindGroup {
+			p := prefix
+			if a.Key != "" {
+				p += a.Key + "."
+			}
+			for _, ga := range a.Value.Group() {
+				add(p, ga)
+			}
+			return
+		}
+
+		key, value := prefix+a.Key, a.Value.String()
+		if strings.Contains(value, "\n") {
+			blocks = append(blocks, key+":\n"+strings.TrimRight(value, "\n"))
+			return
+		}
+		b.WriteString(" " + key + "=" + quote(value))
+	}
+
+	prefix := strings.Join(h.groups, ".")
+	if prefix != "" {
+		prefix += "."
+	}
+	for _, a := range h.attrs {
+		add("", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		add(prefix, a)
+		return true
+	})
+
+	b.WriteByte('\n')
+	for _, block := range blocks {
+		b.WriteString(block)
+		b.WriteByte('\n')
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(b.Bytes())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = slices.Clone(h.attrs)
+	for _, a := range attrs {
+		// Атрибуты, добавленные внутри группы, получают ее префикс
+		for i := len(h.groups) - 1; i >= 0; i-- {
+			a = slog.Group(h.groups[i], a)
+		}
+		h2.attrs = append(h2.attrs, a)
+	}
+	return &h2
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(slices.Clone(h.groups), name)
+	return &h2
+}
+
+// quote заключает значение в кавычки, если оно пустое или содержит пробелы, кавычки или знак =
+func quote(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
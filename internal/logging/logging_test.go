@@ -0,0 +1,188 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+func TestLevel(t *testing.T) {
+	tests := []struct {
+		opts Options
+		want slog.Level
+	}{
+		{Options{}, slog.LevelInfo},
+		{Options{Verbosity: 1}, slog.LevelDebug},
+		{Options{Verbosity: 2}, LevelTrace},
+		{Options{Verbosity: 3}, LevelTrace},
+		{Options{Verbosity: 2, Quiet: true}, slog.LevelWarn},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.Level(); got != tt.want {
+			t.Errorf("%+v.Level() = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Verbosity: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Info("файл обновлен", "file", "main.go")
+	logger.Warn("ошибка форматирования", "file", "my file.go", "err", errors.New("timeout"))
+	logger.Debug("вариант", "candidate", 1, "code", "a\nb\n")
+	logger.Log(t.Context(), LevelTrace, "API request")
+	logger.With("file", "a.go").WithGroup("api").Info("запрос", "status", 200)
+
+	want := `файл обновлен file=main.go
+WARN ошибка форматирования file="my file.go" err=timeout
+DEBUG вариант candidate=1
+code:
+a
+b
+запрос file=a.go api.status=200
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Verbosity: 2, Format: FormatJSON})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Log(t.Context(), LevelTrace, "API request", "status", 200)
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if rec["level"] != "TRACE" || rec["msg"] != "API request" || rec["status"] != float64(200) {
+		t.Errorf("unexpected record: %v", rec)
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Quiet: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("файл обновлен")
+	logger.Error("ошибка записи файла")
+
+	if got, want := buf.String(), "ERROR ошибка записи файла\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestInvalidFormat(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, Options{Format: "xml"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Verbosity: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "debug")
+	client := &http.Client{Transport: Transport(nil, logger, dir)}
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/chat?key=query-secret", strings.NewReader(`{"model":"m","echo":"secret-key"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret-key")
+	req.Header.Set("X-Api-Key", "header-secret")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Choices []any `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil || len(got.Choices) != 1 {
+		t.Errorf("response body was not preserved: %v", err)
+	}
+	resp.Body.Close()
+
+	if !strings.Contains(buf.String(), "TRACE API request method=POST") || !strings.Contains(buf.String(), "status=200") {
+		t.Errorf("request was not logged: %q", buf.String())
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one dump, got %v (%v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"secret-key", "query-secret", "header-secret"} {
+		if strings.Contains(string(data), secret) || strings.Contains(buf.String(), secret) {
+			t.Errorf("secret %q was not redacted:\n%s\n%s", secret, data, buf.String())
+		}
+	}
+
+	var ex exchange
+	if err := json.Unmarshal(data, &ex); err != nil {
+		t.Fatal(err)
+	}
+	if ex.Request.Method != http.MethodPost || ex.Response == nil || ex.Response.Status != http.StatusOK {
+		t.Errorf("unexpected dump: %s", data)
+	}
+	var reqBody, respBody bytes.Buffer
+	json.Compact(&reqBody, ex.Request.Body)
+	json.Compact(&respBody, ex.Response.Body)
+	if reqBody.String() != `{"model":"m","echo":"[REDACTED]"}` || respBody.String() != `{"choices":[{"message":{"content":"ok"}}]}` {
+		t.Errorf("bodies were not saved: %s", data)
+	}
+}
+
+func TestTextTranslated(t *testing.T) {
+	defer i18n.Set(i18n.Lang())
+	i18n.Set(i18n.Russian)
+
+	var text, js bytes.Buffer
+	for _, l := range []struct {
+		w      *bytes.Buffer
+		format string
+	}{{&text, FormatText}, {&js, FormatJSON}} {
+		logger, err := New(l.w, Options{Verbosity: 2, Format: l.format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger.Log(t.Context(), LevelTrace, "API request")
+	}
+
+	if got, want := text.String(), "TRACE запрос к API\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !strings.Contains(js.String(), `"msg":"API request"`) {
+		t.Errorf("JSON message must not be translated: %q", js.String())
+	}
+}
@@ -0,0 +1,216 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// redacted заменяет секреты в сохраненных запросах
+const redacted = "[REDACTED]"
+
+// secretQuery - параметры адреса, в которых передаются ключи API
+var secretQuery = []string{"key", "api_key", "api-key", "token", "access_token"}
+
+// Transport возвращает транспорт HTTP, который выводит сведения о каждом запросе в logger
+// на уровне LevelTrace, а если dir не пуст, записывает запрос и ответ на него в отдельный
+// файл JSON в каталоге dir. Ключи API в заголовках, адресе и телах заменяются на [REDACTED]
+func Transport(base http.RoundTripper, logger *slog.Logger, dir string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, logger: logger, dir: dir}
+}
+
+type transport struct {
+	base   http.RoundTripper
+	logger *slog.Logger
+	dir    string
+	seq    atomic.Int64
+}
+
+// exchange - запрос к API и ответ на него, сохраненные в каталоге отладки
+type exchange struct {
+	Time     time.Time `json:"time"`
+	Duration string    `json:"duration"`
+	Request  message   `json:"request"`
+	Response *message  `json:"response,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// message - запрос или ответ HTTP
+type message struct {
+	Method string          `json:"method,omitempty"`
+	URL    string          `json:"url,omitempty"`
+	Status int             `json:"status,omitempty"`
+	Header http.Header     `json:"header"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	n := t.seq.Add(1)
+
+	var reqBody []byte
+	if t.dir != "" && req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	duration := time.Since(start)
+
+	secrets := secretValues(req)
+	attrs := []any{"method", req.Method, "url", redact(redactURL(req.URL), secrets), "duration", duration.Round(time.Millisecond)}
+	if resp != nil {
+		attrs = append(attrs, "status", resp.StatusCode)
+	}
+	if err != nil {
+		attrs = append(attrs, "err", redact(err.Error(), secrets))
+	}
+	t.logger.Log(req.Context(), LevelTrace, "API request", attrs...)
+
+	if t.dir == "" {
+		return resp, err
+	}
+
+	ex := &exchange{
+		Time:     start,
+		Duration: duration.String(),
+		Request: message{
+			Method: req.Method,
+			URL:    redact(redactURL(req.URL), secrets),
+			Header: redactHeader(req.Header),
+			Body:   body(reqBody, secrets),
+		},
+	}
+	if err != nil {
+		ex.Error = redact(err.Error(), secrets)
+	}
+	if resp != nil {
+		respBody, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+		if readErr != nil {
+			ex.Error = readErr.Error()
+		}
+		ex.Response = &message{Status: resp.StatusCode, Header: redactHeader(resp.Header), Body: body(respBody, secrets)}
+	}
+
+	if werr := t.write(start, n, ex); werr != nil {
+		t.logger.Warn("error writing the request to the debug directory", "dir", t.dir, "err", werr)
+	}
+	return resp, err
+}
+
+// write записывает запрос и ответ в файл с временем и порядковым номером запроса в имени
+func (t *transport) write(start time.Time, n int64, ex *exchange) error {
+	if err := os.MkdirAll(t.dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(ex, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%04d.json", start.Format("20060102-150405.000"), n)
+	return os.WriteFile(filepath.Join(t.dir, name), append(data, '\n'), 0600)
+}
+
+// sensitive проверяет, может ли заголовок содержать секрет
+func sensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"authorization", "key", "token", "secret", "cookie"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretValues возвращает значения секретных заголовков и параметров запроса
+func secretValues(req *http.Request) []string {
+	var secrets []string
+	for name, values := range req.Header {
+		if !sensitive(name) {
+			continue
+		}
+		for _, v := range values {
+			if len(v) > len("Bearer ") && strings.EqualFold(v[:len("Bearer ")], "Bearer ") {
+				v = v[len("Bearer "):]
+			}
+			if v != "" {
+				secrets = append(secrets, v)
+			}
+		}
+	}
+	query := req.URL.Query()
+	for _, name := range secretQuery {
+		if v := query.Get(name); v != "" {
+			secrets = append(secrets, v)
+		}
+	}
+	return secrets
+}
+
+// redactHeader возвращает копию заголовков, в которой значения секретных заголовков скрыты
+func redactHeader(h http.Header) http.Header {
+	res := h.Clone()
+	for name := range res {
+		if sensitive(name) {
+			res[name] = []string{redacted}
+		}
+	}
+	return res
+}
+
+// redactURL возвращает адрес, в котором скрыты значения параметров с ключами API
+func redactURL(u *url.URL) string {
+	c := *u
+	query := c.Query()
+	changed := false
+	for _, name := range secretQuery {
+		if query.Has(name) {
+			query.Set(name, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		c.RawQuery = query.Encode()
+	}
+	c.User = nil
+	return c.String()
+}
+
+// redact заменяет секреты в строке
+func redact(s string, secrets []string) string {
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+// body возвращает тело запроса или ответа без секретов: JSON как есть, остальное - строкой JSON
+func body(data []byte, secrets []string) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	s := redact(string(data), secrets)
+	if json.Valid([]byte(s)) {
+		return json.RawMessage(s)
+	}
+	quoted, _ := json.Marshal(s)
+	return quoted
+}
@@ -0,0 +1,82 @@
+package lsp
+
+import "strings"
+
+// lineEdits возвращает изменения, превращающие text в code. Каждое изменение заменяет
+// непрерывный блок строк, отличающихся в text и code, соответствующим блоком из code
+func lineEdits(text, code string) []TextEdit {
+	a, b := splitLines(text), splitLines(code)
+
+	// Общие строки в начале и в конце не участвуют в поиске подпоследовательности
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] - длина наибольшей общей подпоследовательности x[i:] и y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	edits := []TextEdit{}
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		if i < len(x) && j < len(y) && x[i] == y[j] {
+			i, j = i+1, j+1
+			continue
+		}
+
+		// Блок отличающихся строк продолжается до следующей общей строки
+		si, sj := i, j
+		for i < len(x) || j < len(y) {
+			if i < len(x) && j < len(y) && x[i] == y[j] {
+				break
+			}
+			if j >= len(y) || i < len(x) && lcs[i+1][j] >= lcs[i][j+1] {
+				i++
+			} else {
+				j++
+			}
+		}
+
+		edits = append(edits, TextEdit{
+			Range:   Range{Start: linePosition(text, a, prefix+si), End: linePosition(text, a, prefix+i)},
+			NewText: strings.Join(y[sj:j], ""),
+		})
+	}
+
+	return edits
+}
+
+// splitLines разбивает текст на строки, сохраняя символы перевода строки
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// linePosition возвращает позицию начала строки line текста text, разбитого на строки lines.
+// Для строки за последней возвращается конец текста
+func linePosition(text string, lines []string, line int) Position {
+	if line >= len(lines) {
+		return endPosition(text)
+	}
+	return Position{Line: line}
+}
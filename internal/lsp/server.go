@@ -0,0 +1,536 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/seelentov/aifmt/internal/entity"
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// FormatFunc форматирует код документа path на указанном языке и возвращает новый код и список
+// изменений. path пуст, если документ не сохранен в файл. Если start больше нуля, форматируются
+// только строки с start по end (нумерация с 1, включительно), а остальной код передается ИИ
+// в качестве контекста и не изменяется. Контекст отменяется по запросу клиента $/cancelRequest
+type FormatFunc func(ctx context.Context, path, content, language string, start, end int) (string, []*entity.Update, error)
+
+// result - закешированный результат форматирования документа
+type result struct {
+	input string
+	code  string
+	upds  []*entity.Update
+}
+
+// document - открытый в редакторе документ
+type document struct {
+	uri      string
+	path     string
+	language string
+	text     string
+	cached   *result
+}
+
+// Server - сервер Language Server Protocol, работающий через потоки ввода-вывода
+type Server struct {
+	format      FormatFunc
+	diagnostics bool
+
+	in    *bufio.Reader
+	out   io.Writer
+	outMu sync.Mutex
+
+	docsMu sync.Mutex
+	docs   map[string]*document
+
+	// pending - функции отмены выполняющихся запросов по идентификатору запроса
+	pendingMu sync.Mutex
+	pending   map[string]context.CancelFunc
+
+	wg sync.WaitGroup
+}
+
+// NewServer создает сервер. Если diagnostics установлен, при открытии и сохранении
+// документа выполняется проверка кода ИИ и публикуются диагностики
+func NewServer(in io.Reader, out io.Writer, format FormatFunc, diagnostics bool) *Server {
+	return &Server{
+		format:      format,
+		diagnostics: diagnostics,
+		in:          bufio.NewReader(in),
+		out:         out,
+		docs:        make(map[string]*document),
+		pending:     make(map[string]context.CancelFunc),
+	}
+}
+
+// Run обрабатывает сообщения клиента до получения уведомления exit или закрытия входного потока
+func (s *Server) Run() error {
+	defer s.wg.Wait()
+
+	for {
+		body, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var msg rpcMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			s.reply(nil, nil, &rpcError{Code: codeParseError, Message: err.Error()})
+			continue
+		}
+
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		s.handle(&msg)
+	}
+}
+
+// read читает одно сообщение с заголовком Content-Length
+func (s *Server) read() ([]byte, error) {
+	header, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, i18n.Errorf("error reading the header: %w", err)
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, i18n.Errorf("invalid Content-Length header: %w", err)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, i18n.Errorf("error reading the message: %w", err)
+	}
+
+	return body, nil
+}
+
+// write отправляет сообщение клиенту
+func (s *Server) write(v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(body))
+	s.out.Write(body)
+}
+
+// reply отправляет ответ на запрос
+func (s *Server) reply(id *json.RawMessage, res interface{}, rpcErr *rpcError) {
+	resp := &rpcResponse{JSONRPC: "2.0", ID: id, Error: rpcErr}
+	if rpcErr == nil {
+		raw, err := json.Marshal(res)
+		if err != nil {
+			resp.Error = &rpcError{Code: codeInternalError, Message: err.Error()}
+		} else {
+			resp.Result = raw
+		}
+	}
+	s.write(resp)
+}
+
+// notify отправляет уведомление клиенту
+func (s *Server) notify(method string, params interface{}) {
+	s.write(&rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// handle обрабатывает сообщение. Уведомления об изменении документов обрабатываются синхронно,
+// чтобы сохранить порядок, а запросы к ИИ выполняются в отдельных горутинах
+func (s *Server) handle(msg *rpcMessage) {
+	switch msg.Method {
+	case "initialize":
+		s.reply(msg.ID, map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": map[string]interface{}{
+					"openClose": true,
+					"change":    1,
+					"save":      true,
+				},
+				"documentFormattingProvider":      true,
+				"documentRangeFormattingProvider": true,
+				"codeActionProvider":              true,
+			},
+			"serverInfo": map[string]string{"name": "aifmt"},
+		}, nil)
+
+	case "shutdown":
+		s.reply(msg.ID, nil, nil)
+
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if json.Unmarshal(msg.Params, &p) != nil {
+			return
+		}
+		doc := &document{uri: p.TextDocument.URI, path: documentPath(p.TextDocument.URI), language: p.TextDocument.LanguageID, text: p.TextDocument.Text}
+		s.docsMu.Lock()
+		s.docs[doc.uri] = doc
+		s.docsMu.Unlock()
+		s.review(doc.uri)
+
+	case "textDocument/didChange":
+		var p didChangeParams
+		if json.Unmarshal(msg.Params, &p) != nil || len(p.ContentChanges) == 0 {
+			return
+		}
+		s.docsMu.Lock()
+		if doc, ok := s.docs[p.TextDocument.URI]; ok {
+			doc.text = p.ContentChanges[len(p.ContentChanges)-1].Text
+		}
+		s.docsMu.Unlock()
+
+	case "textDocument/didSave":
+		var p documentParams
+		if json.Unmarshal(msg.Params, &p) != nil {
+			return
+		}
+		s.review(p.TextDocument.URI)
+
+	case "textDocument/didClose":
+		var p documentParams
+		if json.Unmarshal(msg.Params, &p) != nil {
+			return
+		}
+		s.docsMu.Lock()
+		delete(s.docs, p.TextDocument.URI)
+		s.docsMu.Unlock()
+		s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+
+	case "textDocument/formatting":
+		var p documentParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			s.reply(msg.ID, nil, &rpcError{Code: codeParseError, Message: err.Error()})
+			return
+		}
+		s.async(msg.ID, func(ctx context.Context) (interface{}, error) {
+			return s.formatting(ctx, p.TextDocument.URI)
+		})
+
+	case "textDocument/rangeFormatting":
+		var p rangeParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			s.reply(msg.ID, nil, &rpcError{Code: codeParseError, Message: err.Error()})
+			return
+		}
+		s.async(msg.ID, func(ctx context.Context) (interface{}, error) {
+			return s.rangeFormatting(ctx, p.TextDocument.URI, p.Range)
+		})
+
+	case "textDocument/codeAction":
+		var p codeActionParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			s.reply(msg.ID, nil, &rpcError{Code: codeParseError, Message: err.Error()})
+			return
+		}
+		s.async(msg.ID, func(ctx context.Context) (interface{}, error) {
+			return s.codeActions(p.TextDocument.URI, p.Range)
+		})
+
+	case "$/cancelRequest":
+		var p cancelParams
+		if json.Unmarshal(msg.Params, &p) != nil {
+			return
+		}
+		s.pendingMu.Lock()
+		if cancel, ok := s.pending[requestKey(p.ID)]; ok {
+			cancel()
+		}
+		s.pendingMu.Unlock()
+
+	default:
+		// На неизвестные запросы отвечаем ошибкой, неизвестные уведомления игнорируем
+		if msg.ID != nil {
+			s.reply(msg.ID, nil, &rpcError{Code: codeMethodNotFound, Message: i18n.Sprintf("method not supported: %s", msg.Method)})
+		}
+	}
+}
+
+// async выполняет обработчик запроса в отдельной горутине и отправляет ответ. Контекст
+// обработчика отменяется уведомлением $/cancelRequest, на отмененный запрос отвечает
+// ошибкой RequestCancelled
+func (s *Server) async(id *json.RawMessage, fn func(ctx context.Context) (interface{}, error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	key := requestKey(*id)
+	s.pendingMu.Lock()
+	s.pending[key] = cancel
+	s.pendingMu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.pendingMu.Lock()
+			delete(s.pending, key)
+			s.pendingMu.Unlock()
+			cancel()
+		}()
+
+		res, err := fn(ctx)
+		if ctx.Err() != nil {
+			s.reply(id, nil, &rpcError{Code: codeRequestCancelled, Message: i18n.Sprintf("request cancelled")})
+			return
+		}
+		if err != nil {
+			s.reply(id, nil, &rpcError{Code: codeInternalError, Message: err.Error()})
+			return
+		}
+		s.reply(id, res, nil)
+	}()
+}
+
+// requestKey возвращает ключ идентификатора запроса. Идентификатор сравнивается по JSON
+// без пробелов, так как может быть числом или строкой
+func requestKey(id json.RawMessage) string {
+	var buf bytes.Buffer
+	if json.Compact(&buf, id) != nil {
+		return string(id)
+	}
+	return buf.String()
+}
+
+// documentPath возвращает путь к файлу документа с URI вида file:///path или пустую строку,
+// если документ не является файлом
+func documentPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" || u.Path == "" {
+		return ""
+	}
+	path := u.Path
+	// file:///C:/dir/file на Windows
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path)
+}
+
+// snapshot возвращает копию текущего состояния документа
+func (s *Server) snapshot(uri string) (document, error) {
+	s.docsMu.Lock()
+	defer s.docsMu.Unlock()
+
+	doc, ok := s.docs[uri]
+	if !ok {
+		return document{}, i18n.Errorf("document is not open: %s", uri)
+	}
+	return *doc, nil
+}
+
+// formatDocument форматирует текст документа, используя кеш, если текст не изменился
+func (s *Server) formatDocument(ctx context.Context, doc document) (*result, error) {
+	if doc.cached != nil && doc.cached.input == doc.text {
+		return doc.cached, nil
+	}
+
+	code, upds, err := s.format(ctx, doc.path, doc.text, doc.language, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &result{input: doc.text, code: code, upds: upds}
+
+	s.docsMu.Lock()
+	if cur, ok := s.docs[doc.uri]; ok {
+		cur.cached = res
+	}
+	s.docsMu.Unlock()
+
+	return res, nil
+}
+
+func (s *Server) formatting(ctx context.Context, uri string) ([]TextEdit, error) {
+	doc, err := s.snapshot(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.formatDocument(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.code == "" || res.code == doc.text {
+		return []TextEdit{}, nil
+	}
+
+	return []TextEdit{{Range: fullRange(doc.text), NewText: res.code}}, nil
+}
+
+// rangeFormatting форматирует строки, которые затрагивает выделение, передавая ИИ весь документ
+// в качестве контекста, как флаг --lines команды fmt
+func (s *Server) rangeFormatting(ctx context.Context, uri string, rng Range) ([]TextEdit, error) {
+	doc, err := s.snapshot(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if offsetAt(doc.text, rng.Start) >= offsetAt(doc.text, rng.End) {
+		return []TextEdit{}, nil
+	}
+
+	// Выделение, которое заканчивается в начале строки, эту строку не включает
+	start, end := rng.Start.Line+1, rng.End.Line+1
+	if rng.End.Character == 0 && end > start {
+		end--
+	}
+	end = min(end, len(splitLines(doc.text)))
+
+	code, _, err := s.format(ctx, doc.path, doc.text, doc.language, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	if code == "" || code == doc.text {
+		return []TextEdit{}, nil
+	}
+
+	return lineEdits(doc.text, code), nil
+}
+
+// codeActions возвращает действия по результату последней проверки документа. Запрос к ИИ
+// не выполняется: редакторы запрашивают действия при каждом перемещении курсора, поэтому
+// если документ изменился после проверки, действий нет до следующей проверки. Исправление
+// диагностики применяет только изменения в строках, на которые она указывает
+func (s *Server) codeActions(uri string, rng Range) ([]CodeAction, error) {
+	doc, err := s.snapshot(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := []CodeAction{}
+	res := doc.cached
+	if res == nil || res.input != doc.text || res.code == "" || res.code == doc.text {
+		return actions, nil
+	}
+
+	edits := lineEdits(doc.text, res.code)
+	for _, diag := range diagnosticsFor(doc.text, res.upds) {
+		// Диагностика без найденного в документе фрагмента относится ко всему файлу
+		if diag.Severity != severityWarning {
+			continue
+		}
+		if diag.Range.End.Line < rng.Start.Line || diag.Range.Start.Line > rng.End.Line {
+			continue
+		}
+
+		own := editsIn(edits, diag.Range)
+		if len(own) == 0 {
+			continue
+		}
+		actions = append(actions, CodeAction{
+			Title:       "aifmt: " + diag.Message,
+			Kind:        "quickfix",
+			Diagnostics: []Diagnostic{diag},
+			Edit:        &WorkspaceEdit{Changes: map[string][]TextEdit{uri: own}},
+		})
+	}
+
+	actions = append(actions, CodeAction{
+		Title: i18n.Sprintf("aifmt: apply all changes"),
+		Kind:  "source.fixAll.aifmt",
+		Edit:  &WorkspaceEdit{Changes: map[string][]TextEdit{uri: {{Range: fullRange(doc.text), NewText: res.code}}}},
+	})
+
+	return actions, nil
+}
+
+// editsIn возвращает изменения, затрагивающие строки диапазона rng
+func editsIn(edits []TextEdit, rng Range) []TextEdit {
+	var res []TextEdit
+	for _, e := range edits {
+		// Вставка строк затрагивает строку, перед которой они вставляются
+		last := max(e.Range.End.Line-1, e.Range.Start.Line)
+		if e.Range.End.Character > 0 {
+			last = e.Range.End.Line
+		}
+		if e.Range.Start.Line <= rng.End.Line && last >= rng.Start.Line {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// review проверяет документ с помощью ИИ и публикует диагностики по предложенным изменениям
+func (s *Server) review(uri string) {
+	if !s.diagnostics {
+		return
+	}
+
+	doc, err := s.snapshot(uri)
+	if err != nil {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		res, err := s.formatDocument(context.Background(), doc)
+		if err != nil {
+			s.notify("window/logMessage", map[string]interface{}{
+				"type":    1,
+				"message": i18n.Sprintf("aifmt: error checking %s: %v", uri, err),
+			})
+			return
+		}
+
+		s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{
+			URI:         uri,
+			Diagnostics: diagnosticsFor(doc.text, res.upds),
+		})
+	}()
+}
+
+// diagnosticsFor строит диагностики по предложенным изменениям. Если фрагмент кода из
+// изменения найден в документе, диагностика указывает на него, иначе - на начало документа
+func diagnosticsFor(text string, upds []*entity.Update) []Diagnostic {
+	diags := []Diagnostic{}
+
+	for _, upd := range upds {
+		if upd.Description == "" {
+			continue
+		}
+
+		diag := Diagnostic{Severity: severityInformation, Source: "aifmt", Message: upd.Description}
+
+		if line := firstLine(upd.Code); line != "" {
+			if i := strings.Index(text, line); i >= 0 {
+				diag.Range = Range{Start: positionAt(text, i), End: positionAt(text, i+len(line))}
+				diag.Severity = severityWarning
+			}
+		}
+
+		diags = append(diags, diag)
+	}
+
+	return diags
+}
+
+// firstLine возвращает первую непустую строку фрагмента без отступов
+func firstLine(code string) string {
+	for _, line := range strings.Split(code, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
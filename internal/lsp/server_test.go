@@ -0,0 +1,266 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/seelentov/aifmt/internal/entity"
+)
+
+func frame(t *testing.T, msgs ...interface{}) io.Reader {
+	var buf bytes.Buffer
+	for _, msg := range msgs {
+		body, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	return &buf
+}
+
+func readAll(t *testing.T, out *bytes.Buffer) map[string]json.RawMessage {
+	results := make(map[string]json.RawMessage)
+	r := bufio.NewReader(out)
+	for {
+		header, err := textproto.NewReader(r).ReadMIMEHeader()
+		if err == io.EOF {
+			return results
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		body := make([]byte, length)
+		if _, err := io.ReadFull(r, body); err != nil {
+			t.Fatal(err)
+		}
+		var resp struct {
+			ID     json.RawMessage `json:"id"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.ID != nil {
+			results[string(resp.ID)] = resp.Result
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	calls := 0
+	var gotPath string
+	format := func(ctx context.Context, path, content, language string, start, end int) (string, []*entity.Update, error) {
+		calls++
+		gotPath = path
+		return "package main\n\nfunc main() {}\n", []*entity.Update{{Code: "func main(){}", Description: "Форматирование"}}, nil
+	}
+
+	uri := "file:///tmp/main.go"
+	in := frame(t,
+		map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": map[string]interface{}{}},
+		map[string]interface{}{"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "languageId": "go", "version": 1, "text": "package main\nfunc main(){}"},
+		}},
+		map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": "textDocument/formatting", "params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri},
+		}},
+		map[string]interface{}{"jsonrpc": "2.0", "id": 3, "method": "unknown/method"},
+		map[string]interface{}{"jsonrpc": "2.0", "method": "exit"},
+	)
+
+	var out bytes.Buffer
+	if err := NewServer(in, &out, format, false).Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	results := readAll(t, &out)
+
+	var edits []TextEdit
+	if err := json.Unmarshal(results["2"], &edits); err != nil {
+		t.Fatalf("unexpected formatting result %s: %v", results["2"], err)
+	}
+	if len(edits) != 1 || edits[0].NewText != "package main\n\nfunc main() {}\n" {
+		t.Fatalf("unexpected edits: %+v", edits)
+	}
+	if edits[0].Range.End != (Position{Line: 1, Character: 13}) {
+		t.Errorf("unexpected edit range: %+v", edits[0].Range)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 format call, got %d", calls)
+	}
+	if gotPath != filepath.FromSlash("/tmp/main.go") {
+		t.Errorf("unexpected document path %q", gotPath)
+	}
+}
+
+func TestCancelRequest(t *testing.T) {
+	started := make(chan struct{})
+	format := func(ctx context.Context, path, content, language string, start, end int) (string, []*entity.Update, error) {
+		close(started)
+		<-ctx.Done()
+		return "", nil, ctx.Err()
+	}
+
+	uri := "file:///tmp/main.go"
+	inR, inW := io.Pipe()
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- NewServer(inR, &out, format, false).Run()
+	}()
+
+	write := func(msgs ...interface{}) {
+		if _, err := io.Copy(inW, frame(t, msgs...)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(
+		map[string]interface{}{"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "languageId": "go", "version": 1, "text": "package main\n"},
+		}},
+		map[string]interface{}{"jsonrpc": "2.0", "id": "fmt", "method": "textDocument/formatting", "params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri},
+		}},
+	)
+	<-started
+	write(
+		map[string]interface{}{"jsonrpc": "2.0", "method": "$/cancelRequest", "params": map[string]interface{}{"id": "fmt"}},
+		map[string]interface{}{"jsonrpc": "2.0", "method": "exit"},
+	)
+
+	if err := <-done; err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"code":-32800`) {
+		t.Errorf("expected RequestCancelled error, got %s", out.String())
+	}
+}
+
+func TestDiagnosticsFor(t *testing.T) {
+	text := "package main\n\nfunc main(){\n}\n"
+	diags := diagnosticsFor(text, []*entity.Update{
+		{Code: "  func main(){", Description: "Добавлен пробел"},
+		{Code: "missing()", Description: "Общее замечание"},
+	})
+
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %d", len(diags))
+	}
+	if diags[0].Range.Start.Line != 2 || diags[0].Severity != severityWarning {
+		t.Errorf("unexpected diagnostic: %+v", diags[0])
+	}
+	if diags[1].Range.Start.Line != 0 || diags[1].Severity != severityInformation {
+		t.Errorf("unexpected diagnostic: %+v", diags[1])
+	}
+}
+
+func TestOffsetAt(t *testing.T) {
+	text := "ab\nпривет\n"
+	if got := offsetAt(text, Position{Line: 1, Character: 2}); got != len("ab\nпр") {
+		t.Errorf("offsetAt = %d", got)
+	}
+	if got := positionAt(text, len("ab\nпр")); got != (Position{Line: 1, Character: 2}) {
+		t.Errorf("positionAt = %+v", got)
+	}
+}
+
+func TestCodeActions(t *testing.T) {
+	calls := 0
+	format := func(ctx context.Context, path, content, language string, start, end int) (string, []*entity.Update, error) {
+		calls++
+		return "", nil, nil
+	}
+	s := NewServer(&bytes.Buffer{}, &bytes.Buffer{}, format, true)
+
+	uri := "file:///tmp/main.go"
+	text := "package main\n\nfunc a(){}\n\nfunc b(){}\n"
+	code := "package main\n\nfunc a() {}\n\nfunc b() {}\n"
+	s.docs[uri] = &document{uri: uri, language: "go", text: text}
+
+	// До проверки документа действий нет и запрос к ИИ не выполняется
+	actions, err := s.codeActions(uri, fullRange(text))
+	if err != nil || len(actions) != 0 || calls != 0 {
+		t.Fatalf("unexpected actions before review: %+v, %v, %d calls", actions, err, calls)
+	}
+
+	s.docs[uri].cached = &result{input: text, code: code, upds: []*entity.Update{
+		{Code: "func a(){}", Description: "пробел в a"},
+		{Code: "func b(){}", Description: "пробел в b"},
+	}}
+	actions, err = s.codeActions(uri, Range{Start: Position{Line: 4}, End: Position{Line: 4}})
+	if err != nil || len(actions) != 2 {
+		t.Fatalf("expected quickfix for b and fix all, got %+v, %v", actions, err)
+	}
+	edits := actions[0].Edit.Changes[uri]
+	if actions[0].Title != "aifmt: пробел в b" || len(edits) != 1 || edits[0].NewText != "func b() {}\n" || edits[0].Range.Start.Line != 4 {
+		t.Errorf("quickfix must contain only its own edit: %+v", actions[0])
+	}
+	if all := actions[1].Edit.Changes[uri]; len(all) != 1 || all[0].NewText != code {
+		t.Errorf("unexpected fix all action: %+v", actions[1])
+	}
+
+	// После изменения документа результат проверки устарел
+	s.docs[uri].text = text + "\n"
+	if actions, _ := s.codeActions(uri, fullRange(text)); len(actions) != 0 || calls != 0 {
+		t.Errorf("stale result must not be used: %+v, %d calls", actions, calls)
+	}
+}
+
+func TestLineEdits(t *testing.T) {
+	tests := []struct {
+		text, code string
+		want       []TextEdit
+	}{
+		{"a\nb\nc\n", "a\nB\nc\n", []TextEdit{{Range: Range{Start: Position{Line: 1}, End: Position{Line: 2}}, NewText: "B\n"}}},
+		{"a\nc\n", "a\nb\nc\n", []TextEdit{{Range: Range{Start: Position{Line: 1}, End: Position{Line: 1}}, NewText: "b\n"}}},
+		{"a\nb", "a\nb\n", []TextEdit{{Range: Range{Start: Position{Line: 1}, End: Position{Line: 1, Character: 1}}, NewText: "b\n"}}},
+		{"a\nb\nc\nd\n", "A\nb\nc\n", []TextEdit{
+			{Range: Range{Start: Position{Line: 0}, End: Position{Line: 1}}, NewText: "A\n"},
+			{Range: Range{Start: Position{Line: 3}, End: Position{Line: 4}}, NewText: ""},
+		}},
+	}
+	for _, tt := range tests {
+		got := lineEdits(tt.text, tt.code)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lineEdits(%q, %q) = %+v, want %+v", tt.text, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestRangeFormatting(t *testing.T) {
+	text := "package main\n\nfunc a(){}\n\nfunc b(){}\n"
+	var gotContent string
+	var gotStart, gotEnd int
+	format := func(ctx context.Context, path, content, language string, start, end int) (string, []*entity.Update, error) {
+		gotContent, gotStart, gotEnd = content, start, end
+		return "package main\n\nfunc a() {}\n\nfunc b(){}\n", nil, nil
+	}
+	s := NewServer(&bytes.Buffer{}, &bytes.Buffer{}, format, false)
+
+	uri := "file:///tmp/main.go"
+	s.docs[uri] = &document{uri: uri, language: "go", text: text}
+
+	edits, err := s.rangeFormatting(context.Background(), uri, Range{Start: Position{Line: 2}, End: Position{Line: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotContent != text || gotStart != 3 || gotEnd != 3 {
+		t.Errorf("whole document must be sent with lines 3:3, got lines %d:%d", gotStart, gotEnd)
+	}
+	want := []TextEdit{{Range: Range{Start: Position{Line: 2}, End: Position{Line: 3}}, NewText: "func a() {}\n"}}
+	if !reflect.DeepEqual(edits, want) {
+		t.Errorf("unexpected edits: %+v", edits)
+	}
+}
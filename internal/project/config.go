@@ -0,0 +1,234 @@
+package project
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName - имя файла конфигурации проекта
+const FileName = ".aifmt.yaml"
+
+// Режимы работы
+const (
+	ModeFormat = "format" // Форматирование с записью результата в файл
+	ModeReview = "review" // Только вывод предложенных изменений без записи
+)
+
+// Settings - параметры форматирования, которые можно задать в конфигурации проекта
+type Settings struct {
+	Language string   `yaml:"language,omitempty"` // Язык программирования
+	Model    string   `yaml:"model,omitempty"`    // Модель ИИ
+	Mode     string   `yaml:"mode,omitempty"`     // Режим работы: format или review
+	Prompt   string   `yaml:"prompt,omitempty"`   // Дополнительные инструкции для ИИ
+	Comments *bool    `yaml:"comments,omitempty"` // Добавлять ли в код комментарии
+	Style    *bool    `yaml:"style,omitempty"`    // Добавлять ли в запрос правила стиля из конфигурации проекта
+	Ignore   []string `yaml:"ignore,omitempty"`   // Шаблоны файлов, которые не нужно обрабатывать
+
+	// VerifyCmd - команда проверки проекта после записи файлов, выполняется в каталоге конфигурации
+	VerifyCmd string `yaml:"verify_cmd,omitempty"`
+
+	// Formatter - форматер кода до и после ИИ: команда оболочки, builtin или off
+	Formatter string `yaml:"formatter,omitempty"`
+}
+
+// Override - настройки, применяемые к файлам, подходящим под шаблоны
+type Override struct {
+	Files    []string `yaml:"files"` // Шаблоны файлов относительно каталога конфигурации
+	Settings `yaml:",inline"`
+}
+
+// Config - конфигурация проекта из файла .aifmt.yaml
+type Config struct {
+	Path      string `yaml:"-"` // Путь к файлу конфигурации
+	Settings  `yaml:",inline"`
+	Overrides []Override `yaml:"overrides,omitempty"` // Настройки для отдельных путей, применяются по порядку
+}
+
+// Find ищет файл .aifmt.yaml, поднимаясь по каталогам от каталога указанного файла.
+// Если файл не найден, возвращает nil без ошибки
+func Find(file string) (*Config, error) {
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return nil, i18n.Errorf("error getting the absolute path of %s: %w", file, err)
+	}
+
+	for {
+		candidate := filepath.Join(dir, FileName)
+		if _, err := os.Stat(candidate); err == nil {
+			return Load(candidate)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, i18n.Errorf("error checking file %s: %w", candidate, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// Load читает конфигурацию проекта из файла
+func Load(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, i18n.Errorf("error reading project configuration %s: %w", file, err)
+	}
+
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, i18n.Errorf("error parsing project configuration %s: %w", file, err)
+	}
+
+	if cfg.Path, err = filepath.Abs(file); err != nil {
+		return nil, i18n.Errorf("error getting the absolute path of %s: %w", file, err)
+	}
+
+	for _, s := range append([]Settings{cfg.Settings}, overridesSettings(cfg.Overrides)...) {
+		if s.Mode != "" && s.Mode != ModeFormat && s.Mode != ModeReview {
+			return nil, i18n.Errorf("invalid mode %q in %s, allowed values: %s, %s", s.Mode, file, ModeFormat, ModeReview)
+		}
+	}
+
+	return cfg, nil
+}
+
+// Root возвращает каталог, в котором находится конфигурация
+func (c *Config) Root() string {
+	return filepath.Dir(c.Path)
+}
+
+// Resolve возвращает настройки для файла: базовые настройки проекта, поверх которых
+// по порядку применяются все подходящие переопределения. Шаблоны ignore объединяются
+func (c *Config) Resolve(file string) Settings {
+	res := c.Settings
+	res.Ignore = append([]string(nil), c.Ignore...)
+
+	rel, ok := c.rel(file)
+	if !ok {
+		return res
+	}
+
+	for _, o := range c.Overrides {
+		if !matchAny(o.Files, rel) {
+			continue
+		}
+		if o.Language != "" {
+			res.Language = o.Language
+		}
+		if o.Model != "" {
+			res.Model = o.Model
+		}
+		if o.Mode != "" {
+			res.Mode = o.Mode
+		}
+		if o.Prompt != "" {
+			res.Prompt = o.Prompt
+		}
+		if o.Comments != nil {
+			res.Comments = o.Comments
+		}
+		if o.Style != nil {
+			res.Style = o.Style
+		}
+		if o.VerifyCmd != "" {
+			res.VerifyCmd = o.VerifyCmd
+		}
+		if o.Formatter != "" {
+			res.Formatter = o.Formatter
+		}
+		res.Ignore = append(res.Ignore, o.Ignore...)
+	}
+
+	return res
+}
+
+// Ignored проверяет, исключен ли файл из обработки шаблонами ignore
+func (c *Config) Ignored(file string) bool {
+	rel, ok := c.rel(file)
+	if !ok {
+		return false
+	}
+	return matchAny(c.Resolve(file).Ignore, rel)
+}
+
+// rel возвращает путь файла относительно каталога конфигурации в формате со слешами
+func (c *Config) rel(file string) (string, bool) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(c.Root(), abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return filepath.ToSlash(rel), true
+}
+
+func overridesSettings(overrides []Override) []Settings {
+	res := make([]Settings, 0, len(overrides))
+	for _, o := range overrides {
+		res = append(res, o.Settings)
+	}
+	return res
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match проверяет, подходит ли путь со слешами под шаблон. Шаблон без слеша сравнивается
+// с именем файла на любой глубине, "**" соответствует любому количеству каталогов,
+// а шаблон, заканчивающийся на "/", соответствует всему содержимому каталога
+func Match(pattern, rel string) bool {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+
+		pattern, parts = pattern[1:], parts[1:]
+	}
+
+	return len(parts) == 0
+}
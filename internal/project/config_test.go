@@ -0,0 +1,82 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"*_test.go", "pkg/api/client_test.go", true},
+		{"*_test.go", "pkg/api/client.go", false},
+		{"internal/**/*.go", "internal/service/format.go", true},
+		{"internal/**/*.go", "internal/format.go", true},
+		{"internal/**/*.go", "cmd/fmt.go", false},
+		{"vendor/", "vendor/github.com/x/y.go", true},
+		{"./cmd/*.go", "cmd/fmt.go", true},
+	} {
+		if got := Match(tc.pattern, tc.path); got != tc.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestFindAndResolve(t *testing.T) {
+	root := t.TempDir()
+	config := `model: strong/model
+mode: format
+verify_cmd: go build ./...
+ignore:
+  - generated/
+overrides:
+  - files: ["*_test.go"]
+    model: cheap/model
+    comments: false
+  - files: ["legacy/**"]
+    mode: review
+    prompt: Сохраняй совместимость
+    verify_cmd: go test ./legacy/...
+`
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "legacy", "db"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Find(filepath.Join(root, "legacy", "db", "conn_test.go"))
+	if err != nil || cfg == nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+
+	s := cfg.Resolve(filepath.Join(root, "legacy", "db", "conn_test.go"))
+	if s.Model != "cheap/model" || s.Mode != ModeReview || s.Prompt == "" || s.Comments == nil || *s.Comments || s.VerifyCmd != "go test ./legacy/..." {
+		t.Errorf("unexpected settings: %+v", s)
+	}
+
+	s = cfg.Resolve(filepath.Join(root, "main.go"))
+	if s.Model != "strong/model" || s.Mode != ModeFormat || s.VerifyCmd != "go build ./..." {
+		t.Errorf("unexpected settings: %+v", s)
+	}
+
+	if !cfg.Ignored(filepath.Join(root, "generated", "api.go")) {
+		t.Error("expected generated file to be ignored")
+	}
+	if cfg.Ignored(filepath.Join(root, "main.go")) {
+		t.Error("expected main.go not to be ignored")
+	}
+}
+
+func TestLoadInvalidMode(t *testing.T) {
+	file := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(file, []byte("mode: rewrite\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(file); err == nil {
+		t.Error("expected error for invalid mode")
+	}
+}
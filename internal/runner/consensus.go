@@ -0,0 +1,149 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/seelentov/aifmt/internal/consensus"
+	"github.com/seelentov/aifmt/internal/entity"
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/service"
+)
+
+// formatConsensus параллельно запрашивает несколько вариантов форматирования, отбрасывает
+// варианты с ошибками синтаксиса и выбирает результат стратегией из флага --pick. Каждый
+// вариант запрашивается с повторными попытками и переходом к резервным моделям цепочки
+func (r *Runner) formatConsensus(ctx context.Context, content, file string, opts *Options, format func(model string) (string, []*entity.Update, error)) (string, *entity.FileResult, error) {
+	models := opts.CandidateModels
+	if len(models) == 0 {
+		models = opts.Models[:1]
+	}
+
+	candidates := make([]*consensus.Candidate, 0, len(models)*opts.Samples)
+	for _, model := range models {
+		for i := 0; i < opts.Samples; i++ {
+			candidates = append(candidates, &consensus.Candidate{Model: model})
+		}
+	}
+
+	r.log().Info("requesting candidates", "file", file, "candidates", len(candidates))
+
+	var wg sync.WaitGroup
+	for _, c := range candidates {
+		wg.Add(1)
+		go func(c *consensus.Candidate) {
+			defer wg.Done()
+			model, err := r.formatRetry(ctx, file, candidateChain(c.Model, opts.Models), opts, func(model string) error {
+				var err error
+				c.Code, c.Updates, err = formatNonEmpty(format, model)
+				return err
+			})
+			if err != nil {
+				c.Code, c.Updates, c.Err = "", nil, err
+				return
+			}
+			c.Model = model
+		}(c)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return "", nil, context.Cause(ctx)
+	}
+
+	consensus.Analyze(opts.Language, content, candidates)
+
+	res := &entity.FileResult{Path: file, Strategy: opts.Pick}
+
+	best := -1
+	switch opts.Pick {
+	case consensus.StrategySmallestDiff:
+		best = consensus.SmallestDiff(candidates)
+	case consensus.StrategyJudge:
+		best, res.Reason = r.judge(ctx, content, file, opts, candidates)
+	default:
+		best = consensus.Agreement(candidates)
+	}
+
+	if ctx.Err() != nil {
+		return "", nil, context.Cause(ctx)
+	}
+
+	for i, c := range candidates {
+		rc := &entity.Candidate{Model: c.Model, Valid: c.Valid(), Group: c.Group, Changed: c.Changed, Selected: i == best}
+		if c.Err != nil {
+			rc.Error = c.Err.Error()
+			r.log().Debug("candidate discarded", "file", file, "candidate", i+1, "model", c.Model, "err", c.Err)
+		} else {
+			r.log().Debug("candidate", "file", file, "candidate", i+1, "model", c.Model, "group", c.Group, "changed_lines", c.Changed)
+		}
+		res.Candidates = append(res.Candidates, rc)
+	}
+
+	if best < 0 {
+		return "", nil, i18n.Errorf("Failed to format file %s: no valid candidates", file)
+	}
+
+	chosen := candidates[best]
+	r.log().Info("candidate chosen", "file", file, "candidate", best+1, "model", chosen.Model)
+
+	r.printUpdates(file, opts.Language, chosen.Updates)
+
+	res.Model = chosen.Model
+	res.Updates = chosen.Updates
+
+	return chosen.Code, res, nil
+}
+
+// candidateChain возвращает цепочку моделей для варианта модели model: сама модель, а затем
+// резервные модели цепочки models
+func candidateChain(model string, models []string) []string {
+	chain := []string{model}
+	for _, m := range models {
+		if m != model {
+			chain = append(chain, m)
+		}
+	}
+	return chain
+}
+
+// judge выбирает вариант моделью-судьей. Если корректные варианты совпадают или судья
+// не смог выбрать вариант, используется стратегия agreement
+func (r *Runner) judge(ctx context.Context, content, file string, opts *Options, candidates []*consensus.Candidate) (int, string) {
+	var valid []int
+	var codes []string
+	groups := make(map[int]bool)
+	for i, c := range candidates {
+		if c.Valid() && !groups[c.Group] {
+			groups[c.Group] = true
+			valid = append(valid, i)
+			codes = append(codes, c.Code)
+		}
+	}
+
+	if len(valid) < 2 {
+		return consensus.Agreement(candidates), ""
+	}
+
+	model := opts.JudgeModel
+	if model == "" {
+		model = opts.Models[0]
+	}
+
+	choice, reason, err := service.Judge(ctx, content, codes, &service.Options{
+		Language:       opts.Language,
+		Model:          model,
+		Client:         r.Provider,
+		Prompt:         opts.Prompt,
+		PromptLanguage: opts.PromptLanguage,
+	})
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			r.log().Warn("judge model error, falling back to agreement", "file", file, "model", model, "err", err)
+		}
+		return consensus.Agreement(candidates), ""
+	}
+
+	return valid[choice], reason
+}
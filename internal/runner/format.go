@@ -0,0 +1,326 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/seelentov/aifmt/internal/comments"
+	"github.com/seelentov/aifmt/internal/entity"
+	"github.com/seelentov/aifmt/internal/formatter"
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/service"
+	"github.com/seelentov/aifmt/internal/syntax"
+	"github.com/seelentov/aifmt/pkg/api"
+)
+
+// errEmptyResponse возвращается, если модель вернула пустой код
+var errEmptyResponse = i18n.Error("empty AI response")
+
+// ErrRejected возвращается, если код из ответа модели не прошел проверку Options.Validate
+var ErrRejected = i18n.Error("result rejected by a check")
+
+// Format форматирует содержимое файла с повторными попытками при ошибках и пустом ответе,
+// выводит предложенные изменения и возвращает новый код. Если задана цепочка моделей, при ошибке
+// выполняется переход к следующей модели, а повторная попытка начинается с первой модели цепочки.
+// При отмене контекста запрос к ИИ прерывается и повторные попытки не выполняются.
+// В режиме Options.Doc модель документирует код, а результат, в котором изменился не только комментарий, отклоняется.
+// Исходный код и каждый результат ИИ обрабатываются форматером Options.Formatter, результат,
+// который форматер не смог обработать или в котором потеряны исходные комментарии, отклоняется.
+// Файл не читается и не записывается, file используется в сообщениях и отчете
+func (r *Runner) Format(ctx context.Context, content, file string, opts *Options) (string, *entity.FileResult, error) {
+	var u string
+
+	if len(opts.Models) == 0 {
+		return "", nil, i18n.Errorf("Error: no AI model specified for %s", file)
+	}
+
+	start, end, err := selectedLines(content, opts)
+	if err != nil {
+		return "", nil, i18n.Errorf("Error selecting the line range in %s: %v", file, err)
+	}
+
+	fmtr, err := codeFormatter(opts, start)
+	if err != nil {
+		return "", nil, i18n.Errorf("Formatter error for %s: %v", file, err)
+	}
+	content = Preformat(ctx, content, file, opts)
+
+	sopts := &service.Options{
+		Language:         opts.Language,
+		Client:           r.Provider,
+		Comments:         opts.Comments,
+		CommentsLanguage: comments.Language(opts.CommentsLanguage, opts.Language, content, opts.PromptLanguage),
+		Context:          r.Context,
+		Prompt:           opts.Prompt,
+		PromptLanguage:   opts.PromptLanguage,
+	}
+
+	// Функция проверки результата ИИ: код обрабатывается форматером, а затем проверяется, что
+	// при документировании изменены только комментарии, исходные комментарии сохранены и код
+	// проходит проверки opts.Validate. Возвращает код после форматера
+	validate := func(code string) (string, error) {
+		if fmtr != nil {
+			var err error
+			if code, err = fmtr.Format(ctx, file, code); err != nil {
+				return "", err
+			}
+		}
+		if opts.Doc != nil {
+			if err := sameCode(opts.Language, content, code); err != nil {
+				return "", err
+			}
+		}
+		// Документирование обновляет комментарии, поэтому их сохранность не проверяется
+		if !opts.AllowCommentChanges && opts.Doc == nil {
+			if err := comments.Check(opts.Language, content, code); err != nil {
+				return "", err
+			}
+		}
+		if opts.Validate != nil {
+			if err := opts.Validate(code); err != nil {
+				return "", err
+			}
+		}
+		return code, nil
+	}
+
+	// Функция для форматирования кода указанной моделью
+	format := func(model string) (string, []*entity.Update, error) {
+		mopts := *sopts
+		mopts.Model = model
+
+		var code string
+		var upds []*entity.Update
+		var err error
+		switch {
+		case opts.Doc != nil:
+			code, upds, err = service.Document(ctx, content, &mopts, opts.Doc)
+		case start > 0:
+			code, upds, err = service.FormatRange(ctx, content, start, end, &mopts)
+		default:
+			code, upds, err = service.FormatCode(ctx, content, &mopts)
+		}
+
+		if err == nil && code != "" {
+			if code, err = validate(code); err != nil {
+				return "", nil, fmt.Errorf("%w: %v", ErrRejected, err)
+			}
+		}
+		return code, upds, err
+	}
+
+	var result *entity.FileResult
+	if opts.Samples > 1 || len(opts.CandidateModels) > 0 {
+		if u, result, err = r.formatConsensus(ctx, content, file, opts, format); err != nil {
+			return "", nil, err
+		}
+	} else if u, result, err = r.formatSingle(ctx, file, opts, format); err != nil {
+		return "", nil, err
+	}
+
+	if opts.Refine > 0 {
+		if u, err = r.refine(ctx, content, file, opts, u, result, start, end, validate); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return u, result, nil
+}
+
+// Dialog возвращает диалог запроса на форматирование файла целиком, который Format отправляет
+// модели model: код обработан форматером, язык комментариев определен. Используется, когда
+// запрос отправляется отдельно, например в пакетном режиме
+func Dialog(ctx context.Context, content, file, model string, opts *Options) []*api.Message {
+	content = Preformat(ctx, content, file, opts)
+	return service.FormatDialog(content, &service.Options{
+		Language:         opts.Language,
+		Model:            model,
+		Comments:         opts.Comments,
+		CommentsLanguage: comments.Language(opts.CommentsLanguage, opts.Language, content, opts.PromptLanguage),
+		Prompt:           opts.Prompt,
+		PromptLanguage:   opts.PromptLanguage,
+	})
+}
+
+// sameCode проверяет, что код отличается от исходного только комментариями и форматированием.
+// Для Go сравниваются синтаксические деревья, для остальных языков - код без комментариев и пробелов.
+// Код на языке, синтаксис комментариев которого неизвестен, не проверяется
+func sameCode(language, original, changed string) error {
+	if strings.EqualFold(language, "go") {
+		return syntax.SameGoCode(original, changed)
+	}
+
+	a, ok := comments.Strip(language, original)
+	if !ok {
+		return nil
+	}
+	if b, _ := comments.Strip(language, changed); a != b {
+		return i18n.Errorf("the code was changed, not only the comments")
+	}
+	return nil
+}
+
+// Preformat возвращает исходный код, обработанный форматером Options.Formatter, чтобы ИИ получал
+// код в каноническом виде. Если форматера нет, выбран диапазон строк или форматер не смог
+// обработать код, код возвращается без изменений
+func Preformat(ctx context.Context, content, file string, opts *Options) string {
+	start, _, err := selectedLines(content, opts)
+	if err != nil {
+		return content
+	}
+	fmtr, err := codeFormatter(opts, start)
+	if err != nil || fmtr == nil {
+		return content
+	}
+
+	code, err := fmtr.Format(ctx, file, content)
+	if err != nil || code == "" {
+		return content
+	}
+	return code
+}
+
+// codeFormatter возвращает форматер для файла. Форматер обрабатывает файл целиком,
+// поэтому при форматировании диапазона строк он не используется
+func codeFormatter(opts *Options, start int) (formatter.Formatter, error) {
+	if start > 0 {
+		return nil, nil
+	}
+	return formatter.New(opts.Language, opts.Formatter)
+}
+
+// formatSingle форматирует код одной моделью с переходом к резервным моделям цепочки и повторными попытками
+func (r *Runner) formatSingle(ctx context.Context, file string, opts *Options, format func(model string) (string, []*entity.Update, error)) (string, *entity.FileResult, error) {
+	var u string
+	var upds []*entity.Update
+
+	model, err := r.formatRetry(ctx, file, opts.Models, opts, func(model string) error {
+		var err error
+		u, upds, err = formatNonEmpty(format, model)
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(opts.Models) > 1 {
+		r.log().Info("file formatted by a fallback model", "file", file, "model", model)
+	}
+
+	r.printUpdates(file, opts.Language, upds)
+
+	return u, &entity.FileResult{Path: file, Model: model, Updates: upds}, nil
+}
+
+// formatNonEmpty форматирует код моделью model и возвращает errEmptyResponse, если модель вернула пустой код
+func formatNonEmpty(format func(model string) (string, []*entity.Update, error), model string) (string, []*entity.Update, error) {
+	u, upds, err := format(model)
+	if err == nil && u == "" {
+		return "", nil, errEmptyResponse
+	}
+	return u, upds, err
+}
+
+// formatRetry вызывает attempt для цепочки моделей models с повторными попытками и возвращает
+// модель, давшую результат. Повторная попытка начинается с первой модели цепочки, с опцией
+// Skip повторные попытки не выполняются, но резервные модели используются
+func (r *Runner) formatRetry(ctx context.Context, file string, models []string, opts *Options, attempt func(model string) error) (string, error) {
+	attempts := opts.MaxRetries + 1
+	if opts.Skip {
+		attempts = 1
+	}
+
+	var model string
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			r.log().Info("retrying formatting", "file", file, "attempt", i, "max_retries", opts.MaxRetries)
+		}
+
+		if model, err = r.tryModels(ctx, file, models, attempt); err == nil {
+			return model, nil
+		}
+		if ctx.Err() != nil {
+			return "", context.Cause(ctx)
+		}
+		if !api.IsRetryable(err) {
+			return "", i18n.Errorf("Failed to format file %s: %w", file, err)
+		}
+
+		// Увеличиваем задержку между попытками
+		if i+1 < attempts {
+			if err := r.sleep(ctx, time.Second*time.Duration(i+1)); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if opts.Skip {
+		return "", i18n.Errorf("File %s skipped: %w", file, err)
+	}
+	return "", i18n.Errorf("Failed to format file %s after %d attempts: %w", file, attempts, err)
+}
+
+// tryModels вызывает attempt для моделей цепочки по порядку до первого успешного результата
+// и возвращает модель, давшую результат. К следующей модели выполняется переход только
+// после временных ошибок, некорректного или пустого ответа. При отмене контекста и
+// неповторяемых ошибках, например неверном API ключе, перебор прекращается
+func (r *Runner) tryModels(ctx context.Context, file string, models []string, attempt func(model string) error) (string, error) {
+	var err error
+	for i, model := range models {
+		if i > 0 {
+			r.log().Info("switching to a fallback model", "file", file, "model", model)
+		}
+
+		if err = attempt(model); err == nil {
+			return model, nil
+		}
+		if ctx.Err() != nil {
+			return "", context.Cause(ctx)
+		}
+
+		r.log().Warn("formatting error", "file", file, "model", model, "err", err)
+		if !api.IsRetryable(err) {
+			return "", err
+		}
+	}
+
+	return "", err
+}
+
+// printUpdates выводит предложенные изменения и указывает в них путь к файлу
+func (r *Runner) printUpdates(file, language string, upds []*entity.Update) {
+	for _, upd := range upds {
+		upd.Path = file
+		r.log().Info("proposed change", "file", file, "description", upd.Description, "code", fmt.Sprintf("```%s\n%s\n```", language, upd.Code))
+	}
+}
+
+// selectedLines возвращает диапазон строк для форматирования, заданный опциями Lines или Func.
+// Если диапазон не задан, возвращаются нули
+func selectedLines(content string, opts *Options) (int, int, error) {
+	if opts.Func != "" {
+		return service.FuncLines(content, opts.Func)
+	}
+	if opts.Lines == "" {
+		return 0, 0, nil
+	}
+
+	from, to, ok := strings.Cut(opts.Lines, ":")
+	start, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil || !ok {
+		return 0, 0, i18n.Errorf("invalid line range %q, expected start:end", opts.Lines)
+	}
+	end, err := strconv.Atoi(strings.TrimSpace(to))
+	if err != nil {
+		return 0, 0, i18n.Errorf("invalid line range %q, expected start:end", opts.Lines)
+	}
+	if start < 1 || end < start {
+		return 0, 0, i18n.Errorf("invalid line range %q: lines are numbered from 1 and the end cannot be before the start", opts.Lines)
+	}
+
+	return start, end, nil
+}
@@ -0,0 +1,113 @@
+package runner
+
+import (
+	"context"
+	"errors"
+
+	"github.com/seelentov/aifmt/internal/entity"
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/service"
+	"github.com/seelentov/aifmt/internal/syntax"
+
+	"golang.org/x/text/message"
+)
+
+// refine проверяет результат форматирования моделью-рецензентом до opts.Refine раундов.
+// Рецензент получает исходный код, предложенный код и результаты проверок и либо принимает
+// изменения, либо возвращает исправленную версию, которая проверяется в следующем раунде.
+// Каждая версия проходит те же проверки validate, что и ответ модели-форматера. Если исходный
+// код корректен, а итоговый содержит синтаксические ошибки или не прошел validate, возвращается
+// последняя корректная версия, а если такой нет - ошибка
+func (r *Runner) refine(ctx context.Context, content, file string, opts *Options, code string, res *entity.FileResult, start, end int, validate func(string) (string, error)) (string, error) {
+	model := opts.ReviewerModel
+	if model == "" {
+		model = res.Model
+	}
+
+	checkSyntax := syntax.Check(opts.Language, content) == nil
+	check := func(code string) (string, string, bool) {
+		checks, valid := checkResults(i18n.Printer(opts.PromptLanguage), opts.Language, code, checkSyntax)
+		accepted, err := validate(code)
+		if err != nil {
+			return "", checks + "; " + err.Error(), false
+		}
+		return accepted, checks, valid
+	}
+
+	lastValid := ""
+	for round := 1; round <= opts.Refine; round++ {
+		accepted, checks, valid := check(code)
+		if valid {
+			lastValid = accepted
+		}
+
+		r.log().Info("reviewing the result", "file", file, "model", model, "round", round, "rounds", opts.Refine)
+
+		review := &entity.Review{Round: round, Model: model, Checks: checks}
+		res.Reviews = append(res.Reviews, review)
+
+		rv, err := service.Review(ctx, content, code, checks, &service.Options{
+			Language:       opts.Language,
+			Model:          model,
+			Client:         r.Provider,
+			Prompt:         opts.Prompt,
+			PromptLanguage: opts.PromptLanguage,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", context.Cause(ctx)
+			}
+			review.Error = err.Error()
+			r.log().Warn("reviewer model error", "file", file, "model", model, "err", err)
+			break
+		}
+
+		review.Approved, review.Issues = rv.Approved, rv.Issues
+		if rv.Approved {
+			r.log().Info("reviewer approved the result", "file", file)
+			break
+		}
+
+		corrected := rv.Code
+		if start > 0 {
+			if corrected, err = service.SpliceRange(content, rv.Code, start, end); err != nil {
+				review.Error = err.Error()
+				r.log().Warn("reviewer fix rejected", "file", file, "err", err)
+				break
+			}
+		}
+
+		r.log().Info("reviewer fixed the result", "file", file, "issues", rv.Issues)
+		r.printUpdates(file, opts.Language, rv.Updates)
+		res.Updates = append(res.Updates, rv.Updates...)
+		code = corrected
+	}
+
+	if accepted, _, valid := check(code); valid {
+		return accepted, nil
+	}
+	if lastValid != "" {
+		r.log().Warn("reviewer fix failed the checks, using the previous version", "file", file)
+		return lastValid, nil
+	}
+
+	return "", i18n.Errorf("The result for %s did not pass the checks", file)
+}
+
+// checkResults проверяет синтаксис предложенного кода и возвращает описание результата для
+// рецензента на языке принтера pr. Второе значение ложно только при найденной синтаксической ошибке
+func checkResults(pr *message.Printer, language, code string, checkSyntax bool) (string, bool) {
+	if !checkSyntax {
+		return pr.Sprintf("the original code does not pass the syntax check, so the check was not performed"), true
+	}
+
+	err := syntax.Check(language, code)
+	switch {
+	case err == nil:
+		return pr.Sprintf("the syntax is correct"), true
+	case errors.Is(err, syntax.ErrUnsupported):
+		return pr.Sprintf("syntax check is not available for %s", language), true
+	default:
+		return pr.Sprintf("syntax error: %v", err), false
+	}
+}
@@ -0,0 +1,142 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seelentov/aifmt/internal/entity"
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// ErrFileTimeout - причина отмены обработки файла по истечении Runner.FileTimeout
+var ErrFileTimeout = i18n.Error("file processing time exceeded (--file-timeout)")
+
+// Состояния обработки файла
+const (
+	StatusCompleted = "completed" // Файл обработан
+	StatusSkipped   = "skipped"   // Файл не обрабатывался: исключен конфигурацией или обработка отменена до начала
+	StatusFailed    = "failed"    // Обработка завершилась ошибкой
+	StatusAborted   = "aborted"   // Обработка прервана сигналом или по истечении времени
+)
+
+// FileStatus - итог обработки файла
+type FileStatus struct {
+	Path     string             // Путь к файлу
+	Status   string             // Состояние обработки
+	Reason   error              // Необязательное пояснение к состоянию
+	Result   *entity.FileResult // Результат форматирования, если ответ модели получен
+	Changed  bool               // Файл перезаписан новым содержимым
+	Duration time.Duration      // Время обработки файла
+}
+
+// Report - итоги обработки файлов. Безопасен для использования из нескольких горутин
+type Report struct {
+	mu    sync.Mutex
+	files map[string]*FileStatus
+}
+
+// Add добавляет файл в итоги с указанным состоянием или изменяет его состояние.
+// reason - необязательное пояснение
+func (r *Report) Add(status, file string, reason error) {
+	r.update(file, func(st *FileStatus) {
+		st.Status, st.Reason = status, reason
+	})
+}
+
+// set добавляет или заменяет итог обработки файла
+func (r *Report) set(st *FileStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.files == nil {
+		r.files = make(map[string]*FileStatus)
+	}
+	r.files[st.Path] = st
+}
+
+// update изменяет итог обработки файла, добавляя его при необходимости
+func (r *Report) update(file string, f func(st *FileStatus)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.files == nil {
+		r.files = make(map[string]*FileStatus)
+	}
+	st, ok := r.files[file]
+	if !ok {
+		st = &FileStatus{Path: file}
+		r.files[file] = st
+	}
+	f(st)
+}
+
+// Files возвращает копии итогов обработки всех файлов, отсортированные по пути
+func (r *Report) Files() []*FileStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := make([]*FileStatus, 0, len(r.files))
+	for _, st := range r.files {
+		c := *st
+		res = append(res, &c)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Path < res[j].Path })
+
+	return res
+}
+
+// Results возвращает результаты форматирования файлов, для которых получен ответ модели
+func (r *Report) Results() []*entity.FileResult {
+	var res []*entity.FileResult
+	for _, st := range r.Files() {
+		if st.Result != nil {
+			res = append(res, st.Result)
+		}
+	}
+	return res
+}
+
+// Count возвращает количество файлов в указанном состоянии
+func (r *Report) Count(status string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, st := range r.files {
+		if st.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Print выводит количество файлов в каждом состоянии и список необработанных файлов
+func (r *Report) Print(w io.Writer) {
+	groups := make(map[string][]string)
+	for _, st := range r.Files() {
+		file := st.Path
+		if st.Reason != nil {
+			file = fmt.Sprintf("%s (%v)", file, st.Reason)
+		}
+		groups[st.Status] = append(groups[st.Status], file)
+	}
+
+	i18n.Fprintf(w, "Total: %d processed, %d skipped, %d failed, %d aborted\n",
+		len(groups[StatusCompleted]), len(groups[StatusSkipped]),
+		len(groups[StatusFailed]), len(groups[StatusAborted]))
+
+	for _, group := range []struct{ status, title string }{
+		{StatusSkipped, "Skipped"},
+		{StatusFailed, "Failed"},
+		{StatusAborted, "Aborted"},
+	} {
+		if files := groups[group.status]; len(files) > 0 {
+			sort.Strings(files)
+			fmt.Fprintf(w, "%s:\n  %s\n", i18n.Sprintf(group.title), strings.Join(files, "\n  "))
+		}
+	}
+}
@@ -0,0 +1,322 @@
+// Package runner выполняет форматирование файлов: запросы к моделям с повторными попытками
+// и резервными моделями, выбор лучшего из нескольких вариантов, проверку результата
+// рецензентом, запись файлов и проверку проекта командой. Файловая система, модель,
+// часы и поток сообщений задаются полями Runner, поэтому конвейер не зависит от
+// командной строки и глобальной конфигурации
+package runner
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seelentov/aifmt/internal/entity"
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/logging"
+	"github.com/seelentov/aifmt/internal/project"
+	"github.com/seelentov/aifmt/internal/service"
+	"github.com/seelentov/aifmt/pkg/api"
+
+	"golang.org/x/text/language"
+)
+
+// Options - параметры форматирования файла
+type Options struct {
+	Language         string   // Язык программирования
+	Models           []string // Цепочка моделей: основная и резервные
+	Mode             string   // Режим работы: project.ModeFormat или project.ModeReview
+	Prompt           string   // Дополнительные инструкции для ИИ
+	Comments         bool     // Добавить в код комментарии
+	CommentsLanguage string   // Язык комментариев
+	Skip             bool     // Не повторять попытки при ошибках
+	MaxRetries       int      // Максимальное количество повторных попыток
+	Lines            string   // Диапазон строк в формате начало:конец
+	Func             string   // Функция Go, которую нужно отформатировать (для методов - Тип.Метод)
+	Samples          int      // Количество вариантов от каждой модели
+	CandidateModels  []string // Модели, от которых запрашиваются варианты
+	Pick             string   // Стратегия выбора варианта
+	JudgeModel       string   // Модель-судья для стратегии judge
+	Refine           int      // Количество раундов проверки результата рецензентом
+	ReviewerModel    string   // Модель-рецензент
+	VerifyCmd        string   // Команда проверки проекта после записи файлов
+	VerifyDir        string   // Каталог, в котором выполняется команда проверки
+
+	// PromptLanguage - язык запросов к ИИ, по умолчанию английский
+	PromptLanguage language.Tag
+
+	// AllowCommentChanges отключает проверку того, что исходные комментарии не удалены и не переведены
+	AllowCommentChanges bool
+
+	// Formatter - детерминированный форматер, применяемый к коду до и после ИИ: команда оболочки,
+	// formatter.Builtin или formatter.Off. По умолчанию используется встроенный форматер языка
+	Formatter string
+
+	// Doc включает режим документирования: вместо форматирования модель добавляет или обновляет
+	// документирующие комментарии. Результат, в котором изменился сам код, отклоняется
+	Doc *service.DocOptions
+
+	// Validate - дополнительная проверка кода из ответа модели. Отклоненный ответ считается
+	// некорректным: выполняется переход к резервной модели или повторная попытка
+	Validate func(code string) error `json:"-"`
+}
+
+// FS - файловая система, из которой читаются и в которую записываются файлы
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm os.FileMode) error
+}
+
+// OSFS - файловая система операционной системы
+type OSFS struct{}
+
+// ReadFile читает файл
+func (OSFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+// WriteFile записывает файл
+func (OSFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// Clock - источник времени для измерения длительности обработки и задержек между попытками
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock - системные часы
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Job - файл для обработки
+type Job struct {
+	Path    string   // Путь к файлу
+	Options *Options // Параметры форматирования файла
+	Ignored bool     // Файл исключен конфигурацией проекта и не обрабатывается
+}
+
+// Runner выполняет форматирование файлов. Пустые поля заменяются значениями по умолчанию,
+// кроме Provider, который обязателен. Runner можно использовать из нескольких горутин
+type Runner struct {
+	Provider      api.Provider      // Модель ИИ
+	FS            FS                // Файловая система, по умолчанию OSFS
+	Clock         Clock             // Часы, по умолчанию системные
+	Logger        *slog.Logger      // Логгер диагностических сообщений, по умолчанию сообщения не выводятся
+	Context       []*entity.File    // Другие файлы проекта для контекста
+	Channels      int               // Количество параллельно обрабатываемых файлов, по умолчанию 1
+	FileTimeout   time.Duration     // Ограничение времени обработки одного файла, 0 - без ограничения
+	VerifyRetries int               // Количество повторных попыток для файлов, нарушивших проверку
+	OnFile        func(*FileStatus) // Вызывается после обработки каждого файла, в том числе из разных горутин
+}
+
+// Run форматирует файлы параллельно, записывает результат в режиме format и проверяет
+// записанные изменения командами VerifyCmd. Отчет возвращается и при отмене контекста:
+// в этом случае ошибка содержит причину отмены, а необработанные файлы отмечены в отчете
+func (r *Runner) Run(ctx context.Context, jobs []*Job) (*Report, error) {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		changes []*change
+	)
+
+	report := &Report{}
+
+	// Ограничиваем количество одновременно обрабатываемых файлов
+	sem := make(chan struct{}, max(r.Channels, 1))
+
+	for _, job := range jobs {
+		if job.Ignored {
+			r.log().Info("file skipped by the project configuration", "file", job.Path)
+			report.Add(StatusSkipped, job.Path, nil)
+			continue
+		}
+
+		wg.Add(1)
+		go func(job *Job) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				report.Add(StatusSkipped, job.Path, context.Cause(ctx))
+				return
+			}
+
+			fctx, fcancel := r.FileContext(ctx)
+			defer fcancel()
+
+			start := r.clock().Now()
+			st, c := r.process(fctx, job)
+			st.Duration = r.clock().Now().Sub(start)
+			report.set(st)
+
+			if c != nil {
+				mu.Lock()
+				changes = append(changes, c)
+				mu.Unlock()
+			}
+
+			if r.OnFile != nil {
+				r.OnFile(st)
+			}
+		}(job)
+	}
+
+	wg.Wait()
+
+	if len(changes) > 0 {
+		r.verifyChanges(ctx, changes, report)
+	}
+
+	if ctx.Err() != nil {
+		return report, context.Cause(ctx)
+	}
+	return report, nil
+}
+
+// process обрабатывает один файл и возвращает состояние обработки и записанное изменение
+func (r *Runner) process(ctx context.Context, job *Job) (*FileStatus, *change) {
+	file, opts := job.Path, job.Options
+	st := &FileStatus{Path: file}
+
+	r.log().Info("processing file", "file", file, "language", opts.Language,
+		"model", strings.Join(opts.Models, ","), "context", len(r.Context) > 0)
+
+	content, err := r.fs().ReadFile(file)
+	if err != nil {
+		r.log().Warn("error reading file", "file", file, "err", err)
+		if opts.Skip {
+			st.Status, st.Reason = StatusFailed, err
+			return st, nil
+		}
+		r.log().Info("retrying file read", "file", file)
+		if err := r.retryOperation(ctx, opts.MaxRetries, func() error {
+			content, err = r.fs().ReadFile(file)
+			return err
+		}); err != nil {
+			r.log().Error("failed to read file", "file", file, "attempts", opts.MaxRetries, "err", err)
+			st.Status, st.Reason = StatusFailed, err
+			return st, nil
+		}
+	}
+
+	u, result, err := r.Format(ctx, string(content), file, opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			st.Status, st.Reason = StatusAborted, context.Cause(ctx)
+			return st, nil
+		}
+		r.log().Error("failed to process file", "file", file, "err", err)
+		st.Status, st.Reason = StatusFailed, err
+		return st, nil
+	}
+	st.Result = result
+
+	if opts.Mode == project.ModeReview {
+		r.log().Info("file reviewed, changes not written", "file", file)
+		st.Status = StatusCompleted
+		return st, nil
+	}
+
+	// Записываем изменения в файл. Ответ уже получен целиком, поэтому запись выполняется
+	// даже после отмены контекста
+	if err := r.fs().WriteFile(file, []byte(u), 0644); err != nil {
+		r.log().Warn("error writing file", "file", file, "err", err)
+		if opts.Skip {
+			st.Status, st.Reason = StatusFailed, err
+			return st, nil
+		}
+		r.log().Info("retrying file write", "file", file)
+		if err := r.retryOperation(context.WithoutCancel(ctx), opts.MaxRetries, func() error {
+			return r.fs().WriteFile(file, []byte(u), 0644)
+		}); err != nil {
+			r.log().Error("failed to write file", "file", file, "attempts", opts.MaxRetries, "err", err)
+			st.Status, st.Reason = StatusFailed, err
+			return st, nil
+		}
+	}
+
+	r.log().Info("file updated", "file", file)
+	st.Status = StatusCompleted
+
+	if u == string(content) {
+		return st, nil
+	}
+
+	st.Changed = true
+	return st, &change{file: file, original: string(content), formatted: u, opts: opts, result: result}
+}
+
+// FileContext возвращает контекст обработки одного файла с ограничением времени FileTimeout
+func (r *Runner) FileContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.FileTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, r.FileTimeout, ErrFileTimeout)
+}
+
+// retryOperation выполняет операцию с повторными попытками при ошибках.
+// Попытки прекращаются при отмене контекста
+func (r *Runner) retryOperation(ctx context.Context, maxRetries int, op func() error) error {
+	var err error
+	for i := 0; i < maxRetries; i++ {
+		if err = op(); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		r.log().Warn("operation failed", "attempt", i+1, "max_retries", maxRetries, "err", err)
+		// Увеличиваем задержку между попытками
+		if err := r.sleep(ctx, time.Second*time.Duration(i+1)); err != nil {
+			return err
+		}
+	}
+	return i18n.Errorf("maximum number of attempts reached (%d): %v", maxRetries, err)
+}
+
+// sleep ожидает указанное время или отмену контекста
+func (r *Runner) sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-r.clock().After(d):
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+func (r *Runner) fs() FS {
+	if r.FS == nil {
+		return OSFS{}
+	}
+	return r.FS
+}
+
+func (r *Runner) clock() Clock {
+	if r.Clock == nil {
+		return systemClock{}
+	}
+	return r.Clock
+}
+
+// log возвращает логгер конвейера
+func (r *Runner) log() *slog.Logger {
+	if r.Logger == nil {
+		return logging.Discard()
+	}
+	return r.Logger
+}
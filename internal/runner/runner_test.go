@@ -0,0 +1,595 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/seelentov/aifmt/internal/consensus"
+	"github.com/seelentov/aifmt/internal/entity"
+	"github.com/seelentov/aifmt/internal/project"
+	"github.com/seelentov/aifmt/internal/service"
+	"github.com/seelentov/aifmt/pkg/api"
+
+	"golang.org/x/text/language"
+)
+
+// memFS - файловая система в памяти
+type memFS struct {
+	mu    sync.Mutex
+	files map[string]string
+}
+
+func (m *memFS) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	content, ok := m.files[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(content), nil
+}
+
+func (m *memFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[name] = string(data)
+	return nil
+}
+
+// instantClock - часы без ожидания
+type instantClock struct{}
+
+func (instantClock) Now() time.Time { return time.Time{} }
+
+func (instantClock) After(time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+// fakeProvider отвечает на запрос форматирования результатом answer для модели и кода из запроса
+type fakeProvider struct {
+	mu     sync.Mutex
+	calls  []string
+	answer func(model, code string) (string, error)
+}
+
+func (p *fakeProvider) GetAnswerContext(ctx context.Context, model string, dialog []*api.Message, target interface{}) error {
+	p.mu.Lock()
+	p.calls = append(p.calls, model)
+	p.mu.Unlock()
+
+	// Код находится в первом блоке ``` запроса
+	text := dialog[0].Text
+	code := text[strings.Index(text, "\n")+1 : strings.Index(text, "\n```")]
+
+	formatted, err := p.answer(model, code)
+	if err != nil {
+		return err
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"code":    formatted,
+		"updates": []*entity.Update{{Code: code, Description: "formatted"}},
+	})
+	return json.Unmarshal(data, target)
+}
+
+func upper(model, code string) (string, error) {
+	return strings.ToUpper(code), nil
+}
+
+func options() *Options {
+	return &Options{Language: "go", Models: []string{"m"}, Mode: project.ModeFormat, Samples: 1, MaxRetries: 2}
+}
+
+func TestRun(t *testing.T) {
+	fs := &memFS{files: map[string]string{"a.go": "a", "b.go": "B", "c.go": "c"}}
+	r := &Runner{Provider: &fakeProvider{answer: upper}, FS: fs, Clock: instantClock{}, Channels: 2}
+
+	var mu sync.Mutex
+	var done []string
+	r.OnFile = func(st *FileStatus) {
+		mu.Lock()
+		done = append(done, st.Path)
+		mu.Unlock()
+	}
+
+	report, err := r.Run(context.Background(), []*Job{
+		{Path: "a.go", Options: options()},
+		{Path: "b.go", Options: options()},
+		{Path: "c.go", Ignored: true},
+		{Path: "missing.go", Options: &Options{Models: []string{"m"}, Skip: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fs.files["a.go"] != "A" || fs.files["b.go"] != "B" || fs.files["c.go"] != "c" {
+		t.Errorf("unexpected files: %v", fs.files)
+	}
+
+	want := map[string]struct {
+		status  string
+		changed bool
+	}{
+		"a.go":       {StatusCompleted, true},
+		"b.go":       {StatusCompleted, false},
+		"c.go":       {StatusSkipped, false},
+		"missing.go": {StatusFailed, false},
+	}
+	files := report.Files()
+	if len(files) != len(want) {
+		t.Fatalf("unexpected report: %+v", files)
+	}
+	for _, st := range files {
+		if w := want[st.Path]; st.Status != w.status || st.Changed != w.changed {
+			t.Errorf("%s: got %s, changed %v, want %s, changed %v", st.Path, st.Status, st.Changed, w.status, w.changed)
+		}
+	}
+
+	results := report.Results()
+	if len(results) != 2 || results[0].Path != "a.go" || results[0].Model != "m" || results[0].Updates[0].Path != "a.go" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if len(done) != 3 {
+		t.Errorf("OnFile must be called for processed files, got %v", done)
+	}
+}
+
+func TestRunReview(t *testing.T) {
+	fs := &memFS{files: map[string]string{"a.go": "a"}}
+	r := &Runner{Provider: &fakeProvider{answer: upper}, FS: fs}
+
+	opts := options()
+	opts.Mode = project.ModeReview
+	report, err := r.Run(context.Background(), []*Job{{Path: "a.go", Options: opts}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fs.files["a.go"] != "a" {
+		t.Error("file must not be written in review mode")
+	}
+	if st := report.Files()[0]; st.Status != StatusCompleted || st.Result == nil || st.Changed {
+		t.Errorf("unexpected status: %+v", st)
+	}
+}
+
+func TestFormatRefineValidation(t *testing.T) {
+	original := "package calc\n\nfunc Add(a, b int) int { return a + b }\n"
+	formatted := "package calc\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n"
+
+	for _, tc := range []struct {
+		name      string
+		corrected string
+		doc       bool
+	}{
+		{"validate", "package calc\n\nfunc Add(a, b int) int {\n\treturn a + b // BAD\n}\n", false},
+		{"doc", "package calc\n\n// Add складывает числа.\nfunc Add(a, b int) int { return b + a }\n", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			provider := &fakeProvider{answer: func(model, code string) (string, error) {
+				calls++
+				if calls == 1 {
+					return formatted, nil
+				}
+				// Рецензент возвращает исправление, которое не проходит проверки
+				return tc.corrected, nil
+			}}
+			r := &Runner{Provider: provider, Clock: instantClock{}}
+
+			opts := options()
+			opts.Refine = 1
+			opts.Validate = func(code string) error {
+				if strings.Contains(code, "BAD") {
+					return errors.New("bad code")
+				}
+				return nil
+			}
+			if tc.doc {
+				opts.Doc = &service.DocOptions{}
+			}
+
+			u, result, err := r.Format(context.Background(), original, "calc.go", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u != formatted || calls != 2 || len(result.Reviews) != 1 {
+				t.Errorf("refined code must be rejected, got %d calls:\n%s", calls, u)
+			}
+		})
+	}
+}
+
+func TestRunRetryAndFallback(t *testing.T) {
+	fails := 0
+	p := &fakeProvider{answer: func(model, code string) (string, error) {
+		switch {
+		case model == "broken":
+			return "", &api.StatusError{StatusCode: http.StatusServiceUnavailable}
+		case fails < 1:
+			fails++
+			return "", api.ErrInvalidResponse
+		}
+		return strings.ToUpper(code), nil
+	}}
+
+	fs := &memFS{files: map[string]string{"a.go": "a"}}
+	r := &Runner{Provider: p, FS: fs, Clock: instantClock{}}
+
+	opts := options()
+	opts.Models = []string{"broken", "backup"}
+	report, err := r.Run(context.Background(), []*Job{{Path: "a.go", Options: opts}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if st := report.Files()[0]; st.Status != StatusCompleted || st.Result.Model != "backup" {
+		t.Errorf("unexpected status: %+v", st)
+	}
+	// Первая попытка: обе модели с ошибкой, вторая: broken с ошибкой и backup
+	if got := strings.Join(p.calls, ","); got != "broken,backup,broken,backup" {
+		t.Errorf("unexpected calls: %s", got)
+	}
+}
+
+func TestRunRetriesExhausted(t *testing.T) {
+	p := &fakeProvider{answer: func(model, code string) (string, error) {
+		return "", api.ErrInvalidResponse
+	}}
+	fs := &memFS{files: map[string]string{"a.go": "a"}}
+	r := &Runner{Provider: p, FS: fs, Clock: instantClock{}}
+
+	report, err := r.Run(context.Background(), []*Job{{Path: "a.go", Options: options()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// MaxRetries: 2 - первая попытка и две повторные
+	st := report.Files()[0]
+	if st.Status != StatusFailed || st.Reason == nil || !strings.Contains(st.Reason.Error(), "after 3 attempts") {
+		t.Errorf("unexpected status: %+v", st)
+	}
+	if len(p.calls) != 3 {
+		t.Errorf("expected 3 calls, got %v", p.calls)
+	}
+}
+
+func TestRunNotRetryable(t *testing.T) {
+	p := &fakeProvider{answer: func(model, code string) (string, error) {
+		return "", &api.StatusError{StatusCode: http.StatusUnauthorized}
+	}}
+	fs := &memFS{files: map[string]string{"a.go": "a"}}
+	r := &Runner{Provider: p, FS: fs, Clock: instantClock{}}
+
+	opts := options()
+	opts.Models = []string{"m", "backup"}
+	report, err := r.Run(context.Background(), []*Job{{Path: "a.go", Options: opts}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var se *api.StatusError
+	if st := report.Files()[0]; st.Status != StatusFailed || !errors.As(st.Reason, &se) {
+		t.Errorf("unexpected status: %+v", st)
+	}
+	if len(p.calls) != 1 {
+		t.Errorf("authorization errors must not be retried, got calls %v", p.calls)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	stop := errors.New("stop")
+	cancel(stop)
+
+	fs := &memFS{files: map[string]string{"a.go": "a"}}
+	r := &Runner{Provider: &fakeProvider{answer: upper}, FS: fs}
+
+	report, err := r.Run(ctx, []*Job{{Path: "a.go", Options: options()}})
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected cancel cause, got %v", err)
+	}
+	if st := report.Files()[0]; st.Status != StatusSkipped || !errors.Is(st.Reason, stop) {
+		t.Errorf("unexpected status: %+v", st)
+	}
+	if fs.files["a.go"] != "a" {
+		t.Error("file must not change")
+	}
+}
+
+func TestRunVerify(t *testing.T) {
+	dir := t.TempDir()
+	good, bad := filepath.Join(dir, "good.go"), filepath.Join(dir, "bad.go")
+	for _, file := range []string{good, bad} {
+		if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := &fakeProvider{answer: func(model, code string) (string, error) {
+		return "formatted", nil
+	}}
+	r := &Runner{Provider: p, Clock: instantClock{}}
+
+	opts := options()
+	opts.VerifyCmd = "! grep -q formatted bad.go"
+	opts.VerifyDir = dir
+	report, err := r.Run(context.Background(), []*Job{{Path: good, Options: opts}, {Path: bad, Options: opts}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(good); string(data) != "formatted" {
+		t.Errorf("good file must keep changes, got %q", data)
+	}
+	if data, _ := os.ReadFile(bad); string(data) != "x" {
+		t.Errorf("bad file must be reverted, got %q", data)
+	}
+
+	for _, st := range report.Files() {
+		switch st.Path {
+		case good:
+			if st.Status != StatusCompleted || st.Result.Verification != VerifyPassed {
+				t.Errorf("unexpected status for good file: %+v, %s", st, st.Result.Verification)
+			}
+		case bad:
+			if st.Status != StatusFailed || st.Changed || st.Result.Verification != VerifyReverted {
+				t.Errorf("unexpected status for bad file: %+v, %s", st, st.Result.Verification)
+			}
+		}
+	}
+}
+
+func TestRunVerifyRetryTogether(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")
+	for _, file := range []string{a, b} {
+		if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	calls := 0
+	p := &fakeProvider{answer: func(model, code string) (string, error) {
+		calls++
+		if calls > 2 {
+			return "fixed", nil
+		}
+		return "formatted", nil
+	}}
+	r := &Runner{Provider: p, Clock: instantClock{}, Channels: 1, VerifyRetries: 1}
+
+	// Проверки: все изменения (ошибка), без изменений, a, a и b (ошибка), затем проверка без
+	// найденного файла b, которая тоже не проходит: изменения нарушают проверку только вместе
+	// и отменяются все, после чего оба файла форматируются повторно
+	opts := options()
+	opts.VerifyCmd = `n=$(cat count 2>/dev/null || echo 0); n=$((n+1)); echo $n > count; [ $n -ne 5 ] && ! (grep -q formatted a.go && grep -q formatted b.go)`
+	opts.VerifyDir = dir
+	report, err := r.Run(context.Background(), []*Job{{Path: a, Options: opts}, {Path: b, Options: opts}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{a, b} {
+		if data, _ := os.ReadFile(file); string(data) != "fixed" {
+			t.Errorf("%s must keep retried changes, got %q", file, data)
+		}
+	}
+	for _, st := range report.Files() {
+		if st.Status != StatusCompleted || !st.Changed || st.Result.Verification != VerifyRetried {
+			t.Errorf("unexpected status: %+v, %s", st, st.Result.Verification)
+		}
+	}
+}
+
+func TestFormatInvalidLines(t *testing.T) {
+	r := &Runner{Provider: &fakeProvider{answer: upper}, Clock: instantClock{}}
+	content := "a\nb\nc\n"
+
+	for _, lines := range []string{"0:5", "-1:2", "3:2", "2", "a:b"} {
+		opts := options()
+		opts.Lines = lines
+		if u, _, err := r.Format(context.Background(), content, "main.go", opts); err == nil {
+			t.Errorf("expected error for lines %q, got %q", lines, u)
+		}
+	}
+}
+
+func TestRunFormatter(t *testing.T) {
+	fs := &memFS{files: map[string]string{"main.go": "package main\nfunc main(){}\n", "a.txt": "a"}}
+	answers := 0
+	provider := &fakeProvider{answer: func(model, code string) (string, error) {
+		answers++
+		if code == "A" {
+			return "b", nil
+		}
+		// Исходный код передается после форматирования, первый ответ не разбирается форматером
+		if code != "package main\n\nfunc main() {}\n" {
+			return "", errors.New("code was not preformatted: " + code)
+		}
+		if answers == 1 {
+			return "package main\nfunc main() {", nil
+		}
+		return "package main\nfunc main(){ println( 1 ) }\n", nil
+	}}
+	r := &Runner{Provider: provider, FS: fs, Clock: instantClock{}, Channels: 1}
+
+	txt := options()
+	txt.Language, txt.Formatter = "text", "tr a-z A-Z"
+	report, err := r.Run(context.Background(), []*Job{
+		{Path: "main.go", Options: options()},
+		{Path: "a.txt", Options: txt},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fs.files["main.go"]; got != "package main\n\nfunc main() { println(1) }\n" {
+		t.Errorf("file was not formatted by go/format:\n%s", got)
+	}
+	if got := fs.files["a.txt"]; got != "B" {
+		t.Errorf("file was not formatted by command: %q", got)
+	}
+	if report.Count(StatusCompleted) != 2 {
+		t.Errorf("unexpected report: %+v", report.Files())
+	}
+}
+
+func TestRunCommentsCheck(t *testing.T) {
+	original := "package main\n\n// Точка входа программы\nfunc main() {}\n"
+	translated := "package main\n\n// Program entry point\nfunc main() {}\n"
+	kept := "package main\n\n// Точка входа программы\nfunc main() { println() }\n"
+
+	for _, tc := range []struct {
+		name  string
+		allow bool
+		want  string
+		calls int
+	}{
+		{"rejected", false, kept, 2},
+		{"allowed", true, translated, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := &memFS{files: map[string]string{"main.go": original}}
+			calls := 0
+			provider := &fakeProvider{answer: func(model, code string) (string, error) {
+				calls++
+				if calls == 1 {
+					return translated, nil
+				}
+				return kept, nil
+			}}
+			r := &Runner{Provider: provider, FS: fs, Clock: instantClock{}, Channels: 1}
+
+			opts := options()
+			opts.AllowCommentChanges = tc.allow
+			if _, err := r.Run(context.Background(), []*Job{{Path: "main.go", Options: opts}}); err != nil {
+				t.Fatal(err)
+			}
+			if fs.files["main.go"] != tc.want || calls != tc.calls {
+				t.Errorf("got %d calls, file:\n%s", calls, fs.files["main.go"])
+			}
+		})
+	}
+}
+
+func TestRunDoc(t *testing.T) {
+	original := "package calc\n\n// Сумма\nfunc Add(a, b int) int { return a + b }\n"
+	changed := "package calc\n\n// Add возвращает сумму a и b.\nfunc Add(a, b int) int { return b + a }\n"
+	documented := "// Package calc выполняет арифметику.\npackage calc\n\n// Add возвращает сумму a и b.\nfunc Add(a, b int) int { return a + b }\n"
+
+	fs := &memFS{files: map[string]string{"calc.go": original}}
+	calls := 0
+	provider := &fakeProvider{answer: func(model, code string) (string, error) {
+		calls++
+		if calls == 1 {
+			return changed, nil
+		}
+		return documented, nil
+	}}
+	r := &Runner{Provider: provider, FS: fs, Clock: instantClock{}, Channels: 1}
+
+	opts := options()
+	opts.Doc = &service.DocOptions{}
+	if _, err := r.Run(context.Background(), []*Job{{Path: "calc.go", Options: opts}}); err != nil {
+		t.Fatal(err)
+	}
+	// Первый ответ изменил код и отклонен, второй изменил только комментарии
+	if fs.files["calc.go"] != documented || calls != 2 {
+		t.Errorf("got %d calls, file:\n%s", calls, fs.files["calc.go"])
+	}
+}
+
+// judgeProvider отвечает на запросы форматирования через fakeProvider, а на запрос модели-судьи,
+// содержащий несколько сообщений, выбирает второй вариант и запоминает текст запроса
+type judgeProvider struct {
+	fakeProvider
+	prompt string
+}
+
+func (p *judgeProvider) GetAnswerContext(ctx context.Context, model string, dialog []*api.Message, target interface{}) error {
+	if len(dialog) == 1 {
+		return p.fakeProvider.GetAnswerContext(ctx, model, dialog, target)
+	}
+	p.mu.Lock()
+	p.prompt = dialog[0].Text
+	p.mu.Unlock()
+	return json.Unmarshal([]byte(`{"choice": 2, "reason": "shorter"}`), target)
+}
+
+func TestFormatJudgePromptLanguage(t *testing.T) {
+	var mu sync.Mutex
+	n := 0
+	p := &judgeProvider{fakeProvider: fakeProvider{answer: func(model, code string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		n++
+		return fmt.Sprintf("package main // %d\n", n), nil
+	}}}
+	r := &Runner{Provider: p, Clock: instantClock{}}
+
+	opts := options()
+	opts.Samples, opts.Pick = 2, consensus.StrategyJudge
+	opts.Prompt = "keep it short"
+	opts.PromptLanguage = language.Russian
+	opts.AllowCommentChanges = true
+
+	_, res, err := r.Format(context.Background(), "package main\n", "a.go", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Reason != "shorter" {
+		t.Errorf("judge was not used: %+v", res)
+	}
+	if !strings.HasPrefix(p.prompt, "Ниже") || !strings.Contains(p.prompt, "keep it short") {
+		t.Errorf("judge prompt must follow the prompt language and instructions:\n%s", p.prompt)
+	}
+}
+
+func TestFormatConsensusRetryAndFallback(t *testing.T) {
+	var mu sync.Mutex
+	limited := false
+	p := &fakeProvider{answer: func(model, code string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case model == "broken":
+			return "", &api.StatusError{StatusCode: http.StatusServiceUnavailable}
+		case !limited:
+			// Первый запрос к резервной модели отклоняется, вариант запрашивается повторно
+			limited = true
+			return "", &api.StatusError{StatusCode: http.StatusTooManyRequests}
+		}
+		return strings.ToUpper(code), nil
+	}}
+	r := &Runner{Provider: p, Clock: instantClock{}}
+
+	opts := options()
+	opts.Samples = 2
+	opts.Models = []string{"broken", "backup"}
+	opts.AllowCommentChanges = true
+
+	code, res, err := r.Format(context.Background(), "a", "a.go", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "A" || res.Model != "backup" {
+		t.Errorf("unexpected result %q: %+v", code, res)
+	}
+	for _, c := range res.Candidates {
+		if !c.Valid || c.Model != "backup" {
+			t.Errorf("candidate was not retried with the fallback model: %+v", c)
+		}
+	}
+}
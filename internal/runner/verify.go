@@ -0,0 +1,285 @@
+package runner
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/entity"
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// verifyOutputLimit - максимальная длина вывода команды проверки, передаваемого ИИ и выводимого в лог
+const verifyOutputLimit = 4000
+
+// Результаты проверки изменений файла командой VerifyCmd
+const (
+	VerifyPassed         = "passed"          // Проверка пройдена
+	VerifyReverted       = "reverted"        // Изменения нарушили проверку и отменены
+	VerifyRetried        = "retried"         // Изменения отформатированы повторно с учетом ошибок и прошли проверку
+	VerifyBaselineFailed = "baseline-failed" // Проверка не проходит и без изменений, изменения сохранены
+	VerifyAborted        = "aborted"         // Проверка прервана, изменения сохранены
+)
+
+// errReverted - причина отмены изменений файла для итогов обработки
+var errReverted = i18n.Error("changes reverted: the --verify-cmd check failed")
+
+// change - изменение файла, записанное Runner. Исходное содержимое служит резервной копией
+type change struct {
+	file      string
+	original  string
+	formatted string
+	opts      *Options
+	result    *entity.FileResult
+}
+
+// verifyChanges выполняет команды проверки для записанных изменений. Изменения группируются
+// по команде и каталогу, в котором она выполняется. Если проверка не проходит, поиском
+// делением пополам определяются файлы, изменения которых ее нарушают, и эти файлы
+// восстанавливаются из резервной копии. При VerifyRetries > 0 такие файлы форматируются повторно
+// с выводом команды проверки в качестве обратной связи
+func (r *Runner) verifyChanges(ctx context.Context, changes []*change, report *Report) {
+	type group struct{ cmd, dir string }
+
+	var order []group
+	groups := make(map[group][]*change)
+	for _, c := range changes {
+		if c.opts.VerifyCmd == "" {
+			continue
+		}
+		g := group{c.opts.VerifyCmd, c.opts.VerifyDir}
+		if _, ok := groups[g]; !ok {
+			order = append(order, g)
+		}
+		groups[g] = append(groups[g], c)
+	}
+
+	for _, g := range order {
+		v := &verifier{Runner: r, cmd: g.cmd, dir: g.dir, changes: groups[g]}
+		v.run(ctx, report)
+	}
+}
+
+// verifier проверяет группу изменений одной командой
+type verifier struct {
+	*Runner
+	cmd     string
+	dir     string
+	changes []*change
+}
+
+func (v *verifier) run(ctx context.Context, report *Report) {
+	v.log().Info("verifying changes", "cmd", v.cmd)
+
+	all := make(map[*change]bool, len(v.changes))
+	for _, c := range v.changes {
+		all[c] = true
+	}
+
+	// При отмене контекста восстанавливаются все записанные изменения, чтобы файлы
+	// не остались в промежуточном состоянии поиска
+	defer func() {
+		if ctx.Err() != nil {
+			v.apply(all)
+			v.mark(v.changes, VerifyAborted)
+			v.log().Warn("verification aborted", "cmd", v.cmd, "err", context.Cause(ctx))
+		}
+	}()
+
+	out, ok := v.check(ctx, all)
+	if ctx.Err() != nil {
+		return
+	}
+	if ok {
+		v.log().Info("verification passed", "cmd", v.cmd)
+		v.mark(v.changes, VerifyPassed)
+		return
+	}
+	v.log().Warn("verification failed", "cmd", v.cmd, "output", out)
+
+	if _, ok := v.check(ctx, nil); !ok {
+		if ctx.Err() != nil {
+			return
+		}
+		v.log().Warn("verification fails without aifmt changes too, changes kept", "cmd", v.cmd)
+		v.apply(all)
+		v.mark(v.changes, VerifyBaselineFailed)
+		return
+	}
+
+	culprits := v.bisect(ctx, nil, v.changes, out)
+	if ctx.Err() != nil {
+		return
+	}
+
+	applied := make(map[*change]bool)
+	for _, c := range v.changes {
+		applied[c] = true
+	}
+	for _, c := range culprits {
+		applied[c] = false
+	}
+
+	// Изменения могут нарушать проверку только вместе, тогда отменяются все
+	if _, ok := v.check(ctx, applied); !ok {
+		if ctx.Err() != nil {
+			return
+		}
+		culprits = v.changes
+		applied = make(map[*change]bool)
+		v.apply(applied)
+	}
+
+	v.mark(v.changes, VerifyPassed)
+	for _, c := range culprits {
+		v.log().Warn("changes break verification and were reverted", "file", c.file, "cmd", v.cmd)
+		c.result.Verification = VerifyReverted
+		report.update(c.file, func(st *FileStatus) {
+			st.Status, st.Reason, st.Changed = StatusFailed, errReverted, false
+		})
+	}
+
+	for _, c := range culprits {
+		if v.retry(ctx, c, applied, out) {
+			report.update(c.file, func(st *FileStatus) {
+				st.Status, st.Reason, st.Changed = StatusCompleted, nil, true
+			})
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// bisect ищет изменения, нарушающие проверку. Проверка проходит, когда применены изменения
+// good, и не проходит, когда применены good и cands. out - вывод этой неудачной проверки
+func (v *verifier) bisect(ctx context.Context, good, cands []*change, out string) []*change {
+	if len(cands) == 1 || ctx.Err() != nil {
+		return cands
+	}
+
+	left, right := cands[:len(cands)/2], cands[len(cands)/2:]
+
+	var culprits []*change
+	if leftOut, ok := v.check(ctx, set(good, left)); !ok {
+		culprits = v.bisect(ctx, good, left, leftOut)
+	}
+
+	good = append(append([]*change(nil), good...), without(left, culprits)...)
+	if rightOut, ok := v.check(ctx, set(good, right)); !ok {
+		culprits = append(culprits, v.bisect(ctx, good, right, rightOut)...)
+	}
+
+	// Половины по отдельности проверку проходят: изменения нарушают ее только вместе
+	if len(culprits) == 0 {
+		return cands
+	}
+
+	return culprits
+}
+
+// retry форматирует файл повторно с выводом команды проверки в качестве обратной связи.
+// Новый вариант сохраняется, если с ним проверка проходит, и отмечается в applied, чтобы
+// проверки следующих файлов выполнялись вместе с ним
+func (v *verifier) retry(ctx context.Context, c *change, applied map[*change]bool, out string) bool {
+	for i := 1; i <= v.VerifyRetries; i++ {
+		v.log().Info("reformatting with the verification errors", "file", c.file, "attempt", i, "retries", v.VerifyRetries)
+
+		opts := *c.opts
+		opts.Prompt = strings.TrimSpace(opts.Prompt + " " + i18n.Printer(opts.PromptLanguage).Sprintf("The previous formatting of this file broke the build or tests of the project. Output of the check command: ```\n%s\n```. Fix the code so that the check passes.", out))
+
+		u, result, err := v.Format(ctx, c.original, c.file, &opts)
+		if err != nil {
+			if ctx.Err() == nil {
+				v.log().Error("failed to reformat file", "file", c.file, "err", err)
+			}
+			return false
+		}
+
+		c.formatted = u
+		applied[c] = true
+
+		var ok bool
+		if out, ok = v.check(ctx, applied); ok {
+			v.log().Info("file reformatted and passed verification", "file", c.file)
+			*c.result = *result
+			c.result.Verification = VerifyRetried
+			return true
+		}
+
+		applied[c] = false
+		v.apply(applied)
+		if ctx.Err() != nil {
+			return false
+		}
+	}
+
+	return false
+}
+
+// check применяет изменения applied, отменяет остальные и выполняет команду проверки
+func (v *verifier) check(ctx context.Context, applied map[*change]bool) (string, bool) {
+	if err := v.apply(applied); err != nil {
+		return err.Error(), false
+	}
+
+	c := exec.CommandContext(ctx, "sh", "-c", v.cmd)
+	c.Dir = v.dir
+	output, err := c.CombinedOutput()
+
+	out := strings.TrimSpace(string(output))
+	if len(out) > verifyOutputLimit {
+		out = "..." + out[len(out)-verifyOutputLimit:]
+	}
+	if err != nil && out == "" {
+		out = err.Error()
+	}
+
+	return out, err == nil
+}
+
+// apply записывает отформатированное содержимое файлов из applied и исходное содержимое остальных.
+// Запись выполняется и после отмены контекста, чтобы не оставить файлы в промежуточном состоянии
+func (v *verifier) apply(applied map[*change]bool) error {
+	for _, c := range v.changes {
+		content := c.original
+		if applied[c] {
+			content = c.formatted
+		}
+		if err := v.fs().WriteFile(c.file, []byte(content), 0644); err != nil {
+			return i18n.Errorf("Error writing %s: %v", c.file, err)
+		}
+	}
+	return nil
+}
+
+// mark записывает результат проверки в отчет
+func (v *verifier) mark(changes []*change, status string) {
+	for _, c := range changes {
+		c.result.Verification = status
+	}
+}
+
+// set возвращает множество изменений из нескольких списков
+func set(lists ...[]*change) map[*change]bool {
+	res := make(map[*change]bool)
+	for _, list := range lists {
+		for _, c := range list {
+			res[c] = true
+		}
+	}
+	return res
+}
+
+// without возвращает изменения из list, которых нет в exclude
+func without(list, exclude []*change) []*change {
+	excluded := set(exclude)
+
+	var res []*change
+	for _, c := range list {
+		if !excluded[c] {
+			res = append(res, c)
+		}
+	}
+	return res
+}
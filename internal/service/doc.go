@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/entity"
+)
+
+// Стили docstring Python
+const (
+	DocStyleGoogle = "google" // Разделы Args, Returns, Raises
+	DocStyleNumpy  = "numpy"  // Разделы Parameters, Returns, Raises с подчеркиванием
+)
+
+// DocOptions - параметры генерации документирующих комментариев
+type DocOptions struct {
+	All   bool   // Документировать все символы, а не только экспортируемые
+	Style string // Стиль docstring Python: DocStyleGoogle или DocStyleNumpy
+}
+
+// docConventions - соглашения о документирующих комментариях и о том, какие символы считаются
+// экспортируемыми, по языкам. Тексты переводятся на язык запроса
+var docConventions = map[string][2]string{
+	"go": {
+		"Use GoDoc conventions: // line comments directly before the declaration, full sentences, the first sentence starts with the name of the identifier (for example, \"// Format formats ...\"), the package comment starts with \"Package name\"",
+		"exported Go symbols (capitalized names) and the package",
+	},
+	"javascript": {
+		"Use JSDoc: /** */ blocks before the declaration with @param {type} name, @returns {type}, @throws tags",
+		"exported functions, classes, methods and constants",
+	},
+	"typescript": {
+		"Use TSDoc: /** */ blocks before the declaration with @param name - description, @returns, @throws tags, without types in tags since they are declared in the code",
+		"exported functions, classes, interfaces, types and their public members",
+	},
+	"java": {
+		"Use Javadoc: /** */ blocks before the declaration, the first sentence is a short summary, @param, @return, @throws tags",
+		"public and protected classes, methods and fields",
+	},
+	"kotlin": {
+		"Use KDoc: /** */ blocks before the declaration with @param, @return, @throws tags",
+		"public classes, functions and properties",
+	},
+	"rust": {
+		"Use rustdoc: /// comments before the item and //! for the module, # Errors, # Panics and # Examples sections where applicable",
+		"items marked pub",
+	},
+	"csharp": {
+		"Use /// XML comments with <summary>, <param>, <returns>, <exception> tags",
+		"public and protected types and members",
+	},
+	"c": {
+		"Use Doxygen: /** */ blocks before the declaration with @brief, @param, @return tags",
+		"functions and types not declared static",
+	},
+	"cpp": {
+		"Use Doxygen: /** */ blocks before the declaration with @brief, @param, @return, @throws tags",
+		"public classes, functions and methods",
+	},
+	"php": {
+		"Use PHPDoc: /** */ blocks before the declaration with @param type $name, @return, @throws tags",
+		"public classes, functions and methods",
+	},
+	"ruby": {
+		"Use YARD: # comments before the declaration with @param [Type] name, @return [Type], @raise tags",
+		"public classes, modules and methods",
+	},
+}
+
+// pythonDocStyles - соглашения о docstring Python по стилям
+var pythonDocStyles = map[string]string{
+	DocStyleGoogle: "Use Google style docstrings: a triple double quoted string as the first statement of the module, class or function, a short summary on the first line, Args:, Returns:, Raises: sections",
+	DocStyleNumpy:  "Use NumPy style docstrings: a triple double quoted string as the first statement of the module, class or function, a short summary on the first line, Parameters, Returns, Raises sections underlined with dashes",
+}
+
+// DocStyles возвращает поддерживаемые стили docstring Python
+func DocStyles() []string {
+	return []string{DocStyleGoogle, DocStyleNumpy}
+}
+
+// Document добавляет или обновляет документирующие комментарии в коде по соглашениям языка.
+// Код не должен меняться, проверка этого выполняется вызывающей стороной
+func Document(ctx context.Context, content string, opts *Options, doc *DocOptions) (string, []*entity.Update, error) {
+	return request(ctx, docPrompt(content, opts, doc), opts)
+}
+
+// docPrompt формирует текст запроса на документирование кода
+func docPrompt(content string, opts *Options, doc *DocOptions) string {
+	pr := opts.printer()
+	language := strings.ToLower(opts.Language)
+
+	convention, public := pr.Sprintf("Use the documentation comment format accepted for %s", opts.Language), pr.Sprintf("public symbols")
+	if c, ok := docConventions[language]; ok {
+		convention, public = pr.Sprintf(c[0]), pr.Sprintf(c[1])
+	}
+	if language == "python" {
+		style := doc.Style
+		if _, ok := pythonDocStyles[style]; !ok {
+			style = DocStyleGoogle
+		}
+		convention, public = pr.Sprintf(pythonDocStyles[style]), pr.Sprintf("the module, public classes, functions and methods (names without a leading underscore)")
+	}
+
+	scope := pr.Sprintf("Document only %s, leave other symbols untouched", public)
+	if doc.All {
+		scope = pr.Sprintf("Document all symbols, including unexported and private ones")
+	}
+
+	p := pr.Sprintf("Add or update documentation comments in this code: ```%s\n%s\n```. %s. %s. Do not change the code itself: only comments may change, the code formatting must stay the same. Keep existing comments, update them only if they do not match the code or the convention. Comments language: %s. Your answer must contain only a json object, without any text before or after it, in the following format: {code:(new code), updates:(array of changes)[{code:(the part of the code you decided to change), description:(reason for the change)}]}!",
+		opts.Language, content, convention, scope, opts.CommentsLanguage)
+	if opts.Prompt != "" {
+		p += ". " + pr.Sprintf("Additional requirements: %s", opts.Prompt)
+	}
+
+	return p
+}
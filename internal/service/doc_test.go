@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+
+	"golang.org/x/text/language"
+)
+
+func TestDocument(t *testing.T) {
+	opts := &Options{Language: "go", Model: "test", Client: fakeModel(t, `{"code": "// Package calc\npackage calc", "updates": []}`)}
+	code, _, err := Document(context.Background(), "package calc", opts, &DocOptions{})
+	if err != nil || code != "// Package calc\npackage calc" {
+		t.Fatalf("unexpected result %q, %v", code, err)
+	}
+}
+
+func TestDocPrompt(t *testing.T) {
+	tests := []struct {
+		language string
+		lang     language.Tag
+		doc      DocOptions
+		want     []string
+	}{
+		{"go", i18n.English, DocOptions{}, []string{"GoDoc", "starts with the name of the identifier", "Document only exported"}},
+		{"go", i18n.Russian, DocOptions{}, []string{"GoDoc", "начинается с имени идентификатора", "Документируй только экспортируемые"}},
+		{"python", i18n.English, DocOptions{}, []string{"Google style", "Args:"}},
+		{"python", i18n.Russian, DocOptions{Style: DocStyleNumpy}, []string{"стиле NumPy", "Parameters"}},
+		{"typescript", i18n.English, DocOptions{All: true}, []string{"TSDoc", "Document all symbols"}},
+		{"java", i18n.English, DocOptions{}, []string{"Javadoc", "@param"}},
+		{"elixir", i18n.Russian, DocOptions{}, []string{"принятый для языка elixir"}},
+	}
+	for _, tt := range tests {
+		p := docPrompt("code", &Options{Language: tt.language, CommentsLanguage: "Русский", PromptLanguage: tt.lang}, &tt.doc)
+		for _, want := range tt.want {
+			if !strings.Contains(p, want) {
+				t.Errorf("docPrompt(%s, %+v) does not contain %q:\n%s", tt.language, tt.doc, want, p)
+			}
+		}
+	}
+}
@@ -1,11 +1,15 @@
 package service
 
 import (
+	"context"
 	"fmt"
-	"strings"
 
 	"github.com/seelentov/aifmt/internal/entity"
+	"github.com/seelentov/aifmt/internal/i18n"
 	"github.com/seelentov/aifmt/pkg/api"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
 )
 
 type AIFormatCodeRequest struct {
@@ -13,35 +17,83 @@ type AIFormatCodeRequest struct {
 	Updates []*entity.Update `json:"updates"`
 }
 
-func FormatCode(content, language, model, token string, comment bool, commentsLanguage string, ctx []*entity.File) (string, []*entity.Update, error) {
-	format := strings.Builder{}
-	format.WriteString("Исправь этот код: ```%s\n%s\n```. Устрани ошибки, проведи оптимизацию. В твоем ответе обязательно должен быть только json объект, без текста до или после в следующем формате: {code:(новый код), updates:(массив изменений)[{code:(часть кода, которую ты решил изменить), description:(причина изменения)}]}!.")
-	if comment {
-		format.WriteString("Так же закоментируй код. Язык должен быть: %s")
+// Options - параметры запроса к ИИ для форматирования кода
+type Options struct {
+	Language         string         // Язык программирования
+	Model            string         // Модель ИИ
+	Client           api.Provider   // Клиент API
+	Comments         bool           // Добавить в код комментарии
+	CommentsLanguage string         // Язык комментариев
+	Context          []*entity.File // Другие файлы проекта для контекста
+	Prompt           string         // Дополнительные инструкции для ИИ
+	PromptLanguage   language.Tag   // Язык запроса к ИИ, по умолчанию английский
+}
+
+// printer возвращает принтер сообщений на языке запроса к ИИ
+func (o *Options) printer() *message.Printer {
+	return i18n.Printer(o.PromptLanguage)
+}
+
+// FormatCode форматирует код целиком. Запрос к ИИ прерывается при отмене контекста
+func FormatCode(ctx context.Context, content string, opts *Options) (string, []*entity.Update, error) {
+	return request(ctx, prompt(content, opts, ""), opts)
+}
+
+// prompt формирует текст запроса на форматирование кода. extra добавляется к запросу как дополнительная инструкция
+func prompt(content string, opts *Options, extra string) string {
+	pr := opts.printer()
+
+	p := pr.Sprintf("Fix this code: ```%s\n%s\n```. Fix the errors and optimize it. Your answer must contain only a json object, without any text before or after it, in the following format: {code:(new code), updates:(array of changes)[{code:(the part of the code you decided to change), description:(reason for the change)}]}!.", opts.Language, content)
+	if opts.Comments {
+		p += pr.Sprintf("Also comment the code. The comments language must be: %s", opts.CommentsLanguage)
 	} else {
-		format.WriteString("Не добавляй в код новых комментариев, оставь уже имеющиеся")
+		p += pr.Sprintf("Do not add new comments to the code, keep the existing ones")
 	}
 
-	p := fmt.Sprintf(string(format.String()), language, content, commentsLanguage)
+	if opts.Prompt != "" {
+		p += ". " + pr.Sprintf("Additional requirements: %s", opts.Prompt)
+	}
+	if extra != "" {
+		p += ". " + extra
+	}
 
-	var res *AIFormatCodeRequest
+	return p
+}
 
-	dialog := make([]*entity.Message, 0)
-	dialog = append(dialog, &entity.Message{Text: p, IsUser: true})
+// FormatDialog возвращает диалог запроса на форматирование кода целиком, который отправляет FormatCode.
+// Используется, когда запрос отправляется не сразу, например в пакетном режиме
+func FormatDialog(content string, opts *Options) []*api.Message {
+	return dialog(prompt(content, opts, ""), opts)
+}
 
-	if len(ctx) > 1 {
-		ctxPr := "Так же учти и другие файлы этого же проекта. Я пришлю тебе список в виде отдельных сообщений: "
-		dialog = append(dialog, &entity.Message{Text: ctxPr, IsUser: true})
+// dialog формирует диалог из запроса и контекста проекта
+func dialog(p string, opts *Options) []*api.Message {
+	dialog := make([]*api.Message, 0)
+	dialog = append(dialog, &api.Message{Text: p, IsUser: true})
 
-		for _, file := range ctx {
-			filePr := fmt.Sprintf("%s:\n```%s\n%s\n```", file.Path, language, file.Content)
-			dialog = append(dialog, &entity.Message{Text: filePr, IsUser: true})
+	if len(opts.Context) > 1 {
+		ctxPr := opts.printer().Sprintf("Also take into account other files of the same project. I will send them as separate messages: ")
+		dialog = append(dialog, &api.Message{Text: ctxPr, IsUser: true})
+
+		for _, file := range opts.Context {
+			filePr := fmt.Sprintf("%s:\n```%s\n%s\n```", file.Path, opts.Language, file.Content)
+			dialog = append(dialog, &api.Message{Text: filePr, IsUser: true})
 		}
 	}
 
-	if err := api.GetAnswer(token, model, dialog, &res); err != nil {
+	return dialog
+}
+
+// request отправляет запрос к ИИ вместе с контекстом проекта и возвращает новый код и список изменений
+func request(ctx context.Context, p string, opts *Options) (string, []*entity.Update, error) {
+	var res *AIFormatCodeRequest
+
+	if err := opts.Client.GetAnswerContext(ctx, opts.Model, dialog(p, opts), &res); err != nil {
 		return "", nil, err
 	}
+	if res == nil {
+		return "", nil, api.ErrInvalidResponse
+	}
 
 	return res.Code, res.Updates, nil
 }
@@ -1,9 +1,19 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/pkg/api"
+	"github.com/seelentov/aifmt/pkg/api/apitest"
 )
 
 func TestFormatCode(t *testing.T) {
@@ -13,12 +23,9 @@ func TestFormatCode(t *testing.T) {
 		return "Hello, World!"
 	}`
 
-	token := os.Getenv("API_KEY")
-	if token == "" {
-		t.Fatal("API_KEY environment variable is not set")
-	}
-
-	fmtd, upds, err := FormatCode(lg, "go", "deepseek/deepseek-chat:free", token, false, "", nil)
+	// Кассета записана с запросом на русском языке
+	opts := &Options{Language: "go", Model: "deepseek/deepseek-chat:free", Client: cassette(t, "format_code"), PromptLanguage: i18n.Russian}
+	fmtd, upds, err := FormatCode(context.Background(), lg, opts)
 	if err != nil {
 		t.Fatalf("FormatCode failed: %v", err)
 	}
@@ -39,3 +46,104 @@ func TestFormatCode(t *testing.T) {
 		}
 	}
 }
+
+// cassette возвращает клиент, воспроизводящий ответы из testdata/<name>.json. При AIFMT_RECORD=1
+// запросы отправляются реальной модели с ключом из API_KEY, а ответы записываются в кассету
+func cassette(t *testing.T, name string) *api.Client {
+	t.Helper()
+
+	rec, err := apitest.NewRecorder(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := api.NewClient(os.Getenv("API_KEY"))
+	if rec.Mode == apitest.ModeRecord {
+		if client.Token == "" {
+			t.Fatal("API_KEY environment variable is not set")
+		}
+		t.Cleanup(func() {
+			if err := rec.Save(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	client.HTTPClient = &http.Client{Transport: rec}
+
+	return client
+}
+
+func TestFormatCodeRetry(t *testing.T) {
+	srv := apitest.NewServer(apitest.Sequence(
+		apitest.Response{Status: http.StatusBadGateway},
+		apitest.Response{Content: apitest.Formatted("package main\n", apitest.Update{Code: "package main", Description: "fixed"})},
+	))
+	defer srv.Close()
+
+	opts := &Options{Language: "go", Model: "test", Client: srv.Client()}
+
+	_, _, err := FormatCode(context.Background(), "package  main", opts)
+	if !api.IsRetryable(err) {
+		t.Fatalf("expected retryable error, got %v", err)
+	}
+
+	code, upds, err := FormatCode(context.Background(), "package  main", opts)
+	if err != nil {
+		t.Fatalf("FormatCode failed: %v", err)
+	}
+	if code != "package main\n" || len(upds) != 1 || upds[0].Description != "fixed" {
+		t.Errorf("unexpected result %q, %+v", code, upds)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 2 || !strings.Contains(reqs[1].Prompt(), "package  main") {
+		t.Errorf("unexpected requests: %+v", reqs)
+	}
+}
+
+func TestFormatCodeMalformed(t *testing.T) {
+	for name, res := range map[string]apitest.Response{
+		"body":      {Body: "not json"},
+		"choices":   {Body: `{"choices": []}`},
+		"content":   {Content: "Sure! Here is your code"},
+		"truncated": {Content: `{"code": "package main`},
+		"null":      {Content: "null"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := apitest.NewServer(func(*apitest.Request) apitest.Response { return res })
+			defer srv.Close()
+
+			_, _, err := FormatCode(context.Background(), "package main", &Options{Language: "go", Model: "test", Client: srv.Client()})
+			if !errors.Is(err, api.ErrInvalidResponse) {
+				t.Fatalf("expected ErrInvalidResponse, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFormatCodeCanceled(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client := api.NewClient("")
+	client.BaseURL = srv.URL
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := FormatCode(ctx, "package main", &Options{Language: "go", Model: "test", Client: client})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request was not canceled in time: %v", elapsed)
+	}
+}
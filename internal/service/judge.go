@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/pkg/api"
+)
+
+// JudgeResponse - ответ модели-судьи
+type JudgeResponse struct {
+	Choice int    `json:"choice"` // Номер выбранного варианта, начиная с 1
+	Reason string `json:"reason"` // Причина выбора
+}
+
+// Judge просит модель выбрать лучший из вариантов исправления кода. Возвращает индекс
+// выбранного варианта (с 0) и причину выбора
+func Judge(ctx context.Context, original string, candidates []string, opts *Options) (int, string, error) {
+	pr := opts.printer()
+	p := pr.Sprintf("Below is the original %s code and %d candidate fixes, each in a separate message. Choose the best candidate: correct, preserving the behavior of the program, without unnecessary changes. Your answer must contain only a json object, without any text before or after it, in the following format: {choice:(candidate number, starting from 1), reason:(reason for the choice)}!. Original code: ```%s\n%s\n```",
+		opts.Language, len(candidates), opts.Language, original)
+	if opts.Prompt != "" {
+		p += ". " + pr.Sprintf("Additional code requirements: %s", opts.Prompt)
+	}
+
+	dialog := []*api.Message{{Text: p, IsUser: true}}
+	for i, code := range candidates {
+		dialog = append(dialog, &api.Message{Text: pr.Sprintf("Candidate %d:\n```%s\n%s\n```", i+1, opts.Language, code), IsUser: true})
+	}
+
+	var res *JudgeResponse
+	if err := opts.Client.GetAnswerContext(ctx, opts.Model, dialog, &res); err != nil {
+		return 0, "", err
+	}
+	if res == nil || res.Choice < 1 || res.Choice > len(candidates) {
+		return 0, "", i18n.Error("the judge model picked a nonexistent candidate")
+	}
+
+	return res.Choice - 1, res.Reason, nil
+}
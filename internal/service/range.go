@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/entity"
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// FormatRange форматирует только строки с start по end (нумерация с 1, включительно).
+// ИИ получает весь файл в качестве контекста, а из ответа берется только выбранный диапазон.
+// Если ИИ изменил код за пределами диапазона, возвращается ошибка
+func FormatRange(ctx context.Context, content string, start, end int, opts *Options) (string, []*entity.Update, error) {
+	lines := splitLines(content)
+	if start < 1 || end < start || end > len(lines) {
+		return "", nil, i18n.Errorf("invalid line range %d:%d, the file has %d lines", start, end, len(lines))
+	}
+
+	selected := strings.Join(lines[start-1:end], "")
+	// Номера строк передаются строками, чтобы принтер не разбивал их на разряды
+	extra := opts.printer().Sprintf("Change only lines %s to %s (numbered from 1), return all other code in the code field unchanged. Lines that may be changed: ```%s\n%s\n```",
+		strconv.Itoa(start), strconv.Itoa(end), opts.Language, selected)
+
+	code, upds, err := request(ctx, prompt(content, opts, extra), opts)
+	if err != nil {
+		return "", nil, err
+	}
+	if code == "" {
+		return "", upds, nil
+	}
+
+	spliced, err := SpliceRange(content, code, start, end)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return spliced, upds, nil
+}
+
+// SpliceRange заменяет строки с start по end в исходном коде соответствующим фрагментом
+// отформатированного кода. Строки до и после диапазона в отформатированном коде должны
+// совпадать с исходными (без учета пробелов в конце строк), иначе возвращается ошибка
+func SpliceRange(original, formatted string, start, end int) (string, error) {
+	orig := splitLines(original)
+	res := splitLines(formatted)
+
+	prefix := orig[:start-1]
+	suffix := orig[end:]
+
+	if len(res) < len(prefix)+len(suffix) {
+		return "", i18n.Errorf("the AI changed code outside lines %d:%d", start, end)
+	}
+
+	for i, line := range prefix {
+		if !sameLine(line, res[i]) {
+			return "", i18n.Errorf("the AI changed line %d outside the range %d:%d", i+1, start, end)
+		}
+	}
+
+	offset := len(res) - len(suffix)
+	for i, line := range suffix {
+		if !sameLine(line, res[offset+i]) {
+			return "", i18n.Errorf("the AI changed line %d outside the range %d:%d", end+i+1, start, end)
+		}
+	}
+
+	middle := strings.Join(res[len(prefix):offset], "")
+	if len(suffix) > 0 && middle != "" && !strings.HasSuffix(middle, "\n") {
+		middle += "\n"
+	}
+
+	return strings.Join(prefix, "") + middle + strings.Join(suffix, ""), nil
+}
+
+// FuncLines возвращает диапазон строк функции или метода Go вместе с doc-комментарием.
+// Метод можно указать как "Тип.Метод"
+func FuncLines(content, name string) (int, int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments)
+	if err != nil {
+		return 0, 0, i18n.Errorf("error parsing Go code: %w", err)
+	}
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || funcName(fn) != name && fn.Name.Name != name {
+			continue
+		}
+
+		pos := fn.Pos()
+		if fn.Doc != nil {
+			pos = fn.Doc.Pos()
+		}
+
+		return fset.Position(pos).Line, fset.Position(fn.End()).Line, nil
+	}
+
+	return 0, 0, i18n.Errorf("function %s not found", name)
+}
+
+// funcName возвращает имя функции, а для методов - имя в виде "Тип.Метод"
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	typ := fn.Recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	if idx, ok := typ.(*ast.IndexExpr); ok {
+		typ = idx.X
+	}
+	if idx, ok := typ.(*ast.IndexListExpr); ok {
+		typ = idx.X
+	}
+	if ident, ok := typ.(*ast.Ident); ok {
+		return ident.Name + "." + fn.Name.Name
+	}
+
+	return fn.Name.Name
+}
+
+// splitLines разбивает текст на строки с сохранением символов перевода строки
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// sameLine сравнивает строки без учета пробельных символов в конце
+func sameLine(a, b string) bool {
+	return strings.TrimRight(a, " \t\r\n") == strings.TrimRight(b, " \t\r\n")
+}
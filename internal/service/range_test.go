@@ -0,0 +1,46 @@
+package service
+
+import "testing"
+
+func TestSpliceRange(t *testing.T) {
+	original := "a\nb\nc\nd\n"
+
+	got, err := SpliceRange(original, "a\nB\nB2\nc \nd\n", 2, 2)
+	if err != nil {
+		t.Fatalf("SpliceRange failed: %v", err)
+	}
+	if want := "a\nB\nB2\nc\nd\n"; got != want {
+		t.Errorf("SpliceRange = %q, want %q", got, want)
+	}
+
+	if _, err := SpliceRange(original, "A\nB\nc\nd\n", 2, 2); err == nil {
+		t.Error("expected error for change before range")
+	}
+	if _, err := SpliceRange(original, "a\nB\nc\nD\n", 2, 3); err == nil {
+		t.Error("expected error for change after range")
+	}
+}
+
+func TestFuncLines(t *testing.T) {
+	src := `package main
+
+// Run запускает сервер
+func (s *Server) Run() {
+	s.start()
+}
+
+func main() {
+}
+`
+	start, end, err := FuncLines(src, "Server.Run")
+	if err != nil {
+		t.Fatalf("FuncLines failed: %v", err)
+	}
+	if start != 3 || end != 6 {
+		t.Errorf("FuncLines = %d:%d, want 3:6", start, end)
+	}
+
+	if _, _, err := FuncLines(src, "missing"); err == nil {
+		t.Error("expected error for missing function")
+	}
+}
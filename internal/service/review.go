@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+
+	"github.com/seelentov/aifmt/internal/entity"
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/pkg/api"
+)
+
+// ReviewResponse - ответ модели-рецензента
+type ReviewResponse struct {
+	Approved bool             `json:"approved"` // Изменения приняты без исправлений
+	Issues   string           `json:"issues"`   // Найденные проблемы
+	Code     string           `json:"code"`     // Исправленный код, если изменения не приняты
+	Updates  []*entity.Update `json:"updates"`  // Исправления, внесенные рецензентом
+}
+
+// Review отправляет модели-рецензенту исходный код, предложенный код и результаты проверок.
+// Рецензент либо принимает изменения, либо возвращает исправленную версию кода
+func Review(ctx context.Context, original, proposed, checks string, opts *Options) (*ReviewResponse, error) {
+	pr := opts.printer()
+	p := pr.Sprintf("Review the proposed fix of %s code. Original code: ```%s\n%s\n```. Proposed code: ```%s\n%s\n```. Results of the checks of the proposed code: %s. Make sure the proposed code is correct, preserves the behavior of the original code, does not remove existing comments and has no regressions. Your answer must contain only a json object, without any text before or after it, in the following format: {approved:(true if the changes can be accepted without fixes), issues:(found problems), code:(the whole fixed code if approved is false), updates:(array of fixes)[{code:(the part of the code you fixed), description:(reason for the fix)}]}!.",
+		opts.Language, opts.Language, original, opts.Language, proposed, checks)
+	if opts.Prompt != "" {
+		p += ". " + pr.Sprintf("Additional code requirements: %s", opts.Prompt)
+	}
+
+	var res *ReviewResponse
+	if err := opts.Client.GetAnswerContext(ctx, opts.Model, []*api.Message{{Text: p, IsUser: true}}, &res); err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, i18n.Error("empty response from the reviewer model")
+	}
+	if !res.Approved && res.Code == "" {
+		return nil, i18n.Errorf("the reviewer model rejected the changes but returned no corrected code: %s", res.Issues)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/seelentov/aifmt/pkg/api"
+)
+
+// fakeModel возвращает клиент, модель которого всегда отвечает указанным содержимым
+func fakeModel(t *testing.T, content string) *api.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []interface{}{
+				map[string]interface{}{"message": map[string]string{"role": "assistant", "content": content}},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	client := api.NewClient("")
+	client.BaseURL = srv.URL
+	return client
+}
+
+func TestReview(t *testing.T) {
+	opts := &Options{Language: "go", Model: "test"}
+
+	opts.Client = fakeModel(t, `{"approved": true}`)
+	res, err := Review(context.Background(), "a", "b", "ok", opts)
+	if err != nil || !res.Approved {
+		t.Fatalf("expected approval, got %+v, %v", res, err)
+	}
+
+	opts.Client = fakeModel(t, `{"approved": false, "issues": "broken", "code": "c"}`)
+	res, err = Review(context.Background(), "a", "b", "ok", opts)
+	if err != nil || res.Approved || res.Code != "c" {
+		t.Fatalf("expected correction, got %+v, %v", res, err)
+	}
+
+	opts.Client = fakeModel(t, `{"approved": false, "issues": "broken"}`)
+	if _, err := Review(context.Background(), "a", "b", "ok", opts); err == nil {
+		t.Fatal("expected error for rejection without code")
+	}
+}
+
+func TestJudge(t *testing.T) {
+	opts := &Options{Language: "go", Model: "test", Client: fakeModel(t, `{"choice": 2, "reason": "shorter"}`)}
+	choice, reason, err := Judge(context.Background(), "a", []string{"b", "c"}, opts)
+	if err != nil || choice != 1 || reason != "shorter" {
+		t.Fatalf("unexpected result %d, %q, %v", choice, reason, err)
+	}
+
+	opts.Client = fakeModel(t, `{"choice": 5}`)
+	if _, _, err := Judge(context.Background(), "a", []string{"b", "c"}, opts); err == nil {
+		t.Fatal("expected error for out of range choice")
+	}
+}
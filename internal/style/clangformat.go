@@ -0,0 +1,122 @@
+package style
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+// clangNames - соответствие языков значениям Language в .clang-format
+var clangNames = map[string]string{
+	"c":      "Cpp",
+	"cpp":    "Cpp",
+	"objc":   "ObjC",
+	"java":   "Java",
+	"csharp": "CSharp",
+	"proto":  "Proto",
+}
+
+// clangLanguages - языки, которые форматирует clang-format
+var clangLanguages = []string{"c", "cpp", "objc", "java", "csharp", "proto"}
+
+// parseClangFormat возвращает правила из файла .clang-format. Файл может содержать несколько
+// документов YAML для разных языков, документ без Language применяется ко всем языкам
+func parseClangFormat(name string, data []byte, language string, p *message.Printer) ([]string, bool, error) {
+	cfg := make(map[string]interface{})
+	found := false
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc map[string]interface{}
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		lang, ok := doc["Language"]
+		if ok && lang != clangNames[language] {
+			continue
+		}
+		found = true
+		for k, v := range doc {
+			// Настройки документа для языка заменяют общие
+			if _, set := cfg[k]; !set || ok {
+				cfg[k] = v
+			}
+		}
+	}
+	if !found {
+		return nil, false, nil
+	}
+
+	var rules []string
+
+	if style, ok := cfg["BasedOnStyle"].(string); ok {
+		rules = append(rules, p.Sprintf("%s style (clang-format)", style))
+	}
+	switch tab := cfg["UseTab"]; tab {
+	case nil, false, "Never":
+		if n, ok := toInt(cfg["IndentWidth"]); ok {
+			rules = append(rules, indent(p, false, n))
+		}
+	default:
+		rules = append(rules, indent(p, true, 0))
+	}
+	if n, ok := toInt(cfg["ColumnLimit"]); ok {
+		if n == 0 {
+			rules = append(rules, p.Sprintf("no line length limit"))
+		} else {
+			rules = append(rules, lineLength(p, n))
+		}
+	}
+	switch v := cfg["BreakBeforeBraces"].(type) {
+	case string:
+		switch v {
+		case "Attach":
+			rules = append(rules, p.Sprintf("opening brace on the same line"))
+		case "Allman":
+			rules = append(rules, p.Sprintf("opening brace on its own line"))
+		default:
+			rules = append(rules, p.Sprintf("%s brace style", v))
+		}
+	}
+	switch cfg["PointerAlignment"] {
+	case "Left":
+		rules = append(rules, p.Sprintf("pointer symbol attached to the type: int* p"))
+	case "Right":
+		rules = append(rules, p.Sprintf("pointer symbol attached to the name: int *p"))
+	case "Middle":
+		rules = append(rules, p.Sprintf("pointer symbol surrounded by spaces: int * p"))
+	}
+	switch cfg["SortIncludes"] {
+	case true, "CaseSensitive", "CaseInsensitive":
+		rules = append(rules, p.Sprintf("#include directives and imports are sorted"))
+	}
+	switch cfg["AllowShortFunctionsOnASingleLine"] {
+	case "None", false:
+		rules = append(rules, p.Sprintf("a function body is never put on the same line as its declaration"))
+	}
+	switch cfg["IndentCaseLabels"] {
+	case true:
+		rules = append(rules, p.Sprintf("case labels indented relative to switch"))
+	case false:
+		rules = append(rules, p.Sprintf("case labels at the switch level"))
+	}
+	switch cfg["NamespaceIndentation"] {
+	case "None":
+		rules = append(rules, p.Sprintf("no indentation inside namespace"))
+	case "All":
+		rules = append(rules, p.Sprintf("indentation inside namespace"))
+	}
+	if cfg["SpaceBeforeParens"] == "Never" {
+		rules = append(rules, p.Sprintf("no space before parentheses"))
+	}
+
+	return rules, true, nil
+}
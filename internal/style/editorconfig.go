@@ -0,0 +1,233 @@
+package style
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+
+	"golang.org/x/text/message"
+)
+
+// editorconfigName - имя файла EditorConfig
+const editorconfigName = ".editorconfig"
+
+// editorconfigSection - секция файла .editorconfig
+type editorconfigSection struct {
+	glob  string
+	props map[string]string
+}
+
+// editorconfigProps - свойства EditorConfig в порядке вывода правил
+var editorconfigProps = []string{"indent_style", "indent_size", "tab_width", "max_line_length", "end_of_line", "charset", "trim_trailing_whitespace", "insert_final_newline"}
+
+// editorconfig возвращает правила из файлов .editorconfig в каталогах dirs, упорядоченных
+// от каталога файла к корню. Поиск останавливается на файле с root = true, свойства из
+// более близких к файлу файлов и более поздних секций заменяют остальные
+func editorconfig(file string, dirs []string, p *message.Printer) ([]*Rule, error) {
+	props := make(map[string]string)
+	sources := make(map[string]string)
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, editorconfigName)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, i18n.Errorf("error reading %s: %w", path, err)
+		}
+
+		root, sections := parseEditorconfig(data)
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, err
+		}
+
+		// Внутри файла более поздние секции заменяют более ранние, а свойства,
+		// найденные в более близких к файлу каталогах, не заменяются
+		found := make(map[string]string)
+		for _, s := range sections {
+			if !matchEditorconfig(s.glob, filepath.ToSlash(rel)) {
+				continue
+			}
+			for k, v := range s.props {
+				found[k] = v
+			}
+		}
+		for k, v := range found {
+			if _, ok := props[k]; !ok {
+				props[k], sources[k] = v, path
+			}
+		}
+
+		if root {
+			break
+		}
+	}
+
+	var rules []*Rule
+	add := func(key, text string) {
+		rules = append(rules, &Rule{Source: sources[key], Text: text})
+	}
+
+	for _, key := range editorconfigProps {
+		v, ok := props[key]
+		if !ok || v == "unset" {
+			continue
+		}
+
+		switch key {
+		case "indent_style":
+			if v == "tab" {
+				add(key, indent(p, true, 0))
+			} else if size, err := strconv.Atoi(props["indent_size"]); err == nil {
+				add(key, indent(p, false, size))
+			} else if v == "space" {
+				add(key, p.Sprintf("space indentation"))
+			}
+		case "tab_width":
+			if props["indent_style"] == "tab" {
+				add(key, p.Sprintf("tab width %s", v))
+			}
+		case "max_line_length":
+			if n, err := strconv.Atoi(v); err == nil {
+				add(key, lineLength(p, n))
+			}
+		case "end_of_line":
+			add(key, p.Sprintf("%s line endings", strings.ToUpper(v)))
+		case "charset":
+			add(key, p.Sprintf("%s encoding", v))
+		case "trim_trailing_whitespace":
+			if v == "true" {
+				add(key, p.Sprintf("no trailing whitespace"))
+			}
+		case "insert_final_newline":
+			if v == "true" {
+				add(key, p.Sprintf("the file ends with a newline"))
+			} else if v == "false" {
+				add(key, p.Sprintf("no newline at the end of the file"))
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+// parseEditorconfig разбирает файл .editorconfig. Имена и значения свойств приводятся к нижнему регистру
+func parseEditorconfig(data []byte) (bool, []*editorconfigSection) {
+	var (
+		root     bool
+		sections []*editorconfigSection
+		current  *editorconfigSection
+	)
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = &editorconfigSection{glob: line[1 : len(line)-1], props: make(map[string]string)}
+			sections = append(sections, current)
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.ToLower(strings.TrimSpace(value))
+
+		if current == nil {
+			if key == "root" {
+				root = value == "true"
+			}
+			continue
+		}
+		current.props[key] = value
+	}
+
+	return root, sections
+}
+
+// matchEditorconfig проверяет соответствие пути относительно каталога .editorconfig шаблону секции.
+// Шаблон без косой черты применяется к имени файла в любом каталоге
+func matchEditorconfig(glob, rel string) bool {
+	if !strings.Contains(glob, "/") {
+		glob = "**/" + glob
+	}
+	glob = strings.TrimPrefix(glob, "/")
+
+	re, err := regexp.Compile("^" + globRegexp(glob) + "$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(rel)
+}
+
+// numRange - диапазон чисел {n1..n2} в шаблоне EditorConfig
+var numRange = regexp.MustCompile(`^\{-?\d+\.\.-?\d+\}`)
+
+// globRegexp преобразует шаблон EditorConfig в регулярное выражение
+func globRegexp(glob string) string {
+	var b strings.Builder
+	braces := 0
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		case c == '{':
+			if m := numRange.FindString(glob[i:]); m != "" {
+				b.WriteString(`-?\d+`)
+				i += len(m) - 1
+				continue
+			}
+			braces++
+			b.WriteString("(?:")
+		case c == '}' && braces > 0:
+			braces--
+			b.WriteString(")")
+		case c == ',' && braces > 0:
+			b.WriteString("|")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,147 @@
+package style
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+// golangciConfig - настройки golangci-lint версий 1 и 2, влияющие на стиль кода
+type golangciConfig struct {
+	Linters struct {
+		Enable   []string                          `yaml:"enable"`
+		Settings map[string]map[string]interface{} `yaml:"settings"`
+	} `yaml:"linters"`
+	LintersSettings map[string]map[string]interface{} `yaml:"linters-settings"`
+	Formatters      struct {
+		Enable   []string                          `yaml:"enable"`
+		Settings map[string]map[string]interface{} `yaml:"settings"`
+	} `yaml:"formatters"`
+}
+
+// golangciLinters - правила, которые проверяют линтеры и форматеры golangci-lint. Описания
+// правил - ключи каталога сообщений и переводятся при разборе конфигурации
+var golangciLinters = map[string]string{
+	"gofumpt":     "formatting by the strict gofumpt rules",
+	"goimports":   "imports sorted and grouped as by goimports: the standard library in a separate group",
+	"godot":       "comments end with a period",
+	"errorlint":   "errors are compared with errors.Is and errors.As and wrapped with %%w",
+	"wrapcheck":   "errors from other packages are wrapped with context",
+	"nakedret":    "no naked returns in functions with named results",
+	"nlreturn":    "a blank line before return and break",
+	"whitespace":  "no blank lines at the start and end of blocks",
+	"stylecheck":  "names and comments follow the Go conventions: comments on exported names start with the name",
+	"revive":      "names and comments follow the Go conventions: comments on exported names start with the name",
+	"misspell":    "no misspelled English words",
+	"goconst":     "repeated strings are extracted into constants",
+	"gocritic":    "no constructs that gocritic warns about",
+	"prealloc":    "slices of known length are created with the needed capacity",
+	"unparam":     "no unused function parameters",
+	"nolintlint":  "nolint directives name the linter and the reason",
+	"predeclared": "no names that shadow predeclared identifiers",
+}
+
+// parseGolangci возвращает правила из конфигурации golangci-lint
+func parseGolangci(name string, data []byte, language string, p *message.Printer) ([]string, bool, error) {
+	cfg := &golangciConfig{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, false, err
+	}
+
+	settings := cfg.LintersSettings
+	if settings == nil {
+		settings = make(map[string]map[string]interface{})
+	}
+	for _, s := range []map[string]map[string]interface{}{cfg.Linters.Settings, cfg.Formatters.Settings} {
+		for name, v := range s {
+			settings[name] = v
+		}
+	}
+
+	enabled := make(map[string]bool)
+	for _, name := range append(cfg.Linters.Enable, cfg.Formatters.Enable...) {
+		enabled[strings.ToLower(name)] = true
+	}
+
+	var rules []string
+
+	names := make([]string, 0, len(enabled))
+	for name := range enabled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if rule, ok := golangciLinters[name]; ok && !contains(rules, p.Sprintf(rule)) {
+			rules = append(rules, p.Sprintf(rule))
+		}
+	}
+
+	if enabled["lll"] {
+		n, ok := toInt(settings["lll"]["line-length"])
+		if !ok {
+			n = 120
+		}
+		rules = append(rules, lineLength(p, n))
+	} else if enabled["golines"] {
+		n, ok := toInt(settings["golines"]["max-len"])
+		if !ok {
+			n = 100
+		}
+		rules = append(rules, lineLength(p, n))
+	}
+
+	if prefixes := stringList(settings["goimports"]["local-prefixes"]); len(prefixes) > 0 {
+		rules = append(rules, p.Sprintf("imports of packages with the prefix %s in a separate group after external packages", strings.Join(prefixes, ", ")))
+	}
+	if sections := stringList(settings["gci"]["sections"]); enabled["gci"] && len(sections) > 0 {
+		rules = append(rules, p.Sprintf("import group order (gci): %s", strings.Join(sections, ", ")))
+	}
+	if enabled["funlen"] {
+		if n, ok := toInt(settings["funlen"]["lines"]); ok && n > 0 {
+			rules = append(rules, p.Sprintf("functions at most %d lines long", n))
+		}
+	}
+	for _, name := range []string{"gocyclo", "cyclop", "gocognit"} {
+		if !enabled[name] {
+			continue
+		}
+		key := "min-complexity"
+		if name == "cyclop" {
+			key = "max-complexity"
+		}
+		if n, ok := toInt(settings[name][key]); ok {
+			rules = append(rules, p.Sprintf("function complexity (%s) at most %d", name, n))
+		}
+	}
+	if locale, ok := settings["misspell"]["locale"].(string); ok && enabled["misspell"] {
+		rules = append(rules, p.Sprintf("%s spelling of English words", strings.ToUpper(locale)))
+	}
+
+	if len(names) > 0 {
+		rules = append(rules, p.Sprintf("the code must pass the golangci-lint linters: %s", strings.Join(names, ", ")))
+	}
+
+	return rules, true, nil
+}
+
+// stringList возвращает список строк из значения: списка или строки со значениями через запятую
+func stringList(v interface{}) []string {
+	var res []string
+	switch v := v.(type) {
+	case string:
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				res = append(res, s)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				res = append(res, s)
+			}
+		}
+	}
+	return res
+}
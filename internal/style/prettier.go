@@ -0,0 +1,97 @@
+package style
+
+import (
+	"encoding/json"
+
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+// prettierLanguages - языки, которые форматирует Prettier
+var prettierLanguages = []string{"javascript", "typescript", "css", "scss", "less", "json", "html", "vue", "markdown", "yaml", "graphql"}
+
+// parsePrettier возвращает правила из файла .prettierrc (JSON или YAML) или раздела prettier файла package.json
+func parsePrettier(name string, data []byte, language string, p *message.Printer) ([]string, bool, error) {
+	var cfg map[string]interface{}
+
+	if name == "package.json" {
+		var pkg struct {
+			Prettier map[string]interface{} `json:"prettier"`
+		}
+		if err := json.Unmarshal(data, &pkg); err != nil {
+			return nil, false, err
+		}
+		if pkg.Prettier == nil {
+			return nil, false, nil
+		}
+		cfg = pkg.Prettier
+	} else if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, false, err
+	}
+
+	var rules []string
+
+	if n, ok := toInt(cfg["printWidth"]); ok {
+		rules = append(rules, lineLength(p, n))
+	}
+	if tabs, _ := cfg["useTabs"].(bool); tabs {
+		rules = append(rules, indent(p, true, 0))
+	} else if n, ok := toInt(cfg["tabWidth"]); ok {
+		rules = append(rules, indent(p, false, n))
+	}
+	if semi, ok := cfg["semi"].(bool); ok {
+		if semi {
+			rules = append(rules, p.Sprintf("semicolons at the end of statements"))
+		} else {
+			rules = append(rules, p.Sprintf("no semicolons at the end of statements except where required"))
+		}
+	}
+	if single, ok := cfg["singleQuote"].(bool); ok {
+		if single {
+			rules = append(rules, p.Sprintf("single quotes for strings"))
+		} else {
+			rules = append(rules, p.Sprintf("double quotes for strings"))
+		}
+	}
+	if single, _ := cfg["jsxSingleQuote"].(bool); single {
+		rules = append(rules, p.Sprintf("single quotes in JSX attributes"))
+	}
+	switch cfg["trailingComma"] {
+	case "none":
+		rules = append(rules, p.Sprintf("no trailing commas"))
+	case "es5":
+		rules = append(rules, p.Sprintf("trailing commas in multiline objects and arrays"))
+	case "all":
+		rules = append(rules, p.Sprintf("trailing commas wherever valid, including function parameters"))
+	}
+	if spacing, ok := cfg["bracketSpacing"].(bool); ok && !spacing {
+		rules = append(rules, p.Sprintf("no spaces inside object braces: {a: 1}"))
+	}
+	switch cfg["arrowParens"] {
+	case "avoid":
+		rules = append(rules, p.Sprintf("no parentheses around a single arrow function parameter"))
+	case "always":
+		rules = append(rules, p.Sprintf("always parentheses around arrow function parameters"))
+	}
+	if q, ok := cfg["quoteProps"].(string); ok && q != "as-needed" {
+		rules = append(rules, p.Sprintf("quotes in object property names: %s", q))
+	}
+	if eol, ok := cfg["endOfLine"].(string); ok && eol != "auto" {
+		rules = append(rules, p.Sprintf("%s line endings", eol))
+	}
+
+	return rules, true, nil
+}
+
+// toInt возвращает целое число из значения, разобранного из JSON, YAML или TOML
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), n == float64(int(n))
+	}
+	return 0, false
+}
@@ -0,0 +1,150 @@
+package style
+
+import (
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"golang.org/x/text/message"
+)
+
+// tomlDoc - содержимое файла TOML
+type tomlDoc map[string]interface{}
+
+// parseTOML разбирает файл TOML
+func parseTOML(data []byte) (tomlDoc, error) {
+	var doc tomlDoc
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// get возвращает значение по полному имени ключа вида tool.black.line-length
+func (d tomlDoc) get(key string) interface{} {
+	var v interface{} = map[string]interface{}(d)
+	for _, part := range strings.Split(key, ".") {
+		table, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = table[part]
+	}
+	return v
+}
+
+// has сообщает, что в файле есть таблица
+func (d tomlDoc) has(table string) bool {
+	_, ok := d.get(table).(map[string]interface{})
+	return ok
+}
+
+// parseBlack возвращает правила из раздела [tool.black] файла pyproject.toml
+func parseBlack(name string, data []byte, language string, p *message.Printer) ([]string, bool, error) {
+	doc, err := parseTOML(data)
+	if err != nil {
+		return nil, false, err
+	}
+	if !doc.has("tool.black") {
+		return nil, false, nil
+	}
+
+	rules := []string{p.Sprintf("formatting by the black rules")}
+
+	n, ok := toInt(doc.get("tool.black.line-length"))
+	if !ok {
+		n = 88
+	}
+	rules = append(rules, lineLength(p, n))
+
+	if skip, _ := doc.get("tool.black.skip-string-normalization").(bool); skip {
+		rules = append(rules, p.Sprintf("string quotes are left as they are"))
+	} else {
+		rules = append(rules, p.Sprintf("double quotes for strings"))
+	}
+	if skip, _ := doc.get("tool.black.skip-magic-trailing-comma").(bool); !skip {
+		rules = append(rules, p.Sprintf("a trailing comma keeps the construct split across lines"))
+	}
+	if versions := stringList(doc.get("tool.black.target-version")); len(versions) > 0 {
+		rules = append(rules, p.Sprintf("compatibility with Python versions: %s", strings.Join(versions, ", ")))
+	}
+
+	return rules, true, nil
+}
+
+// parseRuff возвращает правила из файла ruff.toml или раздела [tool.ruff] файла pyproject.toml
+func parseRuff(name string, data []byte, language string, p *message.Printer) ([]string, bool, error) {
+	doc, err := parseTOML(data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	prefix := ""
+	if name == "pyproject.toml" {
+		if !doc.has("tool.ruff") {
+			return nil, false, nil
+		}
+		prefix = "tool.ruff."
+	}
+	get := func(key string) interface{} {
+		return doc.get(prefix + key)
+	}
+	// lint получает настройку линтера, которая может быть задана в таблице lint или на верхнем уровне
+	lint := func(key string) interface{} {
+		if v := get("lint." + key); v != nil {
+			return v
+		}
+		return get(key)
+	}
+
+	var rules []string
+
+	n, ok := toInt(get("line-length"))
+	if !ok {
+		n = 88
+	}
+	rules = append(rules, lineLength(p, n))
+
+	if get("format.indent-style") == "tab" {
+		rules = append(rules, indent(p, true, 0))
+	} else if n, ok := toInt(get("indent-width")); ok {
+		rules = append(rules, indent(p, false, n))
+	}
+	switch get("format.quote-style") {
+	case "single":
+		rules = append(rules, p.Sprintf("single quotes for strings"))
+	case "double":
+		rules = append(rules, p.Sprintf("double quotes for strings"))
+	}
+	if v, ok := get("target-version").(string); ok {
+		rules = append(rules, p.Sprintf("compatibility with Python %s", v))
+	}
+
+	selected := append(stringList(lint("select")), stringList(lint("extend-select"))...)
+	for _, code := range selected {
+		switch code {
+		case "I":
+			rules = append(rules, p.Sprintf("imports sorted and grouped by the isort rules"))
+		case "D":
+			rule := p.Sprintf("docstrings for public modules, classes and functions")
+			if conv, ok := lint("pydocstyle.convention").(string); ok {
+				rule = p.Sprintf("docstrings for public modules, classes and functions in the %s style", conv)
+			}
+			rules = append(rules, rule)
+		case "UP":
+			rules = append(rules, p.Sprintf("modern Python syntax (pyupgrade)"))
+		case "ANN":
+			rules = append(rules, p.Sprintf("type annotations for function parameters and results"))
+		}
+	}
+	if first := stringList(lint("isort.known-first-party")); len(first) > 0 {
+		rules = append(rules, p.Sprintf("project packages in a separate import group: %s", strings.Join(first, ", ")))
+	}
+	if len(selected) > 0 {
+		rules = append(rules, p.Sprintf("the code must pass ruff with the rules: %s", strings.Join(selected, ", ")))
+	}
+	if ignored := stringList(lint("ignore")); len(ignored) > 0 {
+		rules = append(rules, p.Sprintf("ruff rules that are not checked: %s", strings.Join(ignored, ", ")))
+	}
+
+	return rules, true, nil
+}
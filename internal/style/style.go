@@ -0,0 +1,173 @@
+// Package style находит в проекте конфигурацию средств форматирования и линтеров
+// (.editorconfig, .prettierrc, .golangci.yml, pyproject.toml, .clang-format) и формирует
+// из нее правила стиля для запроса к ИИ, чтобы результат соответствовал уже принятым в проекте правилам
+package style
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+
+	"golang.org/x/text/message"
+)
+
+// Rule - правило стиля
+type Rule struct {
+	Source string // Файл конфигурации, из которого получено правило
+	Text   string // Описание правила
+}
+
+// source - вид конфигурации стиля
+type source struct {
+	// names - имена файлов конфигурации в порядке приоритета
+	names []string
+	// languages - языки, к которым применяется конфигурация, nil - все языки
+	languages []string
+	// parse возвращает правила из файла. Если файл не содержит настроек этого вида,
+	// возвращается ok = false и поиск продолжается в родительских каталогах
+	parse func(name string, data []byte, language string, p *message.Printer) (rules []string, ok bool, err error)
+}
+
+// sources - поддерживаемые конфигурации, кроме .editorconfig
+var sources = []source{
+	{names: []string{".prettierrc", ".prettierrc.json", ".prettierrc.yaml", ".prettierrc.yml", "package.json"}, languages: prettierLanguages, parse: parsePrettier},
+	{names: []string{".golangci.yml", ".golangci.yaml", ".golangci.json"}, languages: []string{"go"}, parse: parseGolangci},
+	{names: []string{"pyproject.toml"}, languages: []string{"python"}, parse: parseBlack},
+	{names: []string{".ruff.toml", "ruff.toml", "pyproject.toml"}, languages: []string{"python"}, parse: parseRuff},
+	{names: []string{".clang-format", "_clang-format"}, languages: clangLanguages, parse: parseClangFormat},
+}
+
+// aliases - другие названия языков
+var aliases = map[string]string{
+	"golang": "go",
+	"py":     "python",
+	"js":     "javascript",
+	"ts":     "typescript",
+	"c++":    "cpp",
+	"cxx":    "cpp",
+	"cs":     "csharp",
+	"c#":     "csharp",
+}
+
+// Discover ищет конфигурацию стиля для файла на языке language, поднимаясь по каталогам
+// от каталога файла до корня репозитория git. Для каждого вида конфигурации используется
+// ближайший к файлу файл, правила .editorconfig объединяются по правилам EditorConfig.
+// Описания правил формируются принтером p на его языке
+func Discover(file, language string, p *message.Printer) ([]*Rule, error) {
+	language = strings.ToLower(language)
+	if alias, ok := aliases[language]; ok {
+		language = alias
+	}
+
+	if file == "" {
+		file = "stdin"
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, i18n.Errorf("error getting the absolute path of %s: %w", file, err)
+	}
+
+	var dirs []string
+	for dir := filepath.Dir(abs); ; {
+		dirs = append(dirs, dir)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	rules, err := editorconfig(abs, dirs, p)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, src := range sources {
+		if src.languages != nil && !contains(src.languages, language) {
+			continue
+		}
+
+		found, err := src.find(dirs, language, p)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, found...)
+	}
+
+	return rules, nil
+}
+
+// find возвращает правила из ближайшего файла конфигурации
+func (s *source) find(dirs []string, language string, p *message.Printer) ([]*Rule, error) {
+	for _, dir := range dirs {
+		for _, name := range s.names {
+			path := filepath.Join(dir, name)
+			data, err := os.ReadFile(path)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, i18n.Errorf("error reading %s: %w", path, err)
+			}
+
+			texts, ok, err := s.parse(name, data, language, p)
+			if err != nil {
+				return nil, i18n.Errorf("error parsing %s: %w", path, err)
+			}
+			if !ok {
+				continue
+			}
+
+			rules := make([]*Rule, 0, len(texts))
+			for _, text := range texts {
+				rules = append(rules, &Rule{Source: path, Text: text})
+			}
+			return rules, nil
+		}
+	}
+	return nil, nil
+}
+
+// Prompt возвращает инструкцию для ИИ на языке принтера p с правилами стиля или пустую строку,
+// если правил нет
+func Prompt(rules []*Rule, p *message.Printer) string {
+	if len(rules) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(p.Sprintf("Follow the style rules set by the project configuration:"))
+	for _, r := range rules {
+		fmt.Fprintf(&b, "\n- %s (%s)", r.Text, filepath.Base(r.Source))
+	}
+	return b.String()
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// lineLength возвращает правило максимальной длины строки
+func lineLength(p *message.Printer, n int) string {
+	return p.Sprintf("line length at most %d characters", n)
+}
+
+// indent возвращает правило отступа
+func indent(p *message.Printer, tabs bool, width int) string {
+	if tabs {
+		return p.Sprintf("tab indentation")
+	}
+	return p.Sprintf("indentation with %d spaces", width)
+}
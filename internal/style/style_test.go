@@ -0,0 +1,315 @@
+package style
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// ru - принтер правил на русском: тесты заодно проверяют каталог переводов
+var ru = i18n.Printer(i18n.Russian)
+
+// project создает каталог проекта с файлами и возвращает путь к нему
+func project(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// texts возвращает описания правил
+func texts(rules []*Rule) []string {
+	res := make([]string, 0, len(rules))
+	for _, r := range rules {
+		res = append(res, r.Text)
+	}
+	return res
+}
+
+func assertRules(t *testing.T, rules []*Rule, want ...string) {
+	t.Helper()
+	got := texts(rules)
+	for _, w := range want {
+		if !contains(got, w) {
+			t.Errorf("missing rule %q in:\n%s", w, strings.Join(got, "\n"))
+		}
+	}
+}
+
+func TestEditorconfig(t *testing.T) {
+	root := project(t, map[string]string{
+		".editorconfig": `root = true
+
+[*]
+indent_style = space
+indent_size = 4
+end_of_line = lf
+insert_final_newline = true
+
+[*.{go,mod}]
+indent_style = tab
+
+[Makefile]
+indent_style = tab
+
+[docs/**.md]
+max_line_length = 80
+`,
+		"web/.editorconfig": `[*.js]
+indent_size = 2
+trim_trailing_whitespace = true
+`,
+	})
+
+	rules, err := Discover(filepath.Join(root, "cmd", "main.go"), "go", ru)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRules(t, rules, "отступы табуляцией", "окончания строк LF", "файл заканчивается переводом строки")
+
+	rules, err = Discover(filepath.Join(root, "web", "src", "app.js"), "javascript", ru)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRules(t, rules, "отступы пробелами шириной 2", "без пробелов в конце строк")
+	if rules[0].Source != filepath.Join(root, ".editorconfig") {
+		t.Errorf("unexpected source %s", rules[0].Source)
+	}
+
+	rules, err = Discover(filepath.Join(root, "docs", "guide", "intro.md"), "markdown", ru)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRules(t, rules, "длина строки не более 80 символов", "отступы пробелами шириной 4")
+}
+
+func TestMatchEditorconfig(t *testing.T) {
+	for _, tc := range []struct {
+		glob, path string
+		want       bool
+	}{
+		{"*", "a/b/c.go", true},
+		{"*.go", "a/b/c.go", true},
+		{"*.{js,ts}", "src/app.ts", true},
+		{"*.{js,ts}", "src/app.go", false},
+		{"/src/*.js", "src/app.js", true},
+		{"/src/*.js", "src/lib/app.js", false},
+		{"src/**.js", "src/lib/app.js", true},
+		{"file[0-9].txt", "file1.txt", true},
+		{"file[!0-9].txt", "file1.txt", false},
+		{"v{1..3}.txt", "v2.txt", true},
+	} {
+		if got := matchEditorconfig(tc.glob, tc.path); got != tc.want {
+			t.Errorf("matchEditorconfig(%q, %q) = %v, want %v", tc.glob, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestPrettier(t *testing.T) {
+	root := project(t, map[string]string{
+		".prettierrc":      `{"semi": false, "singleQuote": true, "printWidth": 100, "tabWidth": 2, "trailingComma": "all"}`,
+		"pkg/package.json": `{"name": "pkg", "prettier": {"useTabs": true, "arrowParens": "avoid"}}`,
+		"app/package.json": `{"name": "app"}`,
+	})
+
+	rules, err := Discover(filepath.Join(root, "app", "index.ts"), "typescript", ru)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRules(t, rules, "длина строки не более 100 символов", "отступы пробелами шириной 2", "одинарные кавычки для строк",
+		"без точки с запятой в конце инструкций, кроме обязательных случаев", "завершающие запятые везде, где это допустимо, включая параметры функций")
+
+	rules, err = Discover(filepath.Join(root, "pkg", "index.js"), "js", ru)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRules(t, rules, "отступы табуляцией", "без скобок вокруг единственного параметра стрелочной функции")
+	if len(rules) != 2 {
+		t.Errorf("nearest config must win, got %v", texts(rules))
+	}
+
+	if rules, _ := Discover(filepath.Join(root, "main.go"), "go", ru); len(rules) != 0 {
+		t.Errorf("prettier config must not apply to go, got %v", texts(rules))
+	}
+}
+
+func TestGolangci(t *testing.T) {
+	root := project(t, map[string]string{
+		".golangci.yml": `linters:
+  enable:
+    - lll
+    - godot
+    - gocyclo
+linters-settings:
+  lll:
+    line-length: 140
+  gocyclo:
+    min-complexity: 20
+  goimports:
+    local-prefixes: github.com/example/app
+`,
+		"v2/.golangci.yml": `version: "2"
+linters:
+  enable: [funlen]
+  settings:
+    funlen:
+      lines: 60
+formatters:
+  enable: [gofumpt, goimports]
+  settings:
+    goimports:
+      local-prefixes: [github.com/example/v2]
+`,
+	})
+
+	rules, err := Discover(filepath.Join(root, "main.go"), "go", ru)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRules(t, rules, "комментарии заканчиваются точкой", "длина строки не более 140 символов", "сложность функций (gocyclo) не выше 20",
+		"импорты пакетов с префиксом github.com/example/app отдельной группой после внешних пакетов",
+		"код должен проходить проверку линтерами golangci-lint: gocyclo, godot, lll")
+
+	rules, err = Discover(filepath.Join(root, "v2", "main.go"), "golang", ru)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRules(t, rules, "форматирование по строгим правилам gofumpt", "функции не длиннее 60 строк",
+		"импорты пакетов с префиксом github.com/example/v2 отдельной группой после внешних пакетов")
+}
+
+func TestPython(t *testing.T) {
+	root := project(t, map[string]string{
+		"pyproject.toml": `[project]
+name = "app"
+description = """
+Multi-line # not a comment
+"""
+
+[tool.black]
+line-length = 100
+target-version = ["py311", "py312"]  # versions
+
+[tool.ruff]
+indent-width = 4
+lint.select = [
+    "E",  # pycodestyle
+    "I",
+    "D",
+]
+
+[tool.ruff.lint.pydocstyle]
+convention = "google"
+
+[tool.ruff.format]
+quote-style = "single"
+`,
+		"legacy/ruff.toml": `line-length = 79
+select = ["E", "F"]
+ignore = ["E501"]
+`,
+	})
+
+	rules, err := Discover(filepath.Join(root, "app", "main.py"), "python", ru)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRules(t, rules, "форматирование по правилам black", "длина строки не более 100 символов", "совместимость с версиями Python: py311, py312",
+		"импорты отсортированы и сгруппированы по правилам isort", "docstring у публичных модулей, классов и функций в стиле google",
+		"одинарные кавычки для строк", "код должен проходить проверку ruff с правилами: E, I, D")
+
+	rules, err = Discover(filepath.Join(root, "legacy", "old.py"), "python", ru)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRules(t, rules, "длина строки не более 79 символов", "правила ruff, которые не проверяются: E501")
+
+	// Встроенные таблицы, массивы таблиц и многострочные строки
+	root = project(t, map[string]string{
+		"pyproject.toml": `[tool.ruff]
+format = { quote-style = 'double', indent-style = "tab" }
+lint = { select = ["I"], isort = { known-first-party = ["app"] } }
+
+[[tool.ruff.overrides]]
+path = """C:\\legacy "quoted" \"""
+"""
+`,
+	})
+	rules, err = Discover(filepath.Join(root, "main.py"), "python", ru)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRules(t, rules, "двойные кавычки для строк", "отступы табуляцией",
+		"импорты отсортированы и сгруппированы по правилам isort", "пакеты проекта в отдельной группе импортов: app")
+}
+
+func TestClangFormat(t *testing.T) {
+	root := project(t, map[string]string{
+		".clang-format": `BasedOnStyle: Google
+IndentWidth: 4
+ColumnLimit: 100
+---
+Language: Cpp
+PointerAlignment: Left
+BreakBeforeBraces: Allman
+---
+Language: Java
+IndentWidth: 2
+`,
+	})
+
+	rules, err := Discover(filepath.Join(root, "src", "main.cpp"), "cpp", ru)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRules(t, rules, "стиль Google (clang-format)", "отступы пробелами шириной 4", "длина строки не более 100 символов",
+		"символ указателя прижат к типу: int* p", "открывающая фигурная скобка на отдельной строке")
+
+	rules, err = Discover(filepath.Join(root, "src", "Main.java"), "java", ru)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertRules(t, rules, "отступы пробелами шириной 2")
+}
+
+func TestPrompt(t *testing.T) {
+	en := i18n.Printer(i18n.English)
+	if p := Prompt(nil, en); p != "" {
+		t.Errorf("expected empty prompt, got %q", p)
+	}
+
+	root := project(t, map[string]string{".editorconfig": "[*]\nindent_style = tab\n"})
+	rules, err := Discover(filepath.Join(root, "main.go"), "go", en)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Prompt(rules, en)
+	if !strings.HasPrefix(p, "Follow the style rules") || !strings.Contains(p, "- tab indentation (.editorconfig)") {
+		t.Errorf("unexpected prompt %q", p)
+	}
+
+	p = Prompt([]*Rule{{Source: "/p/.editorconfig", Text: "отступы табуляцией"}}, ru)
+	if !strings.HasPrefix(p, "Соблюдай правила стиля") || !strings.Contains(p, "- отступы табуляцией (.editorconfig)") {
+		t.Errorf("unexpected prompt %q", p)
+	}
+}
+
+func TestParseTOMLInvalid(t *testing.T) {
+	if _, err := parseTOML([]byte("[tool.black]\nline-length\n")); err == nil {
+		t.Error("expected error for line without value")
+	}
+}
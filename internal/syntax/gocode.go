@@ -0,0 +1,86 @@
+package syntax
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// goNode - узел синтаксического дерева Go без позиции
+type goNode struct {
+	desc string
+	pos  token.Pos
+}
+
+// SameGoCode проверяет, что код Go отличается от исходного только комментариями и форматированием:
+// синтаксические деревья без учета комментариев и позиций совпадают
+func SameGoCode(original, changed string) error {
+	_, a, err := goNodes(original)
+	if err != nil {
+		return i18n.Errorf("error parsing the original code: %w", err)
+	}
+	fset, b, err := goNodes(changed)
+	if err != nil {
+		return i18n.Errorf("error parsing the new code: %w", err)
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].desc != b[i].desc {
+			return i18n.Errorf("code changed at line %d: %s instead of %s", fset.Position(b[i].pos).Line, b[i].desc, a[i].desc)
+		}
+	}
+	if len(a) != len(b) {
+		return i18n.Errorf("code changed: the new code has %d syntax tree nodes instead of %d", len(b), len(a))
+	}
+	return nil
+}
+
+// goNodes разбирает код без комментариев и возвращает узлы дерева в порядке обхода.
+// Конец списка дочерних узлов отмечается отдельным элементом, поэтому перенос
+// узла в другой блок тоже считается изменением
+func goNodes(src string) (*token.FileSet, []goNode, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var nodes []goNode
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			nodes = append(nodes, goNode{desc: "end"})
+			return true
+		}
+
+		desc := strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast.")
+		switch n := n.(type) {
+		case *ast.Ident:
+			desc += " " + n.Name
+		case *ast.BasicLit:
+			desc += " " + n.Value
+		case *ast.BinaryExpr:
+			desc += " " + n.Op.String()
+		case *ast.UnaryExpr:
+			desc += " " + n.Op.String()
+		case *ast.AssignStmt:
+			desc += " " + n.Tok.String()
+		case *ast.IncDecStmt:
+			desc += " " + n.Tok.String()
+		case *ast.BranchStmt:
+			desc += " " + n.Tok.String()
+		case *ast.GenDecl:
+			desc += " " + n.Tok.String()
+		case *ast.RangeStmt:
+			desc += " " + n.Tok.String()
+		case *ast.ChanType:
+			desc += fmt.Sprintf(" %d", n.Dir)
+		}
+		nodes = append(nodes, goNode{desc: desc, pos: n.Pos()})
+		return true
+	})
+	return fset, nodes, nil
+}
@@ -0,0 +1,35 @@
+package syntax
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// languages - соответствие расширений файлов языкам программирования
+var languages = map[string]string{
+	".go":    "go",
+	".py":    "python",
+	".js":    "javascript",
+	".jsx":   "javascript",
+	".ts":    "typescript",
+	".tsx":   "typescript",
+	".java":  "java",
+	".kt":    "kotlin",
+	".rs":    "rust",
+	".c":     "c",
+	".h":     "c",
+	".cpp":   "cpp",
+	".hpp":   "cpp",
+	".cs":    "csharp",
+	".rb":    "ruby",
+	".php":   "php",
+	".swift": "swift",
+	".sh":    "bash",
+	".sql":   "sql",
+}
+
+// Language определяет язык программирования по расширению файла. Для неизвестных расширений
+// возвращается пустая строка
+func Language(path string) string {
+	return languages[strings.ToLower(filepath.Ext(path))]
+}
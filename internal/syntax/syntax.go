@@ -0,0 +1,70 @@
+package syntax
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os/exec"
+	"strings"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+)
+
+// ErrUnsupported возвращается, если для языка нет проверки синтаксиса
+var ErrUnsupported = i18n.Error("syntax check is not supported")
+
+// Check проверяет синтаксис кода на указанном языке. Для Go и JSON используются встроенные
+// парсеры, для Python - модуль ast интерпретатора python3, если он установлен.
+// Для остальных языков возвращается ErrUnsupported
+func Check(language, code string) error {
+	switch strings.ToLower(language) {
+	case "go", "golang":
+		_, err := parser.ParseFile(token.NewFileSet(), "", code, parser.ParseComments)
+		return err
+	case "json":
+		var v interface{}
+		return json.Unmarshal([]byte(code), &v)
+	case "python", "py":
+		return checkPython(code)
+	}
+	return ErrUnsupported
+}
+
+// checkPython разбирает код модулем ast интерпретатора python3
+func checkPython(code string) error {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		return ErrUnsupported
+	}
+
+	var stderr bytes.Buffer
+	c := exec.Command(python, "-c", "import ast, sys; ast.parse(sys.stdin.read())")
+	c.Stdin = strings.NewReader(code)
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		return i18n.Errorf("syntax error: %s", lines[len(lines)-1])
+	}
+
+	return nil
+}
+
+// Normalize приводит код к каноническому виду для сравнения вариантов: код Go форматируется
+// go/format, поэтому варианты с одинаковым AST и комментариями совпадают. Для остальных
+// языков удаляются пробелы в конце строк и пустые строки в конце файла
+func Normalize(language, code string) string {
+	switch strings.ToLower(language) {
+	case "go", "golang":
+		if src, err := format.Source([]byte(code)); err == nil {
+			return string(src)
+		}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(code, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
@@ -0,0 +1,84 @@
+package syntax
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		language string
+		code     string
+		valid    bool
+	}{
+		{"go", "package main\n\nfunc main() {}\n", true},
+		{"go", "package main\n\nfunc main() {\n", false},
+		{"json", `{"a": [1, 2]}`, true},
+		{"json", `{"a": }`, false},
+	}
+
+	if _, err := exec.LookPath("python3"); err == nil {
+		tests = append(tests,
+			struct {
+				language string
+				code     string
+				valid    bool
+			}{"python", "def f():\n    return 1\n", true},
+			struct {
+				language string
+				code     string
+				valid    bool
+			}{"python", "def f(:\n", false},
+		)
+	}
+
+	for _, tt := range tests {
+		err := Check(tt.language, tt.code)
+		if (err == nil) != tt.valid {
+			t.Errorf("Check(%s, %q) = %v, want valid %v", tt.language, tt.code, err, tt.valid)
+		}
+	}
+
+	if err := Check("cobol", "anything"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	a := "package main\n\nfunc main() {\n\tx := 2\n\t_ = x\n}\n"
+	b := "package main\n\nfunc main() {\n    x := 1\n    _ = x\n}\n"
+	if Normalize("go", a) == Normalize("go", b) {
+		t.Error("different code must not be equal after normalization")
+	}
+
+	c := "package main\n\nfunc main() {\n\tx :=   1\n\t_ = x\n}"
+	if Normalize("go", b) != Normalize("go", c) {
+		t.Errorf("expected equal normalized code:\n%s\n%s", Normalize("go", b), Normalize("go", c))
+	}
+
+	if Normalize("python", "x = 1  \n\n\n") != Normalize("python", "x = 1") {
+		t.Error("trailing whitespace must be ignored")
+	}
+}
+
+func TestSameGoCode(t *testing.T) {
+	original := "package calc\n\nfunc Add(a, b int) int { return a + b }\n\nfunc sub(a, b int) int {\n\treturn a - b\n}\n"
+	tests := []struct {
+		name    string
+		changed string
+		same    bool
+	}{
+		{"doc comments", "// Package calc выполняет арифметику.\npackage calc\n\n// Add возвращает сумму a и b.\nfunc Add(a, b int) int { return a + b }\n\n// sub возвращает разность.\nfunc sub(a, b int) int {\n\treturn a - b // разность\n}\n", true},
+		{"reformatted", "package calc\n\nfunc Add(a, b int) int {\n\treturn a + b\n}\n\nfunc sub(a, b int) int { return a - b }\n", true},
+		{"operator", "package calc\n\n// Add возвращает сумму a и b.\nfunc Add(a, b int) int { return a * b }\n\nfunc sub(a, b int) int {\n\treturn a - b\n}\n", false},
+		{"renamed", "package calc\n\nfunc Add(x, b int) int { return x + b }\n\nfunc sub(a, b int) int {\n\treturn a - b\n}\n", false},
+		{"removed", "package calc\n\nfunc Add(a, b int) int { return a + b }\n", false},
+		{"invalid", "package calc\n\nfunc Add(", false},
+	}
+	for _, tt := range tests {
+		if err := SameGoCode(original, tt.changed); (err == nil) != tt.same {
+			t.Errorf("SameGoCode(%s) = %v, want same %v", tt.name, err, tt.same)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package watch
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/seelentov/aifmt/internal/i18n"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce - время ожидания после последнего изменения файла перед его обработкой
+const DefaultDebounce = 500 * time.Millisecond
+
+// Options - параметры наблюдения за файлами
+type Options struct {
+	Debounce time.Duration                    // Время ожидания после последнего изменения, по умолчанию DefaultDebounce
+	Ignore   func(path string, dir bool) bool // Исключает файлы и каталоги из наблюдения, может быть nil
+}
+
+// Watcher следит за изменениями файлов в каталогах (рекурсивно) и вызывает обработчик для
+// сохраненных файлов. Изменения, выполненные самой программой и отмеченные MarkWritten,
+// обработчик не вызывают
+type Watcher struct {
+	fsw   *fsnotify.Watcher
+	opts  Options
+	files map[string]bool // Отдельные файлы, за которыми ведется наблюдение
+
+	mu      sync.Mutex
+	timers  map[string]*time.Timer
+	written map[string][sha256.Size]byte
+	running map[string]bool
+	pending map[string]bool
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// New создает наблюдатель за указанными файлами и каталогами. Каталоги отслеживаются рекурсивно,
+// для файлов отслеживается их каталог, но обработчик вызывается только для указанных файлов
+func New(paths []string, opts Options) (*Watcher, error) {
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, i18n.Errorf("error creating the watcher: %w", err)
+	}
+
+	w := &Watcher{
+		fsw:     fsw,
+		opts:    opts,
+		timers:  make(map[string]*time.Timer),
+		written: make(map[string][sha256.Size]byte),
+		running: make(map[string]bool),
+		pending: make(map[string]bool),
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			fsw.Close()
+			return nil, i18n.Errorf("error getting information about %s: %w", path, err)
+		}
+
+		if !info.IsDir() {
+			if w.files == nil {
+				w.files = make(map[string]bool)
+			}
+			w.files[filepath.Clean(path)] = true
+			if err := fsw.Add(filepath.Dir(path)); err != nil {
+				fsw.Close()
+				return nil, i18n.Errorf("error watching %s: %w", path, err)
+			}
+			continue
+		}
+
+		if err := w.addDir(path); err != nil {
+			fsw.Close()
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+// addDir добавляет каталог и все вложенные каталоги, кроме исключенных
+func (w *Watcher) addDir(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && w.ignored(path, true) {
+			return filepath.SkipDir
+		}
+		if err := w.fsw.Add(path); err != nil {
+			return i18n.Errorf("error watching %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+func (w *Watcher) ignored(path string, dir bool) bool {
+	return w.opts.Ignore != nil && w.opts.Ignore(path, dir)
+}
+
+// MarkWritten отмечает содержимое, записанное в файл самой программой. Пока файл содержит
+// это содержимое, его изменения не обрабатываются
+func (w *Watcher) MarkWritten(path string, content []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written[filepath.Clean(path)] = sha256.Sum256(content)
+}
+
+// Run обрабатывает события до отмены контекста. Обработчик вызывается в отдельной горутине,
+// но не выполняется одновременно для одного файла: изменения во время обработки приводят
+// к повторному вызову после ее завершения
+func (w *Watcher) Run(ctx context.Context, handle func(path string)) error {
+	defer w.wg.Wait()
+	defer w.stopTimers()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			return i18n.Errorf("error watching files: %w", err)
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			w.event(ctx, ev, handle)
+		}
+	}
+}
+
+// Close прекращает наблюдение
+func (w *Watcher) Close() error {
+	return w.fsw.Close()
+}
+
+func (w *Watcher) event(ctx context.Context, ev fsnotify.Event, handle func(path string)) {
+	if !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) {
+		return
+	}
+
+	path := filepath.Clean(ev.Name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	if info.IsDir() {
+		if ev.Has(fsnotify.Create) && w.files == nil && !w.ignored(path, true) {
+			w.addDir(path)
+		}
+		return
+	}
+
+	if (w.files != nil && !w.files[path]) || w.ignored(path, false) {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if t, ok := w.timers[path]; ok {
+		t.Reset(w.opts.Debounce)
+		return
+	}
+	w.timers[path] = time.AfterFunc(w.opts.Debounce, func() {
+		w.fire(ctx, path, handle)
+	})
+}
+
+// fire вызывается после истечения времени ожидания для файла
+func (w *Watcher) fire(ctx context.Context, path string, handle func(path string)) {
+	w.mu.Lock()
+	delete(w.timers, path)
+	if w.stopped || ctx.Err() != nil {
+		w.mu.Unlock()
+		return
+	}
+	if w.running[path] {
+		w.pending[path] = true
+		w.mu.Unlock()
+		return
+	}
+	w.running[path] = true
+	w.wg.Add(1)
+	w.mu.Unlock()
+
+	go func() {
+		defer w.wg.Done()
+		for {
+			if !w.ownWrite(path) {
+				handle(path)
+			}
+
+			w.mu.Lock()
+			if !w.pending[path] || ctx.Err() != nil {
+				delete(w.running, path)
+				delete(w.pending, path)
+				w.mu.Unlock()
+				return
+			}
+			delete(w.pending, path)
+			w.mu.Unlock()
+		}
+	}()
+}
+
+// ownWrite проверяет, совпадает ли содержимое файла с записанным самой программой
+func (w *Watcher) ownWrite(path string) bool {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Is(err, os.ErrNotExist)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	sum, ok := w.written[path]
+	return ok && sum == sha256.Sum256(content)
+}
+
+func (w *Watcher) stopTimers() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.stopped = true
+	for path, t := range w.timers {
+		t.Stop()
+		delete(w.timers, path)
+	}
+}
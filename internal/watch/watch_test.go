@@ -0,0 +1,135 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const debounce = 50 * time.Millisecond
+
+func startWatcher(t *testing.T, paths []string, opts Options) (*Watcher, chan string) {
+	t.Helper()
+
+	opts.Debounce = debounce
+	w, err := New(paths, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handled := make(chan string, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Run(ctx, func(path string) { handled <- path })
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		w.Close()
+	})
+
+	return w, handled
+}
+
+func expect(t *testing.T, handled chan string, want string) {
+	t.Helper()
+	select {
+	case got := <-handled:
+		if got != want {
+			t.Fatalf("handled %s, want %s", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s was not handled", want)
+	}
+}
+
+func expectNothing(t *testing.T, handled chan string) {
+	t.Helper()
+	select {
+	case got := <-handled:
+		t.Fatalf("unexpected handling of %s", got)
+	case <-time.After(5 * debounce):
+	}
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDebounce(t *testing.T) {
+	dir := t.TempDir()
+	_, handled := startWatcher(t, []string{dir}, Options{})
+
+	file := filepath.Join(dir, "main.go")
+	for i := 0; i < 5; i++ {
+		write(t, file, strings.Repeat("x", i))
+		time.Sleep(debounce / 5)
+	}
+
+	expect(t, handled, file)
+	expectNothing(t, handled)
+}
+
+func TestOwnWrite(t *testing.T) {
+	dir := t.TempDir()
+	w, handled := startWatcher(t, []string{dir}, Options{})
+
+	file := filepath.Join(dir, "main.go")
+	w.MarkWritten(file, []byte("formatted"))
+	write(t, file, "formatted")
+	expectNothing(t, handled)
+
+	write(t, file, "edited")
+	expect(t, handled, file)
+}
+
+func TestIgnoreAndNewDirs(t *testing.T) {
+	dir := t.TempDir()
+	_, handled := startWatcher(t, []string{dir}, Options{
+		Ignore: func(path string, isDir bool) bool {
+			return strings.HasSuffix(path, ".tmp") || isDir && filepath.Base(path) == "vendor"
+		},
+	})
+
+	write(t, filepath.Join(dir, "main.go.tmp"), "x")
+	expectNothing(t, handled)
+
+	if err := os.Mkdir(filepath.Join(dir, "vendor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(dir, "pkg")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(debounce)
+
+	write(t, filepath.Join(dir, "vendor", "lib.go"), "x")
+	expectNothing(t, handled)
+
+	file := filepath.Join(sub, "lib.go")
+	write(t, file, "x")
+	expect(t, handled, file)
+}
+
+func TestSingleFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	write(t, file, "x")
+
+	_, handled := startWatcher(t, []string{file}, Options{})
+
+	write(t, filepath.Join(dir, "other.go"), "x")
+	expectNothing(t, handled)
+
+	write(t, file, "y")
+	expect(t, handled, file)
+}
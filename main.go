@@ -1,29 +1,39 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/seelentov/aifmt/cmd"
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/logging"
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "aifmt",
-	Short: "Инструмент для форматирования кода с помощью ИИ",
-	Long:  `AIFMT - это инструмент командной строки, использующий ИИ для форматирования и улучшения вашего кода.\nПоддерживает множество языков программирования и моделей ИИ.`,
+	Short: i18n.Sprintf("A tool for formatting code with AI"),
+	Long:  i18n.Sprintf(`AIFMT is a command line tool that uses AI to format and improve your code.\nIt supports many programming languages and AI models.`),
 }
 
 func main() {
 	// Инициализация конфигурации перед выполнением команд
 	cmd.InitConfig()
 
+	// Глобальные флаги
+	rootCmd.PersistentFlags().StringVar(&cmd.ProfileName, "profile", "", i18n.Sprintf("Connection profile (AIFMT_PROFILE or the configuration by default)"))
+	// Язык интерфейса определяется пакетом uilang до разбора флагов, флаг регистрируется для справки
+	rootCmd.PersistentFlags().String("ui-lang", "", i18n.Sprintf("Interface language: en or ru (LC_ALL, LC_MESSAGES or LANG by default)"))
+	rootCmd.PersistentFlags().CountVarP(&cmd.Verbosity, "verbose", "v", i18n.Sprintf("Verbose output: -v for debug messages, -vv for every API request"))
+	rootCmd.PersistentFlags().BoolVarP(&cmd.Quiet, "quiet", "q", false, i18n.Sprintf("Print only warnings and errors"))
+	rootCmd.PersistentFlags().StringVar(&cmd.LogFormat, "log-format", logging.FormatText, i18n.Sprintf("Log format: text or json"))
+	rootCmd.PersistentFlags().StringVar(&cmd.DebugDir, "debug-dir", "", i18n.Sprintf("Directory to save every API request and response to, with API keys redacted"))
+
 	// Добавление команд в корневую команду
-	rootCmd.AddCommand(cmd.FmtCmd, cmd.SetCmd)
+	rootCmd.AddCommand(cmd.FmtCmd, cmd.SetCmd, cmd.ConfigCmd, cmd.AuthCmd, cmd.ProfileCmd, cmd.LspCmd, cmd.WatchCmd, cmd.HookCmd, cmd.BatchCmd, cmd.StyleCmd, cmd.DocCmd)
 
 	// Выполнение корневой команды
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, "Ошибка выполнения команды:", err)
+		i18n.Fprintf(os.Stderr, "Error executing the command: %v\n", err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
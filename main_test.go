@@ -0,0 +1,510 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/seelentov/aifmt/pkg/api/apitest"
+)
+
+// mainEnv - переменная окружения, при которой тестовый бинарник работает как aifmt
+const mainEnv = "AIFMT_TEST_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(mainEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// run запускает aifmt с конфигурацией, направленной на фейковый сервер, в отдельном процессе
+func run(t *testing.T, srv *apitest.Server, config string, args ...string) (string, error) {
+	t.Helper()
+	return runIn(t, newHome(t, srv, config), filepath.Dir(args[len(args)-1]), args...)
+}
+
+// newHome создает домашний каталог с конфигурацией, направленной на фейковый сервер
+func newHome(t *testing.T, srv *apitest.Server, config string) string {
+	t.Helper()
+
+	home := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(home, ".aifmt"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	config = "base_url: " + srv.BaseURL() + "\n" + config
+	if err := os.WriteFile(filepath.Join(home, ".aifmt", "config.yaml"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+// runIn запускает aifmt с домашним каталогом home в каталоге dir в отдельном процессе
+func runIn(t *testing.T, home, dir string, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	// Язык интерфейса не зависит от локали, в которой запущены тесты
+	cmd.Env = append(os.Environ(), mainEnv+"=1", "HOME="+home, "AIFMT_API_KEY=test-key", "AIFMT_PROFILE=", "LC_ALL=", "LC_MESSAGES=", "LANG=C")
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestFmt(t *testing.T) {
+	formatted := "package main\n\nfunc main() {}\n"
+	srv := apitest.NewServer(apitest.Reply(apitest.Formatted(formatted, apitest.Update{Code: "func main(){}", Description: "пробелы"})))
+	defer srv.Close()
+
+	file := writeFile(t, "package main\nfunc main(){}\n")
+	out, err := run(t, srv, "", "fmt", "-l", "go", "-m", "test-model", file)
+	if err != nil {
+		t.Fatalf("fmt failed: %v\n%s", err, out)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != formatted {
+		t.Errorf("file was not formatted:\n%s\noutput:\n%s", data, out)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(reqs))
+	}
+	if reqs[0].Model != "test-model" || !strings.Contains(reqs[0].Prompt(), "func main() {}") {
+		t.Errorf("unexpected request: %+v", reqs[0])
+	}
+	if got := reqs[0].Header.Get("Authorization"); got != "Bearer test-key" {
+		t.Errorf("unexpected Authorization header %q", got)
+	}
+}
+
+func TestFmtRetry(t *testing.T) {
+	formatted := "package main\n"
+	srv := apitest.NewServer(apitest.Sequence(
+		apitest.Response{Status: http.StatusServiceUnavailable, Body: "overloaded"},
+		apitest.Response{Body: "not json"},
+		apitest.Response{Content: apitest.Formatted(formatted)},
+	))
+	defer srv.Close()
+
+	file := writeFile(t, "package  main\n")
+	out, err := run(t, srv, "max_retry: 2\n", "fmt", "-l", "go", file)
+	if err != nil {
+		t.Fatalf("fmt failed: %v\n%s", err, out)
+	}
+
+	if data, _ := os.ReadFile(file); string(data) != formatted {
+		t.Errorf("file was not formatted after retries:\n%s\noutput:\n%s", data, out)
+	}
+	if n := len(srv.Requests()); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+}
+
+func TestFmtFailure(t *testing.T) {
+	srv := apitest.NewServer(apitest.Reply("Sure! Here is your code"))
+	defer srv.Close()
+
+	original := "package  main\n"
+	file := writeFile(t, original)
+	out, err := run(t, srv, "max_retry: 0\n", "fmt", "-l", "go", file)
+	if err != nil {
+		t.Logf("fmt exited with %v", err)
+	}
+
+	if data, _ := os.ReadFile(file); string(data) != original {
+		t.Errorf("file must not change on malformed response, got:\n%s", data)
+	}
+	if !strings.Contains(out, "main.go") {
+		t.Errorf("expected failed file in summary, got:\n%s", out)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	formatted := "package main\n\nfunc main() {}\n"
+	srv := apitest.NewServer(apitest.Reply(apitest.Formatted(formatted)))
+	defer srv.Close()
+
+	home := newHome(t, srv, "")
+	file := writeFile(t, "package main\nfunc main(){}\n")
+	changed := filepath.Join(filepath.Dir(file), "changed.go")
+	if err := os.WriteFile(changed, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Dir(file)
+
+	out, err := runIn(t, home, dir, "batch", "submit", "--backend", "local", "-l", "go", file, changed)
+	if err != nil {
+		t.Fatalf("batch submit failed: %v\n%s", err, out)
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Fatalf("expected 2 requests, got %d", n)
+	}
+	if data, _ := os.ReadFile(file); string(data) == formatted {
+		t.Fatal("file must not change before apply")
+	}
+
+	entries, err := filepath.Glob(filepath.Join(home, ".aifmt", "batches", "*.input.jsonl"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one job input file, got %v", entries)
+	}
+	id := strings.TrimSuffix(filepath.Base(entries[0]), ".input.jsonl")
+
+	out, err = runIn(t, home, dir, "batch", "status", id)
+	if err != nil || !strings.Contains(out, "completed") {
+		t.Fatalf("unexpected status: %v\n%s", err, out)
+	}
+
+	if err := os.WriteFile(changed, []byte("package main // edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = runIn(t, home, dir, "batch", "apply", id)
+	if err != nil {
+		t.Fatalf("batch apply failed: %v\n%s", err, out)
+	}
+	if data, _ := os.ReadFile(file); string(data) != formatted {
+		t.Errorf("file was not formatted:\n%s\noutput:\n%s", data, out)
+	}
+	if data, _ := os.ReadFile(changed); string(data) != "package main // edited\n" {
+		t.Errorf("changed file must not be overwritten, got:\n%s", data)
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Errorf("apply must not send requests, got %d requests", n)
+	}
+}
+
+func TestFmtStyle(t *testing.T) {
+	srv := apitest.NewServer(apitest.Reply(apitest.Formatted("package main\n")))
+	defer srv.Close()
+
+	file := writeFile(t, "package main\n")
+	editorconfig := "root = true\n\n[*.go]\nindent_style = tab\nmax_line_length = 120\n"
+	if err := os.WriteFile(filepath.Join(filepath.Dir(file), ".editorconfig"), []byte(editorconfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := run(t, srv, "", "fmt", "-l", "go", file)
+	if err != nil {
+		t.Fatalf("fmt failed: %v\n%s", err, out)
+	}
+	prompt := srv.Requests()[0].Prompt()
+	for _, rule := range []string{"Follow the style rules", "tab indentation (.editorconfig)", "line length at most 120 characters (.editorconfig)"} {
+		if !strings.Contains(prompt, rule) {
+			t.Errorf("prompt does not contain style rule %q:\n%s", rule, prompt)
+		}
+	}
+	if strings.ContainsAny(prompt, "абвгдеёжзийклмнопрстуфхцчшщъыьэюя") {
+		t.Errorf("English prompt contains Russian text:\n%s", prompt)
+	}
+
+	out, err = run(t, srv, "style: false\n", "fmt", "-l", "go", file)
+	if err != nil {
+		t.Fatalf("fmt failed: %v\n%s", err, out)
+	}
+	if prompt := srv.Requests()[1].Prompt(); strings.Contains(prompt, ".editorconfig") {
+		t.Errorf("style rules must not be added when disabled:\n%s", prompt)
+	}
+
+	out, err = run(t, srv, "prompt_language: ru\n", "fmt", "-l", "go", file)
+	if err != nil {
+		t.Fatalf("fmt failed: %v\n%s", err, out)
+	}
+	if prompt := srv.Requests()[2].Prompt(); !strings.Contains(prompt, "отступы табуляцией (.editorconfig)") {
+		t.Errorf("prompt does not contain the Russian style rule:\n%s", prompt)
+	}
+}
+
+func TestLsp(t *testing.T) {
+	formatted := "package main\n\nfunc main() {}\n"
+	srv := apitest.NewServer(apitest.Sequence(
+		apitest.Response{Status: http.StatusServiceUnavailable},
+		apitest.Response{Content: apitest.Formatted(formatted)},
+	))
+	defer srv.Close()
+
+	file := writeFile(t, "package main\nfunc main(){}\n")
+	if err := os.WriteFile(filepath.Join(filepath.Dir(file), ".editorconfig"), []byte("[*.go]\nindent_style = tab\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var in strings.Builder
+	uri := "file://" + filepath.ToSlash(file)
+	for _, msg := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"` + uri + `","languageId":"go","version":1,"text":"package main\nfunc main(){}\n"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/formatting","params":{"textDocument":{"uri":"` + uri + `"}}}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	} {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+
+	cmd := exec.Command(os.Args[0], "lsp", "--diagnostics=false", "-m", "first,second")
+	cmd.Dir = filepath.Dir(file)
+	cmd.Env = append(os.Environ(), mainEnv+"=1", "HOME="+newHome(t, srv, ""), "AIFMT_API_KEY=test-key", "AIFMT_PROFILE=", "LC_ALL=", "LC_MESSAGES=", "LANG=C")
+	cmd.Stdin = strings.NewReader(in.String())
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("lsp failed: %v\n%s", err, out)
+	}
+
+	if !strings.Contains(string(out), `"newText":"package main\n\nfunc main() {}\n"`) {
+		t.Errorf("formatting result not returned:\n%s", out)
+	}
+	reqs := srv.Requests()
+	if len(reqs) != 2 || reqs[0].Model != "first" || reqs[1].Model != "second" {
+		t.Fatalf("expected a request to each model of the chain, got %d requests", len(reqs))
+	}
+	if prompt := reqs[1].Prompt(); !strings.Contains(prompt, "tab indentation (.editorconfig)") {
+		t.Errorf("prompt does not contain style rules:\n%s", prompt)
+	}
+}
+
+func TestFmtFormatter(t *testing.T) {
+	srv := apitest.NewServer(apitest.Reply(apitest.Formatted("package main\nimport (\n\"github.com/spf13/cobra\"\n\"os\"\n)\nvar _, _ = os.Args, cobra.Command{}\n")))
+	defer srv.Close()
+
+	file := writeFile(t, "package main\n")
+	out, err := run(t, srv, "", "fmt", "-l", "go", file)
+	if err != nil {
+		t.Fatalf("fmt failed: %v\n%s", err, out)
+	}
+
+	want := "package main\n\nimport (\n\t\"os\"\n\n\t\"github.com/spf13/cobra\"\n)\n\nvar _, _ = os.Args, cobra.Command{}\n"
+	if data, _ := os.ReadFile(file); string(data) != want {
+		t.Errorf("file was not formatted by the built-in formatter:\n%s", data)
+	}
+
+	out, err = run(t, srv, "formatters:\n  go: \"sed 's/os.Args/nil/'\"\n", "fmt", "-l", "go", file)
+	if err != nil {
+		t.Fatalf("fmt failed: %v\n%s", err, out)
+	}
+	if data, _ := os.ReadFile(file); !strings.Contains(string(data), "var _, _ = nil, cobra.Command{}") {
+		t.Errorf("file was not formatted by the configured command:\n%s", data)
+	}
+}
+
+func TestDoc(t *testing.T) {
+	original := "package main\n\nfunc Run() {}\n"
+	changed := "package main\n\n// Run запускает программу.\nfunc Run() { println() }\n"
+	documented := "package main\n\n// Run запускает программу.\nfunc Run() {}\n"
+	srv := apitest.NewServer(apitest.Sequence(
+		apitest.Response{Content: apitest.Formatted(changed)},
+		apitest.Response{Content: apitest.Formatted(documented)},
+	))
+	defer srv.Close()
+
+	file := writeFile(t, original)
+	out, err := run(t, srv, "comments_language: Русский\n", "doc", file)
+	if err != nil {
+		t.Fatalf("doc failed: %v\n%s", err, out)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != documented {
+		t.Errorf("file was not documented:\n%s\noutput:\n%s", data, out)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected changed code to be rejected and retried, got %d requests\n%s", len(reqs), out)
+	}
+	if prompt := reqs[0].Prompt(); !strings.Contains(prompt, "GoDoc") || !strings.Contains(prompt, "exported") {
+		t.Errorf("unexpected prompt:\n%s", prompt)
+	}
+}
+
+func TestUILanguage(t *testing.T) {
+	srv := apitest.NewServer(apitest.Reply(apitest.Formatted("package main\n")))
+	defer srv.Close()
+
+	home := newHome(t, srv, "")
+	out, err := runIn(t, home, t.TempDir(), "fmt")
+	if err == nil || !strings.Contains(out, "Error: no files to process") {
+		t.Errorf("expected English error, got %v:\n%s", err, out)
+	}
+	out, err = runIn(t, home, t.TempDir(), "--ui-lang", "ru", "fmt")
+	if err == nil || !strings.Contains(out, "Ошибка: не указаны файлы для обработки") {
+		t.Errorf("expected Russian error, got %v:\n%s", err, out)
+	}
+
+	file := writeFile(t, "package main\n")
+	if out, err := run(t, srv, "", "fmt", "--ui-lang", "ru", "-l", "go", file); err != nil {
+		t.Fatalf("fmt failed: %v\n%s", err, out)
+	}
+	if out, err := run(t, srv, "prompt_language: en\n", "fmt", "--ui-lang", "ru", "-l", "go", file); err != nil {
+		t.Fatalf("fmt failed: %v\n%s", err, out)
+	}
+	reqs := srv.Requests()
+	if !strings.Contains(reqs[0].Prompt(), "Исправь этот код") {
+		t.Errorf("expected Russian prompt:\n%s", reqs[0].Prompt())
+	}
+	if !strings.Contains(reqs[1].Prompt(), "Fix this code") {
+		t.Errorf("expected English prompt from prompt_language:\n%s", reqs[1].Prompt())
+	}
+}
+
+func TestLogging(t *testing.T) {
+	srv := apitest.NewServer(apitest.Reply(apitest.Formatted("package main\n")))
+	defer srv.Close()
+
+	file := writeFile(t, "package main\n")
+	out, err := run(t, srv, "", "fmt", "-q", "-l", "go", file)
+	if err != nil {
+		t.Fatalf("fmt failed: %v\n%s", err, out)
+	}
+	if strings.Contains(out, "processing file") {
+		t.Errorf("info messages printed with --quiet:\n%s", out)
+	}
+
+	out, err = run(t, srv, "", "fmt", "--log-format", "json", "-l", "go", file)
+	if err != nil {
+		t.Fatalf("fmt failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, `"level":"INFO","msg":"processing file"`) {
+		t.Errorf("expected JSON messages:\n%s", out)
+	}
+
+	dir := filepath.Join(t.TempDir(), "debug")
+	out, err = run(t, srv, "", "fmt", "-vv", "--debug-dir", dir, "-l", "go", file)
+	if err != nil {
+		t.Fatalf("fmt failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "TRACE API request") {
+		t.Errorf("expected API request in -vv output:\n%s", out)
+	}
+	dumps, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(dumps) != 1 {
+		t.Fatalf("expected one request dump, got %v (%v)", dumps, err)
+	}
+	data, err := os.ReadFile(dumps[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "test-key") || !strings.Contains(string(data), "[REDACTED]") {
+		t.Errorf("API key was not redacted:\n%s", data)
+	}
+
+	if out, err := run(t, srv, "", "fmt", "--log-format", "xml", "-l", "go", file); err == nil {
+		t.Errorf("expected error for unknown log format:\n%s", out)
+	}
+}
+
+// gitRepo создает репозиторий git во временном каталоге
+func gitRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "test"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	return dir
+}
+
+func TestHookInstall(t *testing.T) {
+	srv := apitest.NewServer(apitest.Reply(apitest.Formatted("package main\n")))
+	defer srv.Close()
+
+	home := newHome(t, srv, "")
+	dir := gitRepo(t)
+	hook := filepath.Join(dir, ".git", "hooks", "pre-commit")
+
+	// Хук предыдущих версий с русской меткой распознается и удаляется
+	if err := os.MkdirAll(filepath.Dir(hook), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\n# Установлено aifmt\nexec aifmt fmt --hook\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := runIn(t, home, dir, "hook", "uninstall"); err != nil {
+		t.Fatalf("uninstall failed: %v\n%s", err, out)
+	}
+	if _, err := os.Stat(hook); !os.IsNotExist(err) {
+		t.Fatalf("legacy hook was not removed: %v", err)
+	}
+
+	if out, err := runIn(t, home, dir, "hook", "install"); err != nil {
+		t.Fatalf("install failed: %v\n%s", err, out)
+	}
+	data, err := os.ReadFile(hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "#!/bin/sh\n# installed by aifmt\nexec aifmt fmt --hook\n"; string(data) != want {
+		t.Errorf("unexpected hook:\n%s", data)
+	}
+}
+
+func TestHook(t *testing.T) {
+	formatted := "package main\n\nfunc main() {}\n"
+	srv := apitest.NewServer(apitest.Reply(apitest.Formatted(formatted)))
+	defer srv.Close()
+
+	// Форматер проверяет, что {file} указывает на файл относительно каталога файла
+	home := newHome(t, srv, "formatters:\n  go: \"test -f {file} && cat\"\n")
+	dir := gitRepo(t)
+	file := filepath.Join(dir, "pkg", "main.go")
+	for _, d := range []string{filepath.Dir(file), filepath.Join(dir, "other")} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(file, []byte("package main\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("git", "-C", dir, "add", "pkg/main.go").CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v\n%s", err, out)
+	}
+	staged := func() string {
+		out, err := exec.Command("git", "-C", dir, "show", ":pkg/main.go").Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(out)
+	}
+
+	out, err := runIn(t, home, filepath.Join(dir, "other"), "fmt", "--hook", "--check")
+	if err == nil || !strings.Contains(out, "Files need formatting: pkg/main.go") {
+		t.Errorf("expected check failure, got %v:\n%s", err, out)
+	}
+	if staged() == formatted {
+		t.Error("check mode must not change the index")
+	}
+
+	if out, err := runIn(t, home, filepath.Join(dir, "other"), "fmt", "--hook"); err != nil {
+		t.Fatalf("hook failed: %v\n%s", err, out)
+	}
+	if got := staged(); got != formatted {
+		t.Errorf("index was not updated:\n%s", got)
+	}
+	if data, _ := os.ReadFile(file); string(data) != formatted {
+		t.Errorf("working copy was not updated:\n%s", data)
+	}
+}
@@ -0,0 +1,287 @@
+// Package aifmt - публичный интерфейс для встраивания форматирования кода с помощью ИИ
+// в другие программы. Пакет не читает конфигурацию aifmt и не работает с файлами:
+// код и все параметры передаются в Request, результат возвращается в Result.
+//
+// Типы Update и Result сериализуются в JSON и имеют версию формата SchemaVersion.
+// В пределах одной версии поля только добавляются, при несовместимом изменении
+// версия увеличивается
+package aifmt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/seelentov/aifmt/internal/auth"
+	"github.com/seelentov/aifmt/internal/consensus"
+	"github.com/seelentov/aifmt/internal/entity"
+	"github.com/seelentov/aifmt/internal/formatter"
+	"github.com/seelentov/aifmt/internal/i18n"
+	"github.com/seelentov/aifmt/internal/logging"
+	"github.com/seelentov/aifmt/internal/project"
+	"github.com/seelentov/aifmt/internal/runner"
+	"github.com/seelentov/aifmt/internal/syntax"
+	"github.com/seelentov/aifmt/pkg/api"
+)
+
+// SchemaVersion - версия формата типов Update и Result
+const SchemaVersion = 1
+
+// DefaultModel - модель, используемая, если в запросе не указана модель
+const DefaultModel = "deepseek/deepseek-chat:free"
+
+// Mode - режим форматирования
+type Mode string
+
+// Режимы форматирования
+const (
+	ModeFormat Mode = project.ModeFormat // Result.Code содержит отформатированный код
+	ModeReview Mode = project.ModeReview // Result.Code содержит исходный код, изменения только описываются в Result.Updates
+)
+
+// Стратегии выбора варианта при Request.Samples > 1
+const (
+	PickAgreement    = consensus.StrategyAgreement    // Вариант, с которым совпадает большинство
+	PickJudge        = consensus.StrategyJudge        // Вариант, выбранный моделью-судьей
+	PickSmallestDiff = consensus.StrategySmallestDiff // Вариант с наименьшим количеством изменений
+)
+
+// FormatterBuiltin - значение Request.Formatter для встроенного форматера языка (go/format для Go)
+const FormatterBuiltin = formatter.Builtin
+
+// ErrRejected возвращается, если ни один ответ модели не прошел проверки Request.Validators
+var ErrRejected = runner.ErrRejected
+
+// ErrInvalidResponse возвращается, если ответ модели не удалось разобрать
+var ErrInvalidResponse = api.ErrInvalidResponse
+
+// File - файл проекта, передаваемый модели в качестве контекста
+type File struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// Validator проверяет код из ответа модели. Если проверка не пройдена, ответ отклоняется
+// и запрашивается заново у той же или резервной модели
+type Validator func(language, code string) error
+
+// Hooks - функции, вызываемые до и после запроса к модели. Ошибка хука прерывает Format
+type Hooks struct {
+	// Before вызывается перед запросом и может изменить запрос, например подготовить код
+	Before func(ctx context.Context, req *Request) error
+	// After вызывается с результатом и может изменить его, например отформатировать код
+	// детерминированным инструментом
+	After func(ctx context.Context, res *Result) error
+}
+
+// Request - запрос на форматирование кода
+type Request struct {
+	Path             string       // Путь к файлу, используется для определения языка и в результате
+	Content          string       // Исходный код
+	Language         string       // Язык программирования, по умолчанию определяется по расширению Path
+	Mode             Mode         // Режим, по умолчанию ModeFormat
+	Model            string       // Модель ИИ, по умолчанию DefaultModel
+	FallbackModels   []string     // Резервные модели, используются по порядку при ошибках основной
+	Prompt           string       // Дополнительные инструкции для ИИ
+	Comments         bool         // Добавить в код комментарии
+	CommentsLanguage string       // Язык комментариев, по умолчанию язык существующих комментариев
+	PromptLanguage   string       // Язык запросов к ИИ: "en" или "ru", по умолчанию английский
+	Context          []File       // Другие файлы проекта для контекста
+	MaxRetries       int          // Количество повторных попыток при ошибках
+	Samples          int          // Количество вариантов для выбора лучшего, по умолчанию 1
+	Pick             string       // Стратегия выбора варианта, по умолчанию PickAgreement
+	Refine           int          // Количество раундов проверки результата моделью-рецензентом
+	Formatter        string       // Форматер кода до и после ИИ: FormatterBuiltin или команда оболочки, по умолчанию не используется
+	Validators       []Validator  // Проверки кода из ответа модели
+	Hooks            Hooks        // Функции, вызываемые до и после запроса
+	Client           api.Provider // Клиент API, по умолчанию OpenRouter с ключом из AIFMT_API_KEY или OPENROUTER_API_KEY
+	Log              io.Writer    // Поток диагностических сообщений в текстовом формате, если Logger не задан
+	Logger           *slog.Logger // Логгер диагностических сообщений, по умолчанию сообщения не выводятся
+
+	// AllowCommentChanges отключает проверку того, что исходные комментарии не удалены и не переведены
+	AllowCommentChanges bool
+}
+
+// Update - изменение, предложенное моделью
+type Update struct {
+	Code        string `json:"code"`           // Фрагмент исходного кода, который изменен
+	Description string `json:"description"`    // Причина изменения
+	Path        string `json:"path,omitempty"` // Путь к файлу
+}
+
+// Result - результат форматирования
+type Result struct {
+	Version  int      `json:"version"`            // Версия формата, SchemaVersion
+	Path     string   `json:"path,omitempty"`     // Путь к файлу из запроса
+	Language string   `json:"language"`           // Язык программирования
+	Code     string   `json:"code"`               // Итоговый код
+	Changed  bool     `json:"changed"`            // Код отличается от исходного
+	Model    string   `json:"model"`              // Модель, давшая результат
+	Updates  []Update `json:"updates"`            // Предложенные изменения
+	Strategy string   `json:"strategy,omitempty"` // Стратегия выбора варианта, если запрашивалось несколько вариантов
+	Reason   string   `json:"reason,omitempty"`   // Пояснение модели-судьи к выбору варианта
+}
+
+// Format форматирует код из запроса. В режиме ModeReview код не изменяется,
+// а предложенные изменения возвращаются в Result.Updates
+func Format(ctx context.Context, req Request) (Result, error) {
+	if req.Hooks.Before != nil {
+		if err := req.Hooks.Before(ctx, &req); err != nil {
+			return Result{}, fmt.Errorf("Before hook error: %w", err)
+		}
+	}
+
+	opts, err := req.options()
+	if err != nil {
+		return Result{}, err
+	}
+
+	client := req.Client
+	if client == nil {
+		client = api.NewClient(envKey())
+	}
+
+	files := make([]*entity.File, 0, len(req.Context))
+	for _, f := range req.Context {
+		files = append(files, &entity.File{Path: f.Path, Content: f.Content})
+	}
+
+	name := req.Path
+	if name == "" {
+		name = "<input>"
+	}
+
+	logger := req.Logger
+	if logger == nil && req.Log != nil {
+		logger, _ = logging.New(req.Log, logging.Options{})
+	}
+
+	r := &runner.Runner{Provider: client, Logger: logger, Context: files}
+	code, fr, err := r.Format(ctx, req.Content, name, opts)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if opts.Mode == project.ModeReview {
+		code = req.Content
+	}
+
+	res := Result{
+		Version:  SchemaVersion,
+		Path:     req.Path,
+		Language: opts.Language,
+		Code:     code,
+		Model:    fr.Model,
+		Updates:  make([]Update, 0, len(fr.Updates)),
+		Strategy: fr.Strategy,
+		Reason:   fr.Reason,
+	}
+	for _, u := range fr.Updates {
+		res.Updates = append(res.Updates, Update{Code: u.Code, Description: u.Description, Path: req.Path})
+	}
+
+	if req.Hooks.After != nil {
+		if err := req.Hooks.After(ctx, &res); err != nil {
+			return Result{}, fmt.Errorf("After hook error: %w", err)
+		}
+	}
+	res.Changed = res.Code != req.Content
+
+	return res, nil
+}
+
+// options проверяет запрос и преобразует его в параметры конвейера форматирования
+func (req *Request) options() (*runner.Options, error) {
+	opts := &runner.Options{
+		Language:         req.Language,
+		Mode:             string(req.Mode),
+		Prompt:           req.Prompt,
+		Comments:         req.Comments,
+		CommentsLanguage: req.CommentsLanguage,
+		MaxRetries:       req.MaxRetries,
+		Samples:          max(req.Samples, 1),
+		Pick:             req.Pick,
+		Refine:           req.Refine,
+		Formatter:        req.Formatter,
+
+		AllowCommentChanges: req.AllowCommentChanges,
+	}
+	if opts.Formatter == "" {
+		opts.Formatter = formatter.Off
+	}
+
+	if req.PromptLanguage != "" {
+		tag, ok := i18n.Parse(req.PromptLanguage)
+		if !ok {
+			return nil, fmt.Errorf("unsupported prompt language %q", req.PromptLanguage)
+		}
+		opts.PromptLanguage = tag
+	}
+
+	if opts.Language == "" {
+		opts.Language = syntax.Language(req.Path)
+	}
+	if opts.Language == "" {
+		return nil, errors.New("programming language is not specified")
+	}
+
+	if opts.Mode == "" {
+		opts.Mode = project.ModeFormat
+	}
+	if opts.Mode != project.ModeFormat && opts.Mode != project.ModeReview {
+		return nil, fmt.Errorf("invalid mode %q", req.Mode)
+	}
+
+	if opts.Pick == "" {
+		opts.Pick = consensus.StrategyAgreement
+	}
+	if !slices.Contains(consensus.Strategies(), opts.Pick) {
+		return nil, fmt.Errorf("invalid candidate selection strategy %q", req.Pick)
+	}
+	if opts.MaxRetries < 0 || opts.Refine < 0 {
+		return nil, errors.New("the number of attempts and review rounds cannot be negative")
+	}
+
+	model := req.Model
+	if model == "" {
+		model = DefaultModel
+	}
+	opts.Models = append([]string{model}, req.FallbackModels...)
+
+	if len(req.Validators) > 0 {
+		language := opts.Language
+		opts.Validate = func(code string) error {
+			for _, v := range req.Validators {
+				if err := v(language, code); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	return opts, nil
+}
+
+// SyntaxValidator проверяет синтаксис кода встроенными парсерами для Go и JSON и модулем ast
+// для Python. Код на остальных языках не проверяется
+func SyntaxValidator(language, code string) error {
+	if err := syntax.Check(language, code); err != nil && !errors.Is(err, syntax.ErrUnsupported) {
+		return err
+	}
+	return nil
+}
+
+// envKey возвращает API ключ из переменных окружения
+func envKey() string {
+	for _, name := range auth.EnvVars {
+		if key := os.Getenv(name); key != "" {
+			return key
+		}
+	}
+	return ""
+}
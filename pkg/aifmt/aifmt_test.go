@@ -0,0 +1,191 @@
+package aifmt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/seelentov/aifmt/pkg/api"
+	"github.com/seelentov/aifmt/pkg/api/apitest"
+)
+
+// staticProvider - реализация api.Provider только на публичных типах, как в программах,
+// встраивающих aifmt
+type staticProvider struct {
+	code   string
+	dialog []*api.Message
+}
+
+func (p *staticProvider) GetAnswerContext(ctx context.Context, model string, dialog []*api.Message, target interface{}) error {
+	p.dialog = dialog
+	data, _ := json.Marshal(map[string]interface{}{"code": p.code})
+	return json.Unmarshal(data, target)
+}
+
+func TestFormat(t *testing.T) {
+	srv := apitest.NewServer(apitest.Reply(apitest.Formatted("package main\n", apitest.Update{Code: "package  main", Description: "spaces"})))
+	defer srv.Close()
+
+	res, err := Format(context.Background(), Request{
+		Path:    "main.go",
+		Content: "package  main\n",
+		Model:   "m",
+		Context: []File{{Path: "a.go", Content: "package main"}, {Path: "b.go", Content: "package main"}},
+		Client:  srv.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Version != SchemaVersion || res.Language != "go" || res.Code != "package main\n" || !res.Changed || res.Model != "m" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if len(res.Updates) != 1 || res.Updates[0].Path != "main.go" || res.Updates[0].Description != "spaces" {
+		t.Errorf("unexpected updates: %+v", res.Updates)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 || len(reqs[0].Messages) != 4 {
+		t.Errorf("expected prompt with context files, got %+v", reqs)
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil || !strings.Contains(string(data), `"version":1`) {
+		t.Errorf("unexpected JSON %s, %v", data, err)
+	}
+}
+
+func TestFormatReview(t *testing.T) {
+	srv := apitest.NewServer(apitest.Reply(apitest.Formatted("package main\n", apitest.Update{Code: "package  main"})))
+	defer srv.Close()
+
+	res, err := Format(context.Background(), Request{Language: "go", Content: "package  main\n", Mode: ModeReview, Client: srv.Client()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != "package  main\n" || res.Changed || len(res.Updates) != 1 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestFormatValidators(t *testing.T) {
+	srv := apitest.NewServer(func(req *apitest.Request) apitest.Response {
+		if req.Model == "bad" {
+			return apitest.Response{Content: apitest.Formatted("package main\nfunc {")}
+		}
+		return apitest.Response{Content: apitest.Formatted("package main\n")}
+	})
+	defer srv.Close()
+
+	req := Request{Language: "go", Content: "package  main\n", Model: "bad", Validators: []Validator{SyntaxValidator}, Client: srv.Client()}
+
+	if _, err := Format(context.Background(), req); !errors.Is(err, ErrRejected) {
+		t.Fatalf("expected ErrRejected, got %v", err)
+	}
+
+	req.FallbackModels = []string{"good"}
+	res, err := Format(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Model != "good" {
+		t.Errorf("expected fallback model, got %q", res.Model)
+	}
+}
+
+func TestFormatHooks(t *testing.T) {
+	srv := apitest.NewServer(apitest.Reply(apitest.Formatted("package main\n")))
+	defer srv.Close()
+
+	req := Request{Path: "main.go", Content: "package  main", Client: srv.Client()}
+	req.Hooks.Before = func(ctx context.Context, req *Request) error {
+		req.Content += "\n"
+		return nil
+	}
+	req.Hooks.After = func(ctx context.Context, res *Result) error {
+		res.Code = strings.ToUpper(res.Code)
+		return nil
+	}
+
+	res, err := Format(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != "PACKAGE MAIN\n" {
+		t.Errorf("unexpected code %q", res.Code)
+	}
+	if got := srv.Requests()[0].Prompt(); !strings.Contains(got, "package  main\n") {
+		t.Errorf("Before hook was not applied to the request: %s", got)
+	}
+
+	failed := errors.New("failed")
+	req.Hooks.After = func(ctx context.Context, res *Result) error { return failed }
+	if _, err := Format(context.Background(), req); !errors.Is(err, failed) {
+		t.Errorf("expected hook error, got %v", err)
+	}
+}
+
+func TestFormatInvalidRequest(t *testing.T) {
+	for name, req := range map[string]Request{
+		"language": {Path: "file.unknown"},
+		"mode":     {Language: "go", Mode: "write"},
+		"pick":     {Language: "go", Pick: "random"},
+		"prompt":   {Language: "go", PromptLanguage: "de"},
+	} {
+		if _, err := Format(context.Background(), req); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestFormatFormatter(t *testing.T) {
+	srv := apitest.NewServer(apitest.Reply(apitest.Formatted("package main\nfunc main(){}")))
+	defer srv.Close()
+
+	req := Request{Path: "main.go", Content: "package main\n", Client: srv.Client()}
+	res, err := Format(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != "package main\nfunc main(){}" {
+		t.Errorf("formatter must be disabled by default, got %q", res.Code)
+	}
+
+	req.Formatter = FormatterBuiltin
+	if res, err = Format(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != "package main\n\nfunc main() {}\n" {
+		t.Errorf("unexpected code %q", res.Code)
+	}
+}
+
+func TestFormatPromptLanguage(t *testing.T) {
+	srv := apitest.NewServer(apitest.Reply(apitest.Formatted("package main\n")))
+	defer srv.Close()
+
+	req := Request{Path: "main.go", Content: "package main\n", Client: srv.Client()}
+	for _, lang := range []string{"", "ru"} {
+		req.PromptLanguage = lang
+		if _, err := Format(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reqs := srv.Requests()
+	if !strings.Contains(reqs[0].Prompt(), "Fix this code") || !strings.Contains(reqs[1].Prompt(), "Исправь этот код") {
+		t.Errorf("unexpected prompts:\n%s\n%s", reqs[0].Prompt(), reqs[1].Prompt())
+	}
+}
+
+func TestFormatCustomProvider(t *testing.T) {
+	p := &staticProvider{code: "package main\n"}
+	res, err := Format(context.Background(), Request{Language: "go", Content: "package  main\n", Model: "m", Client: p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Code != "package main\n" || len(p.dialog) == 0 || !p.dialog[0].IsUser {
+		t.Errorf("unexpected result %+v, dialog %+v", res, p.dialog)
+	}
+}
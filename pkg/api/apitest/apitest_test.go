@@ -0,0 +1,128 @@
+package apitest
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/seelentov/aifmt/pkg/api"
+)
+
+func ask(t *testing.T, client *api.Client, text string) (string, error) {
+	t.Helper()
+	var answer string
+	err := client.GetAnswer("test-model", []*api.Message{{Text: text, IsUser: true}}, &answer)
+	return answer, err
+}
+
+func TestServer(t *testing.T) {
+	srv := NewServer(Sequence(
+		Response{Status: http.StatusServiceUnavailable, Body: "overloaded"},
+		Response{Content: "second"},
+	))
+	defer srv.Close()
+
+	client := srv.Client()
+
+	var statusErr *api.StatusError
+	if _, err := ask(t, client, "hi"); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if answer, err := ask(t, client, "hi"); err != nil || answer != "second" {
+			t.Fatalf("unexpected answer %q, %v", answer, err)
+		}
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 3 || reqs[0].Model != "test-model" || reqs[0].Prompt() != "hi" {
+		t.Fatalf("unexpected requests: %+v", reqs)
+	}
+	if got := reqs[0].Header.Get("Authorization"); got != "Bearer test" {
+		t.Errorf("unexpected Authorization header %q", got)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	srv := NewServer(Sequence(Response{Content: "first"}, Response{Content: "second"}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	t.Setenv(RecordEnv, "1")
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := srv.Client()
+	client.HTTPClient = &http.Client{Transport: rec}
+	client.Token = "secret-key"
+	for _, want := range []string{"first", "second"} {
+		if answer, err := ask(t, client, "hi"); err != nil || answer != want {
+			t.Fatalf("unexpected answer %q, %v", answer, err)
+		}
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-key") {
+		t.Error("cassette must not contain the API key")
+	}
+
+	// Воспроизведение не обращается к серверу
+	srv.Close()
+	t.Setenv(RecordEnv, "")
+	rec, err = NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client = api.NewClient("")
+	client.BaseURL = "http://127.0.0.1:1/v1"
+	client.HTTPClient = &http.Client{Transport: rec}
+	for _, want := range []string{"first", "second", "second"} {
+		if answer, err := ask(t, client, "hi"); err != nil || answer != want {
+			t.Fatalf("unexpected replayed answer %q, %v", answer, err)
+		}
+	}
+
+	if _, err := ask(t, client, "unknown"); err == nil {
+		t.Error("expected error for request missing in cassette")
+	}
+}
+
+func TestReplayDefaultClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	body := `{"model":"m","messages":[{"role":"user","content":"hi"}],"temperature":0.3}`
+	cassette := `{"interactions":[{"method":"POST","path":"/api/v1/chat/completions","request":` +
+		strconv.Quote(body) + `,"status":200,"response":` + strconv.Quote(Completion("hello")) + `}]}`
+	if err := os.WriteFile(path, []byte(cassette), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// api.GetAnswer использует http.DefaultClient
+	transport := http.DefaultClient.Transport
+	http.DefaultClient.Transport = rec
+	defer func() { http.DefaultClient.Transport = transport }()
+
+	var answer string
+	if err := api.GetAnswer("", "m", []*api.Message{{Text: "hi", IsUser: true}}, &answer); err != nil || answer != "hello" {
+		t.Fatalf("unexpected answer %q, %v", answer, err)
+	}
+}
@@ -0,0 +1,186 @@
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RecordEnv - переменная окружения, включающая запись кассет вместо воспроизведения
+const RecordEnv = "AIFMT_RECORD"
+
+// Режимы работы Recorder
+const (
+	ModeReplay = "replay" // Ответы берутся из кассеты, запросы не отправляются
+	ModeRecord = "record" // Запросы отправляются, ответы записываются в кассету
+)
+
+// Interaction - записанный запрос и ответ
+type Interaction struct {
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Request  string `json:"request"`
+	Status   int    `json:"status"`
+	Response string `json:"response"`
+}
+
+// Cassette - набор записанных запросов и ответов
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Recorder - транспорт HTTP, который записывает запросы и ответы в кассету или воспроизводит их.
+// Запросы сопоставляются по методу и телу. Одинаковые запросы воспроизводятся в порядке записи,
+// после чего повторяется последний ответ. Заголовки, включая API ключ, не записываются
+type Recorder struct {
+	Path      string            // Путь к файлу кассеты
+	Mode      string            // Режим работы: ModeReplay или ModeRecord
+	Transport http.RoundTripper // Транспорт для режима записи, по умолчанию http.DefaultTransport
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     map[*Interaction]bool
+}
+
+// NewRecorder создает транспорт для кассеты. Режим записи включается переменной
+// окружения AIFMT_RECORD=1, иначе кассета загружается для воспроизведения
+func NewRecorder(path string) (*Recorder, error) {
+	r := &Recorder{Path: path, Mode: ModeReplay, used: make(map[*Interaction]bool)}
+	if os.Getenv(RecordEnv) == "1" {
+		r.Mode = ModeRecord
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения кассеты %s (для записи задайте %s=1): %w", path, RecordEnv, err)
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("ошибка разбора кассеты %s: %w", path, err)
+	}
+
+	return r, nil
+}
+
+// RoundTrip выполняет или воспроизводит запрос
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	if r.Mode == ModeRecord {
+		return r.record(req, body)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := canonical(body)
+	var match *Interaction
+	for _, in := range r.cassette.Interactions {
+		if in.Method != req.Method || canonical([]byte(in.Request)) != key {
+			continue
+		}
+		match = in
+		if !r.used[in] {
+			break
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("в кассете %s нет записи для запроса %s %s", r.Path, req.Method, req.URL.Path)
+	}
+	r.used[match] = true
+
+	return response(req, match.Status, match.Response), nil
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+
+	res, err := transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Method:   req.Method,
+		Path:     req.URL.Path,
+		Request:  string(body),
+		Status:   res.StatusCode,
+		Response: string(data),
+	})
+	r.mu.Unlock()
+
+	return response(req, res.StatusCode, string(data)), nil
+}
+
+// Save записывает кассету на диск. В режиме воспроизведения ничего не делает
+func (r *Recorder) Save() error {
+	if r.Mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.cassette.Interactions) == 0 {
+		return errors.New("нет записанных запросов")
+	}
+
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.Path, append(data, '\n'), 0644)
+}
+
+// canonical приводит JSON тело запроса к виду с отсортированными ключами для сравнения
+func canonical(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func response(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader([]byte(body))),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
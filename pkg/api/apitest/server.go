@@ -0,0 +1,177 @@
+// Package apitest предоставляет фейковый сервер API, совместимого с OpenAI Chat Completions,
+// и транспорт с записью и воспроизведением запросов для тестов без обращения к реальным моделям
+package apitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seelentov/aifmt/pkg/api"
+)
+
+// Message - сообщение диалога в запросе
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request - запрос, полученный фейковым сервером
+type Request struct {
+	Model       string      `json:"model"`
+	Messages    []Message   `json:"messages"`
+	Temperature float64     `json:"temperature"`
+	Header      http.Header `json:"-"`
+}
+
+// Prompt возвращает текст первого сообщения пользователя
+func (r *Request) Prompt() string {
+	if len(r.Messages) == 0 {
+		return ""
+	}
+	return r.Messages[0].Content
+}
+
+// Response - ответ фейкового сервера
+type Response struct {
+	Status  int           // Код ответа, по умолчанию 200
+	Content string        // Содержимое сообщения модели
+	Body    string        // Тело ответа целиком, если задано, заменяет Content
+	Delay   time.Duration // Задержка перед ответом, прерывается при отмене запроса
+}
+
+// Handler формирует ответ на запрос
+type Handler func(req *Request) Response
+
+// Server - фейковый сервер API моделей
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	handler  Handler
+	requests []*Request
+}
+
+// NewServer запускает фейковый сервер. Запросы к пути, оканчивающемуся на /chat/completions,
+// передаются обработчику, остальные завершаются кодом 404
+func NewServer(handler Handler) *Server {
+	s := &Server{handler: handler}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// BaseURL возвращает адрес API сервера для api.Client и конфигурации aifmt
+func (s *Server) BaseURL() string {
+	return s.URL + "/v1"
+}
+
+// Client возвращает клиент API, настроенный на сервер
+func (s *Server) Client() *api.Client {
+	client := api.NewClient("test")
+	client.BaseURL = s.BaseURL()
+	client.HTTPClient = s.Server.Client()
+	return client
+}
+
+// Requests возвращает полученные запросы
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Request(nil), s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+		http.NotFound(w, r)
+		return
+	}
+
+	req := &Request{Header: r.Header.Clone()}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	handler := s.handler
+	s.mu.Unlock()
+
+	res := handler(req)
+
+	if res.Delay > 0 {
+		select {
+		case <-time.After(res.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	status := res.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	body := res.Body
+	if body == "" {
+		body = Completion(res.Content)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprint(w, body)
+}
+
+// Completion возвращает тело ответа API с одним сообщением модели
+func Completion(content string) string {
+	data, _ := json.Marshal(map[string]interface{}{
+		"choices": []interface{}{
+			map[string]interface{}{"message": Message{Role: "assistant", Content: content}},
+		},
+	})
+	return string(data)
+}
+
+// Update - изменение в ответе модели на запрос форматирования
+type Update struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// Formatted возвращает содержимое ответа модели на запрос форматирования в формате,
+// который ожидает aifmt
+func Formatted(code string, updates ...Update) string {
+	if updates == nil {
+		updates = []Update{}
+	}
+	data, _ := json.Marshal(map[string]interface{}{"code": code, "updates": updates})
+	return string(data)
+}
+
+// Reply возвращает обработчик, который всегда отвечает указанным содержимым
+func Reply(content string) Handler {
+	return func(*Request) Response {
+		return Response{Content: content}
+	}
+}
+
+// Sequence возвращает обработчик, который отвечает по очереди указанными ответами.
+// После последнего ответа он повторяется
+func Sequence(responses ...Response) Handler {
+	var mu sync.Mutex
+	i := 0
+	return func(*Request) Response {
+		mu.Lock()
+		defer mu.Unlock()
+
+		res := responses[i]
+		if i < len(responses)-1 {
+			i++
+		}
+		return res
+	}
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// DefaultBaseURL - адрес API OpenRouter
+const DefaultBaseURL = "https://openrouter.ai/api/v1"
+
+// DefaultTemperature - температура генерации по умолчанию
+const DefaultTemperature = 0.3
+
+// DefaultHeaders - заголовки, которыми OpenRouter определяет приложение
+var DefaultHeaders = map[string]string{
+	"HTTP-Referer": "https://github.com/seelentov/aifmt",
+	"X-Title":      "aifmt",
+}
+
+// tlsVersions - поддерживаемые минимальные версии TLS
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Provider отправляет диалог модели и разбирает ответ в target. Строка *string получает текст
+// ответа как есть, остальные типы заполняются из JSON в ответе. Реализуется Client, в тестах
+// и при встраивании может быть заменен другой реализацией
+type Provider interface {
+	GetAnswerContext(ctx context.Context, model string, dialog []*Message, target interface{}) error
+}
+
+// Client - клиент API, совместимого с OpenAI Chat Completions (OpenRouter, OpenAI, Ollama и др.).
+// Клиент можно безопасно использовать из нескольких горутин
+type Client struct {
+	BaseURL     string            // Адрес API, по умолчанию DefaultBaseURL
+	Token       string            // API токен, может быть пустым для локальных моделей
+	Temperature float64           // Температура генерации
+	Headers     map[string]string // Дополнительные заголовки запросов
+	HTTPClient  *http.Client      // HTTP клиент, по умолчанию http.DefaultClient
+}
+
+// Config - настройки клиента API и HTTP соединения
+type Config struct {
+	BaseURL            string            // Адрес API
+	Token              string            // API токен
+	Temperature        float64           // Температура генерации
+	Headers            map[string]string // Дополнительные заголовки, заменяют DefaultHeaders с теми же именами
+	Timeout            time.Duration     // Время ожидания ответа на запрос, 0 - без ограничения
+	Proxy              string            // Адрес прокси-сервера, по умолчанию из переменных окружения HTTPS_PROXY и др.
+	CAFile             string            // Файл с дополнительными корневыми сертификатами в формате PEM
+	InsecureSkipVerify bool              // Не проверять сертификат сервера
+	TLSMinVersion      string            // Минимальная версия TLS: 1.0, 1.1, 1.2 или 1.3
+}
+
+// NewClient создает клиент OpenRouter с настройками по умолчанию
+func NewClient(token string) *Client {
+	return &Client{BaseURL: DefaultBaseURL, Token: token, Temperature: DefaultTemperature, Headers: DefaultHeaders}
+}
+
+// New создает клиент с отдельным HTTP клиентом, настроенным по конфигурации
+func New(cfg Config) (*Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.Proxy != "" {
+		proxy, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy address %q: %w", cfg.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+
+	if cfg.TLSMinVersion != "" {
+		version, ok := tlsVersions[cfg.TLSMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS version %q", cfg.TLSMinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the certificate file %s: %w", cfg.CAFile, err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("file %s contains no PEM certificates", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	headers := make(map[string]string, len(DefaultHeaders)+len(cfg.Headers))
+	for name, value := range DefaultHeaders {
+		headers[name] = value
+	}
+	for name, value := range cfg.Headers {
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		BaseURL:     baseURL,
+		Token:       cfg.Token,
+		Temperature: cfg.Temperature,
+		Headers:     headers,
+		HTTPClient:  &http.Client{Transport: transport, Timeout: cfg.Timeout},
+	}, nil
+}
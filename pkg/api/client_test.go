@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func answer(w http.ResponseWriter, content string) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"choices": []interface{}{
+			map[string]interface{}{"message": map[string]string{"role": "assistant", "content": content}},
+		},
+	})
+}
+
+func TestClientHeadersAndCA(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/gateway/v1/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("X-Title"); got != "custom" {
+			t.Errorf("X-Title = %q", got)
+		}
+		if got := r.Header.Get("HTTP-Referer"); got != DefaultHeaders["HTTP-Referer"] {
+			t.Errorf("HTTP-Referer = %q", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		answer(w, "ok")
+	}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New(Config{
+		BaseURL: srv.URL + "/gateway/v1/",
+		Token:   "token",
+		Headers: map[string]string{"x-title": "custom"},
+		CAFile:  caFile,
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res string
+	if err := client.GetAnswer("model", []*Message{{Text: "hi", IsUser: true}}, &res); err != nil {
+		t.Fatalf("GetAnswer failed: %v", err)
+	}
+	if res != "ok" {
+		t.Errorf("unexpected answer %q", res)
+	}
+}
+
+func TestClientContextCancel(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client, err := New(Config{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var res string
+	if err := client.GetAnswerContext(ctx, "model", nil, &res); err == nil {
+		t.Fatal("expected error after context cancellation")
+	}
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	if _, err := New(Config{TLSMinVersion: "2.0"}); err == nil {
+		t.Error("expected error for unsupported TLS version")
+	}
+	if _, err := New(Config{CAFile: "/nonexistent/ca.pem"}); err == nil {
+		t.Error("expected error for missing CA file")
+	}
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrInvalidResponse возвращается, если ответ модели не удалось разобрать
+var ErrInvalidResponse = errors.New("invalid model response")
+
+// StatusError - ошибка API с кодом ответа HTTP, отличным от 200
+type StatusError struct {
+	StatusCode int    // Код ответа HTTP
+	Body       string // Тело ответа
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("error response: %v %s", e.StatusCode, e.Body)
+}
+
+// IsRetryable сообщает, имеет ли смысл повторить запрос или отправить его другой модели.
+// Отмена запроса и ошибки авторизации не исправляются повтором. Превышение времени ожидания
+// ответа считается временной ошибкой, поэтому истечение срока контекста вызывающая сторона
+// проверяет сама
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode != http.StatusUnauthorized && statusErr.StatusCode != http.StatusForbidden
+	}
+
+	return true
+}
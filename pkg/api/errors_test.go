@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetAnswerErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		invalid   bool
+		status    int
+		retryable bool
+	}{
+		{
+			name:      "overloaded",
+			handler:   func(w http.ResponseWriter, r *http.Request) { http.Error(w, "overloaded", http.StatusTooManyRequests) },
+			status:    http.StatusTooManyRequests,
+			retryable: true,
+		},
+		{
+			name:    "unauthorized",
+			handler: func(w http.ResponseWriter, r *http.Request) { http.Error(w, "bad key", http.StatusUnauthorized) },
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:      "no choices",
+			handler:   func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, `{"choices":[]}`) },
+			invalid:   true,
+			retryable: true,
+		},
+		{
+			name:      "short invalid json",
+			handler:   func(w http.ResponseWriter, r *http.Request) { answer(w, "oops") },
+			invalid:   true,
+			retryable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			client := NewClient("token")
+			client.BaseURL = srv.URL
+
+			var target struct{ Code string }
+			err := client.GetAnswer("model", []*Message{{Text: "hi", IsUser: true}}, &target)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			if got := errors.Is(err, ErrInvalidResponse); got != tt.invalid {
+				t.Errorf("errors.Is(ErrInvalidResponse) = %v, want %v: %v", got, tt.invalid, err)
+			}
+
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) != (tt.status != 0) || (statusErr != nil && statusErr.StatusCode != tt.status) {
+				t.Errorf("unexpected status error: %v", err)
+			}
+
+			if got := IsRetryable(err); got != tt.retryable {
+				t.Errorf("IsRetryable = %v, want %v", got, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestIsRetryableCanceled(t *testing.T) {
+	if IsRetryable(fmt.Errorf("ошибка выполнения запроса: %w", context.Canceled)) {
+		t.Error("canceled request must not be retryable")
+	}
+	if IsRetryable(nil) {
+		t.Error("nil error must not be retryable")
+	}
+}
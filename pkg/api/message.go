@@ -0,0 +1,7 @@
+package api
+
+// Message - сообщение диалога с ИИ
+type Message struct {
+	Text   string `json:"text"`    // Текст сообщения
+	IsUser bool   `json:"is_user"` // Сообщение пользователя, иначе ответ модели
+}
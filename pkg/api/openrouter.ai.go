@@ -2,14 +2,13 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"reflect"
 	"strings"
-
-	"github.com/seelentov/aifmt/internal/entity"
 )
 
 type response struct {
@@ -26,14 +25,44 @@ type message struct {
 }
 
 // GetAnswer отправляет запрос к API OpenRouter и возвращает ответ
-func GetAnswer(token string, model string, dialog []*entity.Message, target interface{}) error {
+func GetAnswer(token string, model string, dialog []*Message, target interface{}) error {
+	return NewClient(token).GetAnswer(model, dialog, target)
+}
+
+// GetAnswer отправляет запрос к API и возвращает ответ
+func (c *Client) GetAnswer(model string, dialog []*Message, target interface{}) error {
+	return c.GetAnswerContext(context.Background(), model, dialog, target)
+}
+
+// GetAnswerContext отправляет запрос к API и возвращает ответ. Запрос прерывается при отмене контекста
+func (c *Client) GetAnswerContext(ctx context.Context, model string, dialog []*Message, target interface{}) error {
+	body, err := c.RequestBody(model, dialog)
+	if err != nil {
+		return err
+	}
+
+	resBody, err := c.Complete(ctx, body)
+	if err != nil {
+		return err
+	}
+
+	msg, err := ParseCompletion(resBody)
+	if err != nil {
+		return err
+	}
+
+	return Decode(msg, target)
+}
+
+// RequestBody возвращает тело запроса Chat Completions для диалога
+func (c *Client) RequestBody(model string, dialog []*Message) ([]byte, error) {
 	rb := struct {
 		Model       string     `json:"model"`
 		Messages    []*message `json:"messages"`
 		Temperature float64    `json:"temperature"`
 	}{
 		Model:       model,
-		Temperature: 0.3,
+		Temperature: c.Temperature,
 	}
 
 	// Преобразуем диалог в формат, понятный API
@@ -51,41 +80,91 @@ func GetAnswer(token string, model string, dialog []*entity.Message, target inte
 
 	bodyBytes, err := json.Marshal(rb)
 	if err != nil {
-		return fmt.Errorf("ошибка маршалинга тела запроса: %w", err)
+		return nil, fmt.Errorf("error encoding the request body: %w", err)
 	}
+	return bodyBytes, nil
+}
 
-	req, err := http.NewRequest("POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(bodyBytes))
+// Complete отправляет готовое тело запроса Chat Completions и возвращает тело ответа.
+// Для кода ответа, отличного от 200, возвращается *StatusError
+func (c *Client) Complete(ctx context.Context, body []byte) ([]byte, error) {
+	req, err := c.NewRequest(ctx, "POST", "/chat/completions", bytes.NewBuffer(body))
 	if err != nil {
-		return fmt.Errorf("ошибка создания запроса: %w", err)
+		return nil, err
 	}
+	req.Header.Set("Content-Type", "application/json;charset=utf-8")
 
-	req.Header.Add("Content-Type", "application/json;charset=utf-8")
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.Do(req)
 	if err != nil {
-		return fmt.Errorf("ошибка выполнения запроса: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	resBodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("ошибка чтения ответа: %w", err)
+		return nil, fmt.Errorf("error reading the response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("ошибка в ответе: %v %s", resp.StatusCode, resBodyBytes)
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(resBodyBytes)}
 	}
 
-	tempTarget := &response{}
-	err = json.Unmarshal(resBodyBytes, tempTarget)
+	return resBodyBytes, nil
+}
+
+// NewRequest создает запрос к API по пути относительно BaseURL с заголовками клиента и токеном
+func (c *Client) NewRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(baseURL, "/")+path, body)
 	if err != nil {
-		return fmt.Errorf("ошибка анмаршалинга ответа: %w", err)
+		return nil, fmt.Errorf("error creating the request: %w", err)
+	}
+
+	for name, value := range c.Headers {
+		req.Header.Set(name, value)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+	}
+
+	return req, nil
+}
+
+// Do выполняет запрос HTTP клиентом API
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
 	}
 
-	msg := tempTarget.Choices[len(tempTarget.Choices)-1].Message.Content
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending the request: %w", err)
+	}
+	return resp, nil
+}
 
+// ParseCompletion возвращает текст последнего сообщения из тела ответа Chat Completions
+func ParseCompletion(body []byte) (string, error) {
+	tempTarget := &response{}
+	if err := json.Unmarshal(body, tempTarget); err != nil {
+		return "", fmt.Errorf("%w: error decoding the response: %v", ErrInvalidResponse, err)
+	}
+
+	if len(tempTarget.Choices) == 0 || tempTarget.Choices[len(tempTarget.Choices)-1].Message == nil {
+		return "", fmt.Errorf("%w: the response contains no messages: %s", ErrInvalidResponse, abbreviate(string(body)))
+	}
+
+	return tempTarget.Choices[len(tempTarget.Choices)-1].Message.Content, nil
+}
+
+// Decode записывает текст ответа модели в target. Строка *string получает текст как есть,
+// остальные типы заполняются из JSON, в том числе обернутого в блок ```json
+func Decode(msg string, target interface{}) error {
 	// Обработка ответа в зависимости от типа целевого объекта
 	if reflect.TypeOf(target).String() == "*string" {
 		reflect.ValueOf(target).Elem().Set(reflect.ValueOf(msg))
@@ -95,10 +174,19 @@ func GetAnswer(token string, model string, dialog []*entity.Message, target inte
 	msg = strings.TrimPrefix(msg, "```json\n")
 	msg = strings.TrimSuffix(msg, "\n```")
 
-	err = json.Unmarshal([]byte(msg), &target)
+	err := json.Unmarshal([]byte(msg), &target)
 	if err != nil {
-		return fmt.Errorf("ошибка анмаршалинга: %w: %s", err, msg[0:20]+"...")
+		return fmt.Errorf("%w: decoding error: %v: %s", ErrInvalidResponse, err, abbreviate(msg))
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// abbreviate сокращает текст ответа для сообщения об ошибке
+func abbreviate(s string) string {
+	const limit = 20
+	if r := []rune(s); len(r) > limit {
+		return string(r[:limit]) + "..."
+	}
+	return s
+}